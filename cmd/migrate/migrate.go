@@ -2,14 +2,105 @@ package main
 
 import (
 	"customer-manager/database"
+	"customer-manager/database/migrations"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+const usage = `Usage: migrate <command> [arguments]
+
+Commands:
+  up                 apply all pending migrations (default)
+  down N             roll back N most recently applied migrations
+  status             list migrations and whether they are applied
+  create [-dir DIR] NAME
+                     create a new empty migration in DIR (default "database/migrations")
+`
+
+func getDatabase() *gorm.DB {
+	return database.GetDatabase(&gorm.Config{Logger: database.GetLogger(logger.Warn)})
+}
+
+func up() {
+	applied, err := migrations.Up(getDatabase())
+	for _, migration := range applied {
+		log.Printf("applied migration %d '%s'", migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(applied) == 0 {
+		log.Print("database is up to date")
+	}
+}
+
+func down(args []string) {
+	if len(args) != 1 {
+		log.Fatal("down requires number of migrations to roll back")
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		log.Fatalf("given number of migrations '%s' is not a positive integer", args[0])
+	}
+	rolledBack, err := migrations.Down(getDatabase(), steps)
+	for _, migration := range rolledBack {
+		log.Printf("rolled back migration %d '%s'", migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func status() {
+	statuses, err := migrations.Status(getDatabase())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, appliedAt)
+	}
+}
+
+func create(args []string) {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	dir := flags.String("dir", "database/migrations", "directory with migrations")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal("create requires migration name")
+	}
+	path, err := migrations.Create(*dir, flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("created migration %s", path)
+}
+
 func main() {
-	db := database.GetDatabase(&gorm.Config{Logger: database.GetLogger(logger.Info)})
-	if err := database.RunMigration(db, &database.Customer{}, &database.Purchase{}, &database.Repair{}); err != nil {
-		panic("failed during performing migrations")
+	command, args := "up", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+	switch command {
+	case "up":
+		up()
+	case "down":
+		down(args)
+	case "status":
+		status()
+	case "create":
+		create(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"customer-manager/database"
+	"customer-manager/repositories"
+	"flag"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// defaultRetention is how long soft deleted records can still be restored before they are purged.
+const defaultRetention = 90 * 24 * time.Hour
+
+func main() {
+	retention := flag.Duration("retention", defaultRetention, "purge records deleted longer than this ago")
+	flag.Parse()
+	if *retention < 0 {
+		log.Fatalf("given retention '%s' must not be negative", *retention)
+	}
+
+	db := database.GetDatabase(&gorm.Config{Logger: database.GetLogger(logger.Warn)})
+	before := time.Now().UTC().Add(-*retention)
+	err, purged := repositories.PurgeDeleted(context.Background(), db, before)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf(
+		"purged records deleted before %s: %d customers, %d purchases, %d repairs, %d prescriptions, "+
+			"%d payments, kept %d invoiced records",
+		before.Format(time.RFC3339),
+		purged.Customers,
+		purged.Purchases,
+		purged.Repairs,
+		purged.Prescriptions,
+		purged.Payments,
+		purged.KeptInvoiced,
+	)
+}
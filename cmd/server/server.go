@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"customer-manager/database"
 	"customer-manager/repositories"
 	"customer-manager/server"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -19,15 +22,52 @@ func getServerPort() string {
 	return ":" + port
 }
 
+func getQueryTimeout() time.Duration {
+	timeout := os.Getenv("QUERY_TIMEOUT")
+	if timeout == "" {
+		return server.DefaultQueryTimeout
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		panic(fmt.Sprintf("invalid QUERY_TIMEOUT '%s', expected duration like '5s'", timeout))
+	}
+	return duration
+}
+
+func getReorderInterval() time.Duration {
+	interval := os.Getenv("REORDER_INTERVAL")
+	if interval == "" {
+		return server.DefaultReorderInterval
+	}
+	duration, err := time.ParseDuration(interval)
+	if err != nil || duration <= 0 {
+		panic(fmt.Sprintf("invalid REORDER_INTERVAL '%s', expected positive duration like '1h'", interval))
+	}
+	return duration
+}
+
 func main() {
-	app := fiber.New(fiber.Config{Network: fiber.NetworkTCP})
+	app := fiber.New(fiber.Config{Network: fiber.NetworkTCP, ErrorHandler: server.ErrorHandler})
 	db := database.GetDatabase(&gorm.Config{Logger: database.GetLogger(logger.Info)})
 	customerManagerServer := server.NewCustomerManagerServer(
 		app,
 		&repositories.DBCustomerRepository{DB: db},
 		&repositories.DBPurchaseRepository{DB: db},
 		&repositories.DBRepairRepository{DB: db},
+		&repositories.DBPrescriptionRepository{DB: db},
+		&repositories.DBInvoiceRepository{DB: db},
+		&repositories.DBPaymentRepository{DB: db},
+		&repositories.DBCatalogRepository[database.Frame]{DB: db},
+		&repositories.DBCatalogRepository[database.LensType]{DB: db},
+		&repositories.DBInventoryRepository{DB: db},
+		&repositories.DBAuditRepository{DB: db},
+		&repositories.DBUnitOfWork{DB: db},
 	)
+	customerManagerServer.QueryTimeout = getQueryTimeout()
+	customerManagerServer.TelephoneRegion = database.GetTelephoneRegion()
+	customerManagerServer.Currency = database.GetCurrency()
+	customerManagerServer.Seller = database.GetSeller()
+	customerManagerServer.StartReorderJob(context.Background(), getReorderInterval())
 
 	panic(customerManagerServer.App.Listen(getServerPort()))
 }
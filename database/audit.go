@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+type AuditEntity string
+
+const (
+	AuditCustomer AuditEntity = "customer"
+	AuditPurchase AuditEntity = "purchase"
+	AuditRepair   AuditEntity = "repair"
+	AuditInvoice  AuditEntity = "invoice"
+	AuditPayment  AuditEntity = "payment"
+	AuditFrame    AuditEntity = "frame"
+	AuditLensType AuditEntity = "lens_type"
+)
+
+// FieldChange holds JSON values of a single field before and after the change, nil when the field is absent.
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditChanges maps JSON field names of the entity to their changes, it is stored as a JSON document.
+type AuditChanges map[string]FieldChange
+
+func (a AuditChanges) Value() (driver.Value, error) {
+	value, err := json.Marshal(a)
+	return string(value), err
+}
+
+func (a *AuditChanges) Scan(value any) error {
+	*a = nil
+	switch data := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(data), a)
+	case []byte:
+		return json.Unmarshal(data, a)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into audit changes", value)
+	}
+}
+
+func (AuditChanges) GormDataType() string {
+	return "text"
+}
+
+// AuditEntry records a single change of an entity made by the actor.
+type AuditEntry struct {
+	ID         string       `gorm:"primaryKey"     json:"id"`
+	Action     AuditAction  `gorm:"size:16"        json:"action"`
+	EntityType AuditEntity  `gorm:"size:32"        json:"entity_type"`
+	EntityID   string       `gorm:"size:256;index" json:"entity_id"`
+	Actor      string       `gorm:"size:256;index" json:"actor"`
+	Changes    AuditChanges `                      json:"changes"`
+	CreatedAt  time.Time    `gorm:"index"          json:"created_at"`
+}
+
+func (a *AuditEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.NewString()
+	return
+}
+
+// auditIgnoredFields change on every write and would only add noise to the diff.
+var auditIgnoredFields = []string{"updated_at", "version"}
+
+func toJSONFields(entity any) (map[string]any, error) {
+	fields := map[string]any{}
+	if entity == nil {
+		return fields, nil
+	}
+	if value := reflect.ValueOf(entity); value.Kind() == reflect.Pointer && value.IsNil() {
+		return fields, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	return fields, json.Unmarshal(data, &fields)
+}
+
+// Diff compares JSON representations of the entity before and after the change and returns changed fields.
+// Either of them can be nil, e.g. before is nil for created entities.
+func Diff(before any, after any) (AuditChanges, error) {
+	oldFields, err := toJSONFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := toJSONFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := AuditChanges{}
+	for field, value := range newFields {
+		if !reflect.DeepEqual(oldFields[field], value) {
+			changes[field] = FieldChange{Old: oldFields[field], New: value}
+		}
+	}
+	for field, value := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			changes[field] = FieldChange{Old: value}
+		}
+	}
+	for _, field := range auditIgnoredFields {
+		delete(changes, field)
+	}
+	return changes, nil
+}
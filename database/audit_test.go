@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	customer := &Customer{ID: "customerID", FirstName: "John", LastName: "Doe", TelephoneNumber: "123"}
+
+	t.Run("test diff of created entity", func(t *testing.T) {
+		changes, err := Diff(nil, customer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, FieldChange{New: "John"}, changes["first_name"])
+		assert.Equal(t, FieldChange{New: "0001-01-01T00:00:00Z"}, changes["created_at"])
+		assert.NotContains(t, changes, "deleted_at", "null fields should not be recorded as changed")
+		assert.NotContains(t, changes, "updated_at")
+	})
+
+	t.Run("test diff of updated entity", func(t *testing.T) {
+		updatedCustomer := *customer
+		updatedCustomer.TelephoneNumber = "321"
+		updatedCustomer.UpdatedAt = time.Now()
+
+		changes, err := Diff(customer, &updatedCustomer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, AuditChanges{"telephone_number": {Old: "123", New: "321"}}, changes)
+	})
+
+	t.Run("test diff of deleted entity", func(t *testing.T) {
+		changes, err := Diff(customer, (*Customer)(nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, FieldChange{Old: "Doe"}, changes["last_name"])
+		assert.Equal(t, FieldChange{Old: "customerID"}, changes["id"])
+	})
+
+	t.Run("test diff of nested fields", func(t *testing.T) {
+		purchase := &Purchase{LensPower: LensPower{Right: EyePrescription{Sphere: -1}}}
+		updatedPurchase := &Purchase{LensPower: LensPower{Right: EyePrescription{Sphere: -1.5}}}
+
+		changes, err := Diff(purchase, updatedPurchase)
+
+		assert.NoError(t, err)
+		assert.Contains(t, changes, "lens_power")
+		assert.Len(t, changes, 1)
+	})
+}
+
+func TestAuditChanges(t *testing.T) {
+	t.Run("test stored changes can be read back", func(t *testing.T) {
+		changes := AuditChanges{"status": {Old: "reported", New: "in_progress"}}
+		value, err := changes.Value()
+		assert.NoError(t, err)
+
+		var scanned AuditChanges
+		assert.NoError(t, scanned.Scan(value))
+		assert.Equal(t, changes, scanned)
+		assert.NoError(t, scanned.Scan([]byte(`{"cost": {"old": 1, "new": null}}`)))
+		assert.Equal(t, AuditChanges{"cost": {Old: 1.0}}, scanned)
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product holds what frames and lens types of the catalog have in common. The SKU is the code the product
+// is known by in the shop, while the barcode is the one printed by the manufacturer, if any.
+// Stock is the quantity on hand, it is negative when more was sold on backorder than delivered.
+// The product is reordered from its supplier when the stock is expected to fall below the reorder threshold.
+type Product struct {
+	ID               string    `gorm:"primaryKey"          json:"id"`
+	SKU              string    `gorm:"size:64;uniqueIndex" json:"sku"`
+	Barcode          *string   `gorm:"size:64;uniqueIndex" json:"barcode"`
+	Supplier         string    `gorm:"size:128"            json:"supplier"`
+	Stock            int       `gorm:"not null;default:0"  json:"stock"`
+	ReorderThreshold int       `gorm:"not null;default:0"  json:"reorder_threshold"`
+	CreatedAt        time.Time `                           json:"created_at"`
+	UpdatedAt        time.Time `                           json:"updated_at"`
+}
+
+func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	return
+}
+
+// GetProduct returns the product details of a frame or a lens type.
+func (p *Product) GetProduct() *Product {
+	return p
+}
+
+// Frame is a spectacle frame model of the catalog in a single color and size, e.g. "51-21-145" for the lens
+// width, the bridge width and the temple length in millimeters.
+type Frame struct {
+	Product
+	Brand string `gorm:"size:128" json:"brand"`
+	Model string `gorm:"size:128" json:"model"`
+	Color string `gorm:"size:64"  json:"color"`
+	Size  string `gorm:"size:32"  json:"size"`
+}
+
+// Description names the frame the way it is written down as the frame model of purchases.
+func (f *Frame) Description() string {
+	return strings.Join(strings.Fields(strings.Join([]string{f.Brand, f.Model, f.Color, f.Size}, " ")), " ")
+}
+
+// LensType is a kind of spectacle lenses of the catalog, e.g. single vision polycarbonate lenses with
+// an anti-reflective coating. Lenses are stocked and sold in pairs.
+type LensType struct {
+	Product
+	Name     string `gorm:"size:128" json:"name"`
+	Material string `gorm:"size:64"  json:"material"`
+	Coating  string `gorm:"size:64"  json:"coating"`
+}
+
+// Description names the lens type the way it is written down as the lens type of purchases.
+func (l *LensType) Description() string {
+	return strings.Join(strings.Fields(strings.Join([]string{l.Name, l.Material, l.Coating}, " ")), " ")
+}
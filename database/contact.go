@@ -0,0 +1,35 @@
+package database
+
+// ContactChannel is the way the customer prefers to be contacted in, e.g. when their order is ready.
+type ContactChannel string
+
+const (
+	ContactTelephone ContactChannel = "telephone"
+	ContactSMS       ContactChannel = "sms"
+	ContactEmail     ContactChannel = "email"
+	ContactPost      ContactChannel = "post"
+)
+
+var ContactChannels = []ContactChannel{ContactTelephone, ContactSMS, ContactEmail, ContactPost}
+
+func (c ContactChannel) IsValid() bool {
+	for _, channel := range ContactChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Address is a postal address, Country holds ISO 3166-1 alpha-2 code.
+type Address struct {
+	Street     string `                json:"street"`
+	City       string `                json:"city"`
+	PostalCode string `gorm:"size:16" json:"postal_code"`
+	Country    string `gorm:"size:2"  json:"country"`
+}
+
+// IsEmpty tells whether no part of the address is given.
+func (a Address) IsEmpty() bool {
+	return a == Address{}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvoiceParty is the seller or the buyer named on the invoice. TaxID is the tax identification number,
+// e.g. NIP, which private customers do not have.
+type InvoiceParty struct {
+	Name    string  `                                        json:"name"`
+	TaxID   string  `gorm:"size:32"                          json:"tax_id"`
+	Address Address `gorm:"embedded;embeddedPrefix:address_" json:"address"`
+}
+
+// GetSeller returns the seller issuing invoices, set in SELLER_NAME, SELLER_TAX_ID, SELLER_STREET, SELLER_CITY,
+// SELLER_POSTAL_CODE and SELLER_COUNTRY environment variables.
+func GetSeller() InvoiceParty {
+	return InvoiceParty{
+		Name:  os.Getenv("SELLER_NAME"),
+		TaxID: os.Getenv("SELLER_TAX_ID"),
+		Address: Address{
+			Street:     os.Getenv("SELLER_STREET"),
+			City:       os.Getenv("SELLER_CITY"),
+			PostalCode: os.Getenv("SELLER_POSTAL_CODE"),
+			Country:    strings.ToUpper(os.Getenv("SELLER_COUNTRY")),
+		},
+	}
+}
+
+// Invoice is issued to a customer for their purchases and repairs. Invoices are numbered with a sequence
+// which starts over every year and has no gaps, so they are never deleted. Seller, buyer and items are
+// copied when the invoice is issued, later changes of the customer, purchases or repairs do not alter it.
+type Invoice struct {
+	ID         string        `gorm:"primaryKey"                                 json:"id"`
+	Number     string        `gorm:"size:32;uniqueIndex"                        json:"number"`
+	Year       int           `gorm:"not null;uniqueIndex:uniqueInvoiceSequence" json:"year"`
+	Sequence   int           `gorm:"not null;uniqueIndex:uniqueInvoiceSequence" json:"sequence"`
+	CustomerID string        `gorm:"size:256;index"                             json:"customer_id"`
+	Seller     InvoiceParty  `gorm:"embedded;embeddedPrefix:seller_"            json:"seller"`
+	Buyer      InvoiceParty  `gorm:"embedded;embeddedPrefix:buyer_"             json:"buyer"`
+	IssuedAt   time.Time     `gorm:"type:date"                                  json:"issued_at"`
+	Net        Money         `gorm:"embedded;embeddedPrefix:net_"               json:"net"`
+	VAT        Money         `gorm:"embedded;embeddedPrefix:vat_"               json:"vat"`
+	Total      Money         `gorm:"embedded;embeddedPrefix:total_"             json:"total"`
+	Items      []InvoiceItem `gorm:"foreignKey:InvoiceID"                       json:"items"`
+	CreatedAt  time.Time     `                                                  json:"created_at"`
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.NewString()
+	return
+}
+
+// SetNumber numbers the invoice with the sequence number within the year it is issued in, e.g. "FV/2024/0001".
+func (i *Invoice) SetNumber(sequence int) {
+	i.Year = i.IssuedAt.Year()
+	i.Sequence = sequence
+	i.Number = fmt.Sprintf("FV/%d/%04d", i.Year, sequence)
+}
+
+// CalculateTotals sums amounts of the items, which have to be in the same currency.
+func (i *Invoice) CalculateTotals() {
+	currency := ""
+	if len(i.Items) > 0 {
+		currency = i.Items[0].Total.Currency
+	}
+	i.Net, i.VAT, i.Total = Money{Currency: currency}, Money{Currency: currency}, Money{Currency: currency}
+	for _, item := range i.Items {
+		i.Net = i.Net.Add(item.Net)
+		i.VAT = i.VAT.Add(item.VAT)
+		i.Total = i.Total.Add(item.Total)
+	}
+}
+
+// InvoiceItem is a line of the invoice for a single purchase or repair, which can be invoiced only once.
+// The unit price includes VAT, the total is the unit price less the discount and the net amount is the total
+// less VAT included in it.
+type InvoiceItem struct {
+	ID          string  `gorm:"primaryKey"                          json:"id"`
+	InvoiceID   string  `gorm:"size:256;index"                      json:"invoice_id"`
+	Position    int     `gorm:"not null"                            json:"position"`
+	PurchaseID  *string `gorm:"size:256;uniqueIndex"                json:"purchase_id"`
+	RepairID    *string `gorm:"size:256;uniqueIndex"                json:"repair_id"`
+	Description string  `                                           json:"description"`
+	UnitPrice   Money   `gorm:"embedded;embeddedPrefix:unit_price_" json:"unit_price"`
+	Discount    Money   `gorm:"embedded;embeddedPrefix:discount_"   json:"discount"`
+	VATRate     int     `gorm:"not null;default:0"                  json:"vat_rate"`
+	Net         Money   `gorm:"embedded;embeddedPrefix:net_"        json:"net"`
+	VAT         Money   `gorm:"embedded;embeddedPrefix:vat_"        json:"vat"`
+	Total       Money   `gorm:"embedded;embeddedPrefix:total_"      json:"total"`
+}
+
+func (i *InvoiceItem) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.NewString()
+	return
+}
+
+func (i *InvoiceItem) calculateTotals() {
+	i.Total = i.UnitPrice.Sub(i.Discount)
+	i.VAT = i.Total.Fraction(int64(i.VATRate), int64(100+i.VATRate))
+	i.Net = i.Total.Sub(i.VAT)
+}
+
+// NewPurchaseInvoiceItem returns the item invoicing the purchase at its price, discount and VAT rate.
+func NewPurchaseInvoiceItem(purchase *Purchase) InvoiceItem {
+	item := InvoiceItem{
+		PurchaseID:  &purchase.ID,
+		Description: strings.Join([]string{purchase.FrameModel, purchase.LensType}, ", "),
+		UnitPrice:   purchase.Price,
+		Discount:    Money{Currency: purchase.Price.Currency},
+		VATRate:     purchase.VATRate,
+	}
+	if !purchase.Discount.IsZero() {
+		item.Discount = purchase.Discount
+	}
+	item.calculateTotals()
+	return item
+}
+
+// NewRepairInvoiceItem returns the item invoicing the repair at its cost, which includes VAT at the given rate,
+// as repairs do not have a VAT rate of their own.
+func NewRepairInvoiceItem(repair *Repair, vatRate int) InvoiceItem {
+	item := InvoiceItem{
+		RepairID:    &repair.ID,
+		Description: repair.Description,
+		UnitPrice:   repair.Cost,
+		Discount:    Money{Currency: repair.Cost.Currency},
+		VATRate:     vatRate,
+	}
+	item.calculateTotals()
+	return item
+}
+
+// InvoiceSequence holds the last number given to an invoice issued in the year.
+type InvoiceSequence struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int `gorm:"not null;default:0"`
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Databases created before versioned migrations were introduced already contain these tables,
+// AutoMigrate leaves them untouched, so such databases are adopted by this migration.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			type Purchase struct {
+				ID           string `gorm:"primaryKey"`
+				FrameModel   string
+				LensType     string
+				LensPower    string
+				PD           string
+				CustomerID   string `gorm:"size:256"`
+				PurchaseType string
+				PurchasedAt  time.Time `gorm:"type:date"`
+				CreatedAt    time.Time
+				UpdatedAt    time.Time
+			}
+			type Repair struct {
+				ID          string `gorm:"primaryKey"`
+				Description string
+				Cost        float64 `gorm:"precision:2"`
+				CustomerID  string  `gorm:"size:256"`
+				CreatedAt   time.Time
+				ReportedAt  time.Time `gorm:"type:date"`
+			}
+			type Customer struct {
+				ID              string `gorm:"primaryKey"`
+				FirstName       string
+				LastName        string
+				TelephoneNumber string `gorm:"uniqueIndex:uniqueTelephoneNumber;size:256"`
+				CreatedAt       time.Time
+				UpdatedAt       time.Time
+				Purchases       []Purchase `gorm:"foreignKey:CustomerID;"`
+				Repairs         []Repair   `gorm:"foreignKey:CustomerID;"`
+			}
+			return tx.AutoMigrate(&Customer{}, &Purchase{}, &Repair{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("purchases", "repairs", "customers")
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package migrations
+
+import (
+	"customer-manager/database"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type eyePrescription struct {
+	Sphere   float64 `gorm:"precision:5;scale:2"`
+	Cylinder float64 `gorm:"precision:5;scale:2"`
+	Axis     int
+	Add      float64 `gorm:"precision:5;scale:2"`
+	Prism    float64 `gorm:"precision:5;scale:2"`
+	Base     string  `gorm:"size:2"`
+}
+
+type lensPower struct {
+	Right eyePrescription `gorm:"embedded;embeddedPrefix:right_"`
+	Left  eyePrescription `gorm:"embedded;embeddedPrefix:left_"`
+}
+
+type pupillaryDistance struct {
+	Binocular float64 `gorm:"precision:4;scale:1"`
+	Right     float64 `gorm:"precision:4;scale:1"`
+	Left      float64 `gorm:"precision:4;scale:1"`
+}
+
+func getPrescriptionColumns() []string {
+	columns := []string{"pd_binocular", "pd_right", "pd_left"}
+	for _, eye := range []string{"right", "left"} {
+		for _, field := range []string{"sphere", "cylinder", "axis", "add", "prism", "base"} {
+			columns = append(columns, fmt.Sprintf("lens_power_%s_%s", eye, field))
+		}
+	}
+	return columns
+}
+
+// migrateLegacyPrescriptions converts free-text "lens_power" and "pd" purchase columns into
+// structured prescription columns. Values which cannot be parsed are left empty, original
+// columns are kept as "legacy_lens_power" and "legacy_pd" so no data is lost.
+func migrateLegacyPrescriptions(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasColumn(&purchases{}, "lens_power") {
+		return nil
+	}
+	var legacyPurchases []struct {
+		ID        string
+		LensPower string
+		PD        string
+	}
+	if err := tx.Table("purchases").Select("id", "lens_power", "pd").Scan(&legacyPurchases).Error; err != nil {
+		return err
+	}
+	type Purchase struct {
+		LensPower database.LensPower         `gorm:"embedded;embeddedPrefix:lens_power_"`
+		PD        database.PupillaryDistance `gorm:"embedded;embeddedPrefix:pd_"`
+	}
+	for _, legacy := range legacyPurchases {
+		purchase := Purchase{}
+		lensPower, lensPowerParsed := database.ParseLensPower(legacy.LensPower)
+		if lensPowerParsed {
+			purchase.LensPower = lensPower
+		}
+		pd, pdParsed := database.ParsePupillaryDistance(legacy.PD)
+		if pdParsed {
+			purchase.PD = pd
+		}
+		if !lensPowerParsed && !pdParsed {
+			continue
+		}
+		if err := tx.Table("purchases").Where("id = ?", legacy.ID).Updates(&purchase).Error; err != nil {
+			return err
+		}
+	}
+	if err := migrator.RenameColumn(&purchases{}, "lens_power", "legacy_lens_power"); err != nil {
+		return err
+	}
+	return migrator.RenameColumn(&purchases{}, "pd", "legacy_pd")
+}
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "structured_prescriptions",
+		Up: func(tx *gorm.DB) error {
+			type Purchase struct {
+				ID             string            `gorm:"primaryKey"`
+				LensPower      lensPower         `gorm:"embedded;embeddedPrefix:lens_power_"`
+				PD             pupillaryDistance `gorm:"embedded;embeddedPrefix:pd_"`
+				PrescriptionID *string           `gorm:"size:256;index"`
+			}
+			type Prescription struct {
+				ID         string            `gorm:"primaryKey"`
+				LensPower  lensPower         `gorm:"embedded;embeddedPrefix:lens_power_"`
+				PD         pupillaryDistance `gorm:"embedded;embeddedPrefix:pd_"`
+				IssuedBy   string
+				IssuedAt   time.Time `gorm:"type:date"`
+				ExpiresAt  time.Time `gorm:"type:date"`
+				CustomerID string    `gorm:"size:256"`
+				CreatedAt  time.Time
+				UpdatedAt  time.Time
+			}
+			type Customer struct {
+				ID            string         `gorm:"primaryKey"`
+				Prescriptions []Prescription `gorm:"foreignKey:CustomerID;"`
+			}
+			if err := tx.AutoMigrate(&Purchase{}, &Prescription{}, &Customer{}); err != nil {
+				return err
+			}
+			return migrateLegacyPrescriptions(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			for _, column := range [][2]string{{"legacy_lens_power", "lens_power"}, {"legacy_pd", "pd"}} {
+				if !migrator.HasColumn(&purchases{}, column[0]) {
+					continue
+				}
+				if err := migrator.RenameColumn(&purchases{}, column[0], column[1]); err != nil {
+					return err
+				}
+			}
+			if err := migrator.DropIndex(&purchases{}, "idx_purchases_prescription_id"); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, "purchases", append(getPrescriptionColumns(), "prescription_id")...); err != nil {
+				return err
+			}
+			return migrator.DropTable("prescriptions")
+		},
+	})
+}
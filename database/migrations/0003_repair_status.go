@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "repair_status",
+		Up: func(tx *gorm.DB) error {
+			type Repair struct {
+				ID                string  `gorm:"primaryKey"`
+				Cost              float64 `gorm:"precision:10;scale:2"`
+				UpdatedAt         time.Time
+				Status            string `gorm:"size:32;index;default:reported"`
+				InProgressAt      *time.Time
+				WaitingForPartsAt *time.Time
+				ReadyForPickupAt  *time.Time
+				CollectedAt       *time.Time
+				CancelledAt       *time.Time
+			}
+			return tx.AutoMigrate(&Repair{})
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			if err := migrator.DropIndex(&repairs{}, "idx_repairs_status"); err != nil {
+				return err
+			}
+			return dropColumns(
+				tx,
+				"repairs",
+				"updated_at",
+				"status",
+				"in_progress_at",
+				"waiting_for_parts_at",
+				"ready_for_pickup_at",
+				"collected_at",
+				"cancelled_at",
+			)
+		},
+	})
+}
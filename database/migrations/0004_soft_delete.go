@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "soft_delete",
+		Up: func(tx *gorm.DB) error {
+			type Customer struct {
+				ID        string         `gorm:"primaryKey"`
+				DeletedAt gorm.DeletedAt `gorm:"index"`
+			}
+			type Purchase struct {
+				ID        string         `gorm:"primaryKey"`
+				DeletedAt gorm.DeletedAt `gorm:"index"`
+			}
+			type Repair struct {
+				ID        string         `gorm:"primaryKey"`
+				DeletedAt gorm.DeletedAt `gorm:"index"`
+			}
+			return tx.AutoMigrate(&Customer{}, &Purchase{}, &Repair{})
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			for _, table := range []schema.Tabler{&customers{}, &purchases{}, &repairs{}} {
+				if err := migrator.DropIndex(table, fmt.Sprintf("idx_%s_deleted_at", table.TableName())); err != nil {
+					return err
+				}
+				if err := dropColumns(tx, table.TableName(), "deleted_at"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
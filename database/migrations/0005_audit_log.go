@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "audit_log",
+		Up: func(tx *gorm.DB) error {
+			type AuditEntry struct {
+				ID         string    `gorm:"primaryKey"`
+				Action     string    `gorm:"size:16"`
+				EntityType string    `gorm:"size:32"`
+				EntityID   string    `gorm:"size:256;index"`
+				Actor      string    `gorm:"size:256;index"`
+				Changes    string    `gorm:"type:text"`
+				CreatedAt  time.Time `gorm:"index"`
+			}
+			return tx.AutoMigrate(&AuditEntry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("audit_entries")
+		},
+	})
+}
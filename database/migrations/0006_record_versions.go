@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "record_versions",
+		Up: func(tx *gorm.DB) error {
+			type Customer struct {
+				ID      string `gorm:"primaryKey"`
+				Version int    `gorm:"not null;default:1"`
+			}
+			type Purchase struct {
+				ID      string `gorm:"primaryKey"`
+				Version int    `gorm:"not null;default:1"`
+			}
+			type Repair struct {
+				ID      string `gorm:"primaryKey"`
+				Version int    `gorm:"not null;default:1"`
+			}
+			return tx.AutoMigrate(&Customer{}, &Purchase{}, &Repair{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range []schema.Tabler{&customers{}, &purchases{}, &repairs{}} {
+				if err := dropColumns(tx, table.TableName(), "version"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
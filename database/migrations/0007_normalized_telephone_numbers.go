@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"customer-manager/database"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// normalizeTelephoneNumbers fills in E.164 form of telephone numbers of existing customers, numbers
+// given without the country calling code are considered to belong to TELEPHONE_REGION. Displayed
+// numbers are kept as they were entered. Numbers which cannot be parsed, or which turn out to be
+// the same number as one of another customer, are left without the normalized form and logged.
+func normalizeTelephoneNumbers(tx *gorm.DB) error {
+	var customers []struct {
+		ID              string
+		TelephoneNumber string
+	}
+	err := tx.Table("customers").Select("id", "telephone_number").Order("created_at asc").Scan(&customers).Error
+	if err != nil {
+		return err
+	}
+	region := database.GetTelephoneRegion()
+	taken := map[string]string{}
+	for _, customer := range customers {
+		normalized, _, ok := database.NormalizeTelephoneNumber(customer.TelephoneNumber, region)
+		if !ok {
+			log.Printf("telephone number '%s' of customer %s cannot be normalized", customer.TelephoneNumber, customer.ID)
+			continue
+		}
+		if takenBy, exists := taken[normalized]; exists {
+			log.Printf("telephone number '%s' of customer %s is already taken by customer %s",
+				customer.TelephoneNumber, customer.ID, takenBy)
+			continue
+		}
+		taken[normalized] = customer.ID
+		err := tx.Table("customers").Where("id = ?", customer.ID).Update("telephone_number_e164", normalized).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "normalized_telephone_numbers",
+		Up: func(tx *gorm.DB) error {
+			type Customer struct {
+				ID                  string  `gorm:"primaryKey"`
+				TelephoneNumberE164 *string `gorm:"size:16"`
+			}
+			if err := tx.AutoMigrate(&Customer{}); err != nil {
+				return err
+			}
+			if err := normalizeTelephoneNumbers(tx); err != nil {
+				return err
+			}
+			err := createUniqueIndex(tx, "customers", "uniqueTelephoneNumberE164", "telephone_number_e164")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropIndex(&customers{}, "uniqueTelephoneNumber")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := createUniqueIndex(tx, "customers", "uniqueTelephoneNumber", "telephone_number"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&customers{}, "uniqueTelephoneNumberE164"); err != nil {
+				return err
+			}
+			return dropColumns(tx, "customers", "telephone_number_e164")
+		},
+	})
+}
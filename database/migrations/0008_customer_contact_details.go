@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "customer_contact_details",
+		Up: func(tx *gorm.DB) error {
+			type Customer struct {
+				ID                string `gorm:"primaryKey"`
+				Email             string `gorm:"size:256;index"`
+				AddressStreet     string
+				AddressCity       string
+				AddressPostalCode string     `gorm:"size:16"`
+				AddressCountry    string     `gorm:"size:2"`
+				BirthDate         *time.Time `gorm:"type:date"`
+				PreferredContact  string     `gorm:"size:16"`
+				Notes             string     `gorm:"type:text"`
+			}
+			return tx.AutoMigrate(&Customer{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&customers{}, "idx_customers_email"); err != nil {
+				return err
+			}
+			return dropColumns(
+				tx,
+				"customers",
+				"email",
+				"address_street",
+				"address_city",
+				"address_postal_code",
+				"address_country",
+				"birth_date",
+				"preferred_contact",
+				"notes",
+			)
+		},
+	})
+}
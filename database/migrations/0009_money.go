@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"customer-manager/database"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "money",
+		Up: func(tx *gorm.DB) error {
+			type Purchase struct {
+				ID               string `gorm:"primaryKey"`
+				PriceAmount      int64  `gorm:"not null;default:0"`
+				PriceCurrency    string `gorm:"size:3"`
+				DiscountAmount   int64  `gorm:"not null;default:0"`
+				DiscountCurrency string `gorm:"size:3"`
+				VATRate          int    `gorm:"not null;default:0"`
+			}
+			type Repair struct {
+				ID           string `gorm:"primaryKey"`
+				CostAmount   int64  `gorm:"not null;default:0"`
+				CostCurrency string `gorm:"size:3"`
+			}
+			if err := tx.AutoMigrate(&Purchase{}, &Repair{}); err != nil {
+				return err
+			}
+			// Costs were entered in the currency of the shop, which is the one set in CURRENCY.
+			currency := database.GetCurrency()
+			err := tx.Exec(
+				"UPDATE repairs SET cost_amount = ROUND(cost * ?), cost_currency = ?",
+				pow10(database.CurrencyScale(currency)),
+				currency,
+			).Error
+			if err != nil {
+				return err
+			}
+			return dropColumns(tx, "repairs", "cost")
+		},
+		Down: func(tx *gorm.DB) error {
+			type Repair struct {
+				ID   string  `gorm:"primaryKey"`
+				Cost float64 `gorm:"precision:10;scale:2"`
+			}
+			if err := tx.AutoMigrate(&Repair{}); err != nil {
+				return err
+			}
+			err := tx.Exec(
+				"UPDATE repairs SET cost = cost_amount / ?",
+				float64(pow10(database.CurrencyScale(database.GetCurrency()))),
+			).Error
+			if err != nil {
+				return err
+			}
+			err = dropColumns(
+				tx,
+				"purchases",
+				"price_amount",
+				"price_currency",
+				"discount_amount",
+				"discount_currency",
+				"vat_rate",
+			)
+			if err != nil {
+				return err
+			}
+			return dropColumns(tx, "repairs", "cost_amount", "cost_currency")
+		},
+	})
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
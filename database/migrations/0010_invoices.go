@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "invoices",
+		Up: func(tx *gorm.DB) error {
+			type Invoice struct {
+				ID                      string `gorm:"primaryKey"`
+				Number                  string `gorm:"size:32;uniqueIndex"`
+				Year                    int    `gorm:"not null;uniqueIndex:uniqueInvoiceSequence"`
+				Sequence                int    `gorm:"not null;uniqueIndex:uniqueInvoiceSequence"`
+				CustomerID              string `gorm:"size:256;index"`
+				SellerName              string
+				SellerTaxID             string `gorm:"size:32"`
+				SellerAddressStreet     string
+				SellerAddressCity       string
+				SellerAddressPostalCode string `gorm:"size:16"`
+				SellerAddressCountry    string `gorm:"size:2"`
+				BuyerName               string
+				BuyerTaxID              string `gorm:"size:32"`
+				BuyerAddressStreet      string
+				BuyerAddressCity        string
+				BuyerAddressPostalCode  string    `gorm:"size:16"`
+				BuyerAddressCountry     string    `gorm:"size:2"`
+				IssuedAt                time.Time `gorm:"type:date"`
+				NetAmount               int64     `gorm:"not null;default:0"`
+				NetCurrency             string    `gorm:"size:3"`
+				VATAmount               int64     `gorm:"not null;default:0"`
+				VATCurrency             string    `gorm:"size:3"`
+				TotalAmount             int64     `gorm:"not null;default:0"`
+				TotalCurrency           string    `gorm:"size:3"`
+				CreatedAt               time.Time
+			}
+			type InvoiceItem struct {
+				ID                string  `gorm:"primaryKey"`
+				InvoiceID         string  `gorm:"size:256;index"`
+				Position          int     `gorm:"not null"`
+				PurchaseID        *string `gorm:"size:256;uniqueIndex"`
+				RepairID          *string `gorm:"size:256;uniqueIndex"`
+				Description       string
+				UnitPriceAmount   int64  `gorm:"not null;default:0"`
+				UnitPriceCurrency string `gorm:"size:3"`
+				DiscountAmount    int64  `gorm:"not null;default:0"`
+				DiscountCurrency  string `gorm:"size:3"`
+				VATRate           int    `gorm:"not null;default:0"`
+				NetAmount         int64  `gorm:"not null;default:0"`
+				NetCurrency       string `gorm:"size:3"`
+				VATAmount         int64  `gorm:"not null;default:0"`
+				VATCurrency       string `gorm:"size:3"`
+				TotalAmount       int64  `gorm:"not null;default:0"`
+				TotalCurrency     string `gorm:"size:3"`
+			}
+			type InvoiceSequence struct {
+				Year       int `gorm:"primaryKey;autoIncrement:false"`
+				LastNumber int `gorm:"not null;default:0"`
+			}
+			return tx.AutoMigrate(&Invoice{}, &InvoiceItem{}, &InvoiceSequence{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("invoice_items", "invoices", "invoice_sequences")
+		},
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "payments",
+		Up: func(tx *gorm.DB) error {
+			type Payment struct {
+				ID             string  `gorm:"primaryKey"`
+				CustomerID     string  `gorm:"size:256;index"`
+				PurchaseID     *string `gorm:"size:256;index"`
+				RepairID       *string `gorm:"size:256;index"`
+				Method         string  `gorm:"size:16"`
+				AmountAmount   int64   `gorm:"not null;default:0"`
+				AmountCurrency string  `gorm:"size:3"`
+				Refund         bool    `gorm:"not null;default:false"`
+				PaidAt         time.Time
+				CreatedAt      time.Time
+			}
+			return tx.AutoMigrate(&Payment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("payments")
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "product_catalog",
+		Up: func(tx *gorm.DB) error {
+			type Frame struct {
+				ID        string  `gorm:"primaryKey"`
+				SKU       string  `gorm:"size:64;uniqueIndex"`
+				Barcode   *string `gorm:"size:64;uniqueIndex"`
+				Stock     int     `gorm:"not null;default:0"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+				Brand     string `gorm:"size:128"`
+				Model     string `gorm:"size:128"`
+				Color     string `gorm:"size:64"`
+				Size      string `gorm:"size:32"`
+			}
+			type LensType struct {
+				ID        string  `gorm:"primaryKey"`
+				SKU       string  `gorm:"size:64;uniqueIndex"`
+				Barcode   *string `gorm:"size:64;uniqueIndex"`
+				Stock     int     `gorm:"not null;default:0"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+				Name      string `gorm:"size:128"`
+				Material  string `gorm:"size:64"`
+				Coating   string `gorm:"size:64"`
+			}
+			type Purchase struct {
+				ID         string  `gorm:"primaryKey"`
+				FrameID    *string `gorm:"size:256;index"`
+				LensTypeID *string `gorm:"size:256;index"`
+			}
+			return tx.AutoMigrate(&Frame{}, &LensType{}, &Purchase{})
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			for _, column := range []string{"frame_id", "lens_type_id"} {
+				if err := migrator.DropIndex(&purchases{}, "idx_purchases_"+column); err != nil {
+					return err
+				}
+				if err := dropColumns(tx, "purchases", column); err != nil {
+					return err
+				}
+			}
+			return migrator.DropTable("frames", "lens_types")
+		},
+	})
+}
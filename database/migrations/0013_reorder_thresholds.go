@@ -0,0 +1,31 @@
+package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "reorder_thresholds",
+		Up: func(tx *gorm.DB) error {
+			type Frame struct {
+				ID               string `gorm:"primaryKey"`
+				Supplier         string `gorm:"size:128"`
+				ReorderThreshold int    `gorm:"not null;default:0"`
+			}
+			type LensType struct {
+				ID               string `gorm:"primaryKey"`
+				Supplier         string `gorm:"size:128"`
+				ReorderThreshold int    `gorm:"not null;default:0"`
+			}
+			return tx.AutoMigrate(&Frame{}, &LensType{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range []string{"frames", "lens_types"} {
+				if err := dropColumns(tx, table, "supplier", "reorder_threshold"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"customer-manager/database"
+
+	"gorm.io/gorm"
+)
+
+// fillCustomerSearchText fills in the search text of existing customers, deleted ones included.
+func fillCustomerSearchText(tx *gorm.DB) error {
+	var customers []struct {
+		ID              string
+		FirstName       string
+		LastName        string
+		TelephoneNumber string
+	}
+	err := tx.Table("customers").Select("id", "first_name", "last_name", "telephone_number").Scan(&customers).Error
+	if err != nil {
+		return err
+	}
+	for _, customer := range customers {
+		searchText := database.CustomerSearchText(customer.FirstName, customer.LastName, customer.TelephoneNumber)
+		err := tx.Table("customers").Where("id = ?", customer.ID).Update("search_text", searchText).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "customer_search_text",
+		Up: func(tx *gorm.DB) error {
+			type Customer struct {
+				ID         string `gorm:"primaryKey"`
+				SearchText string `gorm:"type:text"`
+			}
+			if err := tx.AutoMigrate(&Customer{}); err != nil {
+				return err
+			}
+			return fillCustomerSearchText(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, "customers", "search_text")
+		},
+	})
+}
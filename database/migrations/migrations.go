@@ -0,0 +1,201 @@
+// Package migrations holds versioned schema migrations. Every migration lives in its own
+// "<version>_<name>.go" file and registers itself from init, applied versions are recorded
+// in the "schema_migrations" table.
+//
+// Migrations must not use models from the database package, as those describe the latest schema
+// only - declare snapshot structs of the tables inside the migration instead.
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a single versioned schema change. Up and Down run in a transaction, note that
+// MySQL commits DDL statements implicitly, so on MySQL a failed migration may be applied partially.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is a record of an applied migration.
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:256"`
+	AppliedAt time.Time
+}
+
+// MigrationStatus tells whether given migration has been applied, AppliedAt is nil for pending ones.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+var registered = map[int]Migration{}
+
+func register(migration Migration) {
+	if _, exists := registered[migration.Version]; exists {
+		panic(fmt.Sprintf("migration with version %d is registered twice", migration.Version))
+	}
+	registered[migration.Version] = migration
+}
+
+// All returns registered migrations ordered by version.
+func All() []Migration {
+	migrations := make([]Migration, 0, len(registered))
+	for _, migration := range registered {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+func getApplied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Up applies all pending migrations in version order and returns the applied ones.
+func Up(db *gorm.DB) ([]Migration, error) {
+	applied, err := getApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	var migrated []Migration
+	for _, migration := range All() {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			record := SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}
+			return tx.Create(&record).Error
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("migration %d '%s' failed: %w", migration.Version, migration.Name, err)
+		}
+		migrated = append(migrated, migration)
+	}
+	return migrated, nil
+}
+
+// Down rolls back given number of the most recently applied migrations and returns the rolled back ones.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	applied, err := getApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations := All()
+	var rolledBack []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rollback of migration %d '%s' failed: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// Status returns all registered migrations together with the time they were applied at.
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := getApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []MigrationStatus
+	for _, migration := range All() {
+		status := MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+var (
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_\w+\.go$`)
+	migrationNamePattern = regexp.MustCompile(`[^a-z0-9]+`)
+	migrationTemplate    = template.Must(template.New("migration").Parse(`package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: {{.Version}},
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+)
+
+// Create writes a new, empty migration into given directory, numbered after the latest one found there,
+// and returns the path of the created file.
+func Create(dir string, name string) (string, error) {
+	name = strings.Trim(migrationNamePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("migration name must contain letters or digits")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	version := 0
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		if fileVersion, _ := strconv.Atoi(match[1]); fileVersion > version {
+			version = fileVersion
+		}
+	}
+	version++
+
+	var content bytes.Buffer
+	if err := migrationTemplate.Execute(&content, Migration{Version: version, Name: name}); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.go", version, name))
+	return path, os.WriteFile(path, content.Bytes(), 0o644)
+}
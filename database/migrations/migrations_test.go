@@ -0,0 +1,268 @@
+package migrations
+
+import (
+	"customer-manager/database"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func getTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenDatabase("sqlite://:memory:", &gorm.Config{Logger: database.GetLogger(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpMatchesModels(t *testing.T) {
+	db := getTestDatabase(t)
+
+	applied, err := Up(db)
+
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(All()))
+	migrator := db.Migrator()
+	for _, model := range database.Schemas() {
+		stmt := &gorm.Statement{DB: db}
+		assert.NoError(t, stmt.Parse(model))
+		assert.True(t, migrator.HasTable(model), stmt.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, migrator.HasColumn(model, field.DBName), "%s.%s", stmt.Table, field.DBName)
+			}
+		}
+	}
+
+	applied, err = Up(db)
+
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestDown(t *testing.T) {
+	db := getTestDatabase(t)
+	_, err := Up(db)
+	assert.NoError(t, err)
+	migrations := All()
+
+	rolledBack, err := Down(db, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{migrations[len(migrations)-1].Version}, getVersions(rolledBack))
+	statuses, err := Status(db)
+	assert.NoError(t, err)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+	assert.NotNil(t, statuses[0].AppliedAt)
+
+	rolledBack, err = Down(db, len(migrations))
+
+	assert.NoError(t, err)
+	assert.Len(t, rolledBack, len(migrations)-1)
+	for _, model := range database.Schemas() {
+		assert.False(t, db.Migrator().HasTable(model))
+	}
+
+	applied, err := Up(db)
+
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+}
+
+// getSchema returns names of columns and indexes of all tables in SQLite database.
+func getSchema(t *testing.T, db *gorm.DB) map[string][]string {
+	t.Helper()
+	var tables []string
+	db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations'").Scan(&tables)
+	schema := map[string][]string{}
+	for _, table := range tables {
+		columnTypes, err := db.Migrator().ColumnTypes(table)
+		assert.NoError(t, err)
+		for _, columnType := range columnTypes {
+			schema[table] = append(schema[table], columnType.Name())
+		}
+		var indexes []string
+		db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? ORDER BY name", table).Scan(&indexes)
+		schema[table] = append(schema[table], indexes...)
+		sort.Strings(schema[table])
+	}
+	return schema
+}
+
+func TestDownRestoresPreviousSchema(t *testing.T) {
+	migrations := All()
+	for i := len(migrations) - 1; i > 0; i-- {
+		previous := migrations[i-1]
+		t.Run(fmt.Sprintf("test rollback to version %d", previous.Version), func(t *testing.T) {
+			expectedDB := getTestDatabase(t)
+			for _, migration := range migrations[:i] {
+				assert.NoError(t, migration.Up(expectedDB))
+			}
+			db := getTestDatabase(t)
+			_, err := Up(db)
+			assert.NoError(t, err)
+
+			_, err = Down(db, len(migrations)-i)
+
+			assert.NoError(t, err)
+			assert.Equal(t, getSchema(t, expectedDB), getSchema(t, db))
+		})
+	}
+}
+
+func TestMigrateLegacyPrescriptions(t *testing.T) {
+	db := getTestDatabase(t)
+	assert.NoError(t, registered[1].Up(db))
+	legacyPurchases := []map[string]interface{}{
+		{"id": "parsed", "lens_power": "R: -1.25 -0.50x180 L: -1.00", "pd": "31.5/30.5"},
+		{"id": "unparsed", "lens_power": "see paper card", "pd": ""},
+	}
+	assert.NoError(t, db.Table("purchases").Create(legacyPurchases).Error)
+
+	_, err := Up(db)
+
+	assert.NoError(t, err)
+	var purchases []database.Purchase
+	db.Order("id asc").Find(&purchases)
+	assert.Len(t, purchases, 2)
+	assert.Equal(t, -1.25, purchases[0].LensPower.Right.Sphere)
+	assert.Equal(t, -0.5, purchases[0].LensPower.Right.Cylinder)
+	assert.Equal(t, 180, purchases[0].LensPower.Right.Axis)
+	assert.Equal(t, -1.0, purchases[0].LensPower.Left.Sphere)
+	assert.Equal(t, database.PupillaryDistance{Binocular: 62, Right: 31.5, Left: 30.5}, purchases[0].PD)
+	assert.Equal(t, database.LensPower{}, purchases[1].LensPower)
+	var legacyLensPower string
+	db.Table("purchases").Where("id = ?", "unparsed").Select("legacy_lens_power").Scan(&legacyLensPower)
+	assert.Equal(t, "see paper card", legacyLensPower)
+	assert.False(t, db.Migrator().HasColumn(&purchases, "lens_power"))
+
+	_, err = Down(db, len(All())-1)
+
+	assert.NoError(t, err)
+	assert.True(t, db.Migrator().HasColumn(&legacyPurchase{}, "lens_power"))
+	assert.False(t, db.Migrator().HasColumn(&legacyPurchase{}, "legacy_lens_power"))
+}
+
+func TestNormalizeTelephoneNumbers(t *testing.T) {
+	t.Setenv("TELEPHONE_REGION", "PL")
+	db := getTestDatabase(t)
+	assert.NoError(t, registered[1].Up(db))
+	legacyCustomers := []map[string]interface{}{
+		{"id": "first", "telephone_number": "600-100-200", "created_at": time.Unix(1, 0)},
+		{"id": "duplicated", "telephone_number": "+48 600 100 200", "created_at": time.Unix(2, 0)},
+		{"id": "unparsed", "telephone_number": "ask at the desk", "created_at": time.Unix(3, 0)},
+	}
+	assert.NoError(t, db.Table("customers").Create(legacyCustomers).Error)
+
+	_, err := Up(db)
+
+	assert.NoError(t, err)
+	var customers []database.Customer
+	db.Order("created_at asc").Find(&customers)
+	assert.Len(t, customers, 3)
+	assert.Equal(t, "+48600100200", *customers[0].TelephoneNumberE164)
+	assert.Equal(t, "600-100-200", customers[0].TelephoneNumber)
+	assert.Nil(t, customers[1].TelephoneNumberE164)
+	assert.Nil(t, customers[2].TelephoneNumberE164)
+}
+
+func TestMigrateRepairCosts(t *testing.T) {
+	t.Setenv("CURRENCY", "PLN")
+	db := getTestDatabase(t)
+	_, err := Up(db)
+	assert.NoError(t, err)
+	_, err = Down(db, stepsDownTo(8))
+	assert.NoError(t, err)
+	legacyRepairs := []map[string]interface{}{
+		{"id": "first", "cost": 120.5},
+		{"id": "second", "cost": 19.99},
+	}
+	assert.NoError(t, db.Table("repairs").Create(legacyRepairs).Error)
+
+	_, err = Up(db)
+
+	assert.NoError(t, err)
+	var repairs []database.Repair
+	db.Order("id asc").Find(&repairs)
+	assert.Len(t, repairs, 2)
+	assert.Equal(t, database.Money{Amount: 12050, Currency: "PLN"}, repairs[0].Cost)
+	assert.Equal(t, database.Money{Amount: 1999, Currency: "PLN"}, repairs[1].Cost)
+
+	_, err = Down(db, stepsDownTo(8))
+
+	assert.NoError(t, err)
+	var costs []float64
+	db.Table("repairs").Order("id asc").Pluck("cost", &costs)
+	assert.Equal(t, []float64{120.5, 19.99}, costs)
+}
+
+func TestFillCustomerSearchText(t *testing.T) {
+	db := getTestDatabase(t)
+	_, err := Up(db)
+	assert.NoError(t, err)
+	_, err = Down(db, stepsDownTo(13))
+	assert.NoError(t, err)
+	legacyCustomers := []map[string]interface{}{
+		{"id": "first", "first_name": "Łukasz", "last_name": "Żółć", "telephone_number": "600-100-200"},
+	}
+	assert.NoError(t, db.Table("customers").Create(legacyCustomers).Error)
+
+	_, err = Up(db)
+
+	assert.NoError(t, err)
+	var searchTexts []string
+	db.Table("customers").Pluck("search_text", &searchTexts)
+	assert.Equal(t, []string{"lukasz zolc 600100200"}, searchTexts)
+}
+
+// stepsDownTo returns the number of migrations to roll back to get the schema of the given version.
+func stepsDownTo(version int) int {
+	steps := 0
+	for _, migration := range All() {
+		if migration.Version > version {
+			steps++
+		}
+	}
+	return steps
+}
+
+type legacyPurchase struct{}
+
+func (legacyPurchase) TableName() string {
+	return "purchases"
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "0007_existing.go"), []byte("package migrations\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "0012_other_test.go"), []byte("package migrations\n"), 0o644))
+
+	path, err := Create(dir, "Add customer e-mail")
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0008_add_customer_e_mail.go"), path)
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Version: 8,")
+	assert.Contains(t, string(content), `Name:    "add_customer_e_mail",`)
+
+	_, err = Create(dir, "--")
+
+	assert.EqualError(t, err, "migration name must contain letters or digits")
+}
+
+func getVersions(migrations []Migration) []int {
+	var versions []int
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
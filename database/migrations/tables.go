@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Following types refer to tables in Migrator methods which look columns up in the model schema,
+// and therefore do not accept plain table names.
+
+type customers struct{}
+
+func (customers) TableName() string {
+	return "customers"
+}
+
+type purchases struct{}
+
+func (purchases) TableName() string {
+	return "purchases"
+}
+
+type repairs struct{}
+
+func (repairs) TableName() string {
+	return "repairs"
+}
+
+// dropColumns drops columns with plain ALTER TABLE statements. SQLite Migrator recreates the table
+// instead, which loses indexes of the table.
+func dropColumns(tx *gorm.DB, table string, columns ...string) error {
+	for _, column := range columns {
+		err := tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: column}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createUniqueIndex creates unique index with a plain CREATE INDEX statement, as adding a column together
+// with its unique index is not supported by SQLite.
+func createUniqueIndex(tx *gorm.DB, table string, name string, column string) error {
+	return tx.Exec(
+		"CREATE UNIQUE INDEX ? ON ? (?)",
+		clause.Column{Name: name},
+		clause.Table{Name: table},
+		clause.Column{Name: column},
+	).Error
+}
@@ -7,50 +7,170 @@ import (
 	"gorm.io/gorm"
 )
 
+// Schemas returns all models which are stored in the database.
+func Schemas() []interface{} {
+	return []interface{}{
+		&Customer{},
+		&Purchase{},
+		&Prescription{},
+		&Repair{},
+		&AuditEntry{},
+		&Invoice{},
+		&InvoiceItem{},
+		&InvoiceSequence{},
+		&Payment{},
+		&Frame{},
+		&LensType{},
+	}
+}
+
+// Customer keeps the telephone number in the form it is displayed in, while TelephoneNumberE164 holds
+// the number normalized to E.164 format which customers cannot share. Numbers which could not be
+// normalized, e.g. entered before the normalization was introduced, have no normalized form.
+// Remaining contact details are optional. SearchText is kept up to date with the names and the telephone
+// number, so that customers are narrowed down by it before they are searched.
 type Customer struct {
-	ID              string     `gorm:"primaryKey"                                 json:"id"`
-	FirstName       string     `                                                  json:"first_name"       validate:"required"`
-	LastName        string     `                                                  json:"last_name"        validate:"required"`
-	TelephoneNumber string     `gorm:"uniqueIndex:uniqueTelephoneNumber;size:256" json:"telephone_number" validate:"required"`
-	CreatedAt       time.Time  `                                                  json:"created_at"`
-	UpdatedAt       time.Time  `                                                  json:"updated_at"`
-	Purchases       []Purchase `gorm:"foreignKey:CustomerID;"                     json:"-"`
-	Repairs         []Repair   `gorm:"foreignKey:CustomerID;"                     json:"-"`
+	ID                  string         `gorm:"primaryKey"                                    json:"id"`
+	FirstName           string         `                                                     json:"first_name"            validate:"required"`
+	LastName            string         `                                                     json:"last_name"             validate:"required"`
+	TelephoneNumber     string         `gorm:"size:256"                                      json:"telephone_number"      validate:"required"`
+	TelephoneNumberE164 *string        `gorm:"uniqueIndex:uniqueTelephoneNumberE164;size:16" json:"telephone_number_e164"`
+	Email               string         `gorm:"size:256;index"                                json:"email"`
+	Address             Address        `gorm:"embedded;embeddedPrefix:address_"              json:"address"`
+	BirthDate           *time.Time     `gorm:"type:date"                                     json:"birth_date"`
+	PreferredContact    ContactChannel `gorm:"size:16"                                       json:"preferred_contact"`
+	Notes               string         `gorm:"type:text"                                     json:"notes"`
+	SearchText          string         `gorm:"type:text"                                     json:"-"`
+	CreatedAt           time.Time      `                                                     json:"created_at"`
+	UpdatedAt           time.Time      `                                                     json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index"                                         json:"deleted_at"`
+	Version             int            `gorm:"not null;default:1"                            json:"version"`
+	Purchases           []Purchase     `gorm:"foreignKey:CustomerID;"                        json:"-"`
+	Repairs             []Repair       `gorm:"foreignKey:CustomerID;"                        json:"-"`
+	Prescriptions       []Prescription `gorm:"foreignKey:CustomerID;"                        json:"-"`
 }
 
 func (u *Customer) BeforeCreate(tx *gorm.DB) (err error) {
 	u.ID = uuid.NewString()
+	u.Version = 1
+	u.SearchText = CustomerSearchText(u.FirstName, u.LastName, u.TelephoneNumber)
 	return
 }
 
+// EyePrescription describes the correction of a single eye. Sphere, cylinder,
+// add and prism are expressed in diopters, axis in degrees.
+type EyePrescription struct {
+	Sphere   float64 `gorm:"precision:5;scale:2" json:"sphere"`
+	Cylinder float64 `gorm:"precision:5;scale:2" json:"cylinder"`
+	Axis     int     `                           json:"axis"`
+	Add      float64 `gorm:"precision:5;scale:2" json:"add"`
+	Prism    float64 `gorm:"precision:5;scale:2" json:"prism"`
+	Base     string  `gorm:"size:2"              json:"base"`
+}
+
+type LensPower struct {
+	Right EyePrescription `gorm:"embedded;embeddedPrefix:right_" json:"right"`
+	Left  EyePrescription `gorm:"embedded;embeddedPrefix:left_"  json:"left"`
+}
+
+// PupillaryDistance holds either binocular distance, monocular distances or both, in millimeters.
+type PupillaryDistance struct {
+	Binocular float64 `gorm:"precision:4;scale:1" json:"binocular"`
+	Right     float64 `gorm:"precision:4;scale:1" json:"right"`
+	Left      float64 `gorm:"precision:4;scale:1" json:"left"`
+}
+
+// Purchase is priced at the gross price, which includes VAT at the VAT rate given in percent, less the discount.
+// Total and VAT are not stored, but calculated whenever the purchase is read or saved. Frames and lens types
+// picked from the catalog are referenced along with their descriptions, StockWarnings are only set when
+// the purchase is created and tell which of them were sold out of stock.
 type Purchase struct {
-	ID           string    `gorm:"primaryKey" json:"id"`
-	FrameModel   string    `                  json:"frame_model"`
-	LensType     string    `                  json:"lens_type"`
-	LensPower    string    `                  json:"lens_power"`
-	PD           string    `                  json:"pd"`
-	CustomerID   string    `gorm:"size:256"   json:"customer_id"`
-	PurchaseType string    `                  json:"purchase_type"`
-	PurchasedAt  time.Time `gorm:"type:date"  json:"purchased_at"`
-	CreatedAt    time.Time `                  json:"created_at"`
-	UpdatedAt    time.Time `                  json:"updated_at"`
+	ID             string            `gorm:"primaryKey"                          json:"id"`
+	FrameModel     string            `                                           json:"frame_model"`
+	LensType       string            `                                           json:"lens_type"`
+	LensPower      LensPower         `gorm:"embedded;embeddedPrefix:lens_power_" json:"lens_power"`
+	PD             PupillaryDistance `gorm:"embedded;embeddedPrefix:pd_"         json:"pd"`
+	PrescriptionID *string           `gorm:"size:256;index"                      json:"prescription_id"`
+	FrameID        *string           `gorm:"size:256;index"                      json:"frame_id"`
+	LensTypeID     *string           `gorm:"size:256;index"                      json:"lens_type_id"`
+	CustomerID     string            `gorm:"size:256"                            json:"customer_id"`
+	PurchaseType   string            `                                           json:"purchase_type"`
+	PurchasedAt    time.Time         `gorm:"type:date"                           json:"purchased_at"`
+	Price          Money             `gorm:"embedded;embeddedPrefix:price_"      json:"price"`
+	Discount       Money             `gorm:"embedded;embeddedPrefix:discount_"   json:"discount"`
+	VATRate        int               `gorm:"not null;default:0"                  json:"vat_rate"`
+	Total          Money             `gorm:"-"                                   json:"total"`
+	VAT            Money             `gorm:"-"                                   json:"vat"`
+	StockWarnings  []string          `gorm:"-"                                   json:"stock_warnings,omitempty"`
+	CreatedAt      time.Time         `                                           json:"created_at"`
+	UpdatedAt      time.Time         `                                           json:"updated_at"`
+	DeletedAt      gorm.DeletedAt    `gorm:"index"                               json:"deleted_at"`
+	Version        int               `gorm:"not null;default:1"                  json:"version"`
 }
 
 func (p *Purchase) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	p.Version = 1
+	return
+}
+
+// CalculateTotals sets the total, which is the price less the discount, and the VAT included in it.
+func (p *Purchase) CalculateTotals() {
+	p.Total = p.Price.Sub(p.Discount)
+	p.VAT = p.Total.Fraction(int64(p.VATRate), int64(100+p.VATRate))
+}
+
+func (p *Purchase) AfterFind(tx *gorm.DB) (err error) {
+	p.CalculateTotals()
+	return
+}
+
+func (p *Purchase) AfterSave(tx *gorm.DB) (err error) {
+	p.CalculateTotals()
+	return
+}
+
+// Prescription is an optical prescription issued to a customer by an optometrist.
+type Prescription struct {
+	ID         string            `gorm:"primaryKey"                          json:"id"`
+	LensPower  LensPower         `gorm:"embedded;embeddedPrefix:lens_power_" json:"lens_power"`
+	PD         PupillaryDistance `gorm:"embedded;embeddedPrefix:pd_"         json:"pd"`
+	IssuedBy   string            `                                           json:"issued_by"`
+	IssuedAt   time.Time         `gorm:"type:date"                           json:"issued_at"`
+	ExpiresAt  time.Time         `gorm:"type:date"                           json:"expires_at"`
+	CustomerID string            `gorm:"size:256"                            json:"customer_id"`
+	CreatedAt  time.Time         `                                           json:"created_at"`
+	UpdatedAt  time.Time         `                                           json:"updated_at"`
+}
+
+func (p *Prescription) BeforeCreate(tx *gorm.DB) (err error) {
 	p.ID = uuid.NewString()
 	return
 }
 
 type Repair struct {
-	ID          string    `gorm:"primaryKey"  json:"id"`
-	Description string    `                   json:"description"`
-	Cost        float64   `gorm:"precision:2" json:"cost"`
-	CustomerID  string    `gorm:"size:256"    json:"customer_id"`
-	CreatedAt   time.Time `                   json:"created_at"`
-	ReportedAt  time.Time `gorm:"type:date"   json:"reported_at"`
+	ID                string         `gorm:"primaryKey"                     json:"id"`
+	Description       string         `                                      json:"description"`
+	Cost              Money          `gorm:"embedded;embeddedPrefix:cost_"  json:"cost"`
+	CustomerID        string         `gorm:"size:256"                       json:"customer_id"`
+	CreatedAt         time.Time      `                                      json:"created_at"`
+	UpdatedAt         time.Time      `                                      json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index"                          json:"deleted_at"`
+	Version           int            `gorm:"not null;default:1"             json:"version"`
+	ReportedAt        time.Time      `gorm:"type:date"                      json:"reported_at"`
+	Status            RepairStatus   `gorm:"size:32;index;default:reported" json:"status"`
+	InProgressAt      *time.Time     `                                      json:"in_progress_at"`
+	WaitingForPartsAt *time.Time     `                                      json:"waiting_for_parts_at"`
+	ReadyForPickupAt  *time.Time     `                                      json:"ready_for_pickup_at"`
+	CollectedAt       *time.Time     `                                      json:"collected_at"`
+	CancelledAt       *time.Time     `                                      json:"cancelled_at"`
 }
 
 func (r *Repair) BeforeCreate(tx *gorm.DB) (err error) {
 	r.ID = uuid.NewString()
+	r.Version = 1
+	if r.Status == "" {
+		r.Status = RepairReported
+	}
 	return
 }
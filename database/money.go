@@ -0,0 +1,168 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// DefaultCurrency is the currency of amounts given without one, unless CURRENCY environment variable sets another one.
+const DefaultCurrency = "PLN"
+
+// IsCurrency tells whether the code is an ISO 4217 currency code written in upper case, e.g. "PLN".
+func IsCurrency(code string) bool {
+	if len(code) != 3 || strings.ToUpper(code) != code {
+		return false
+	}
+	_, err := currency.ParseISO(code)
+	return err == nil
+}
+
+// GetCurrency returns the currency set in CURRENCY environment variable, e.g. "PLN" or "EUR".
+func GetCurrency() string {
+	code := strings.ToUpper(os.Getenv("CURRENCY"))
+	if code == "" {
+		return DefaultCurrency
+	}
+	if !IsCurrency(code) {
+		panic(fmt.Sprintf("unsupported CURRENCY '%s', expected ISO 4217 currency code like 'PLN'", code))
+	}
+	return code
+}
+
+// CurrencyScale returns the number of decimal places of the currency, e.g. 2 for PLN and 0 for JPY.
+// Unknown currencies have two decimal places.
+func CurrencyScale(code string) int {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return 2
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	return scale
+}
+
+// Money is an amount in minor units of the currency, e.g. grosze of PLN, which keeps amounts free of
+// floating point rounding errors. In JSON the amount is a decimal string written with the scale of the
+// currency, e.g. {"amount": "120.50", "currency": "PLN"}.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0" json:"amount"   swaggertype:"string" example:"120.50"`
+	Currency string `gorm:"size:3"             json:"currency"                      example:"PLN"`
+}
+
+var amountPattern = regexp.MustCompile(`^(-?)([0-9]+)(?:\.([0-9]+))?$`)
+
+// ParseMoney parses a decimal amount, such as "120.50" or "-5", in the currency. Amounts with more
+// decimal places than the currency has, or which do not fit minor units, are rejected rather than rounded.
+func ParseMoney(amount string, code string) (Money, error) {
+	if !IsCurrency(code) {
+		return Money{}, fmt.Errorf("'%s' is not an ISO 4217 currency code", code)
+	}
+	parts := amountPattern.FindStringSubmatch(amount)
+	if parts == nil {
+		return Money{}, fmt.Errorf("'%s' is not a decimal amount", amount)
+	}
+	scale := CurrencyScale(code)
+	if len(parts[3]) > scale {
+		return Money{}, fmt.Errorf("'%s' has more than %d decimal places of %s", amount, scale, code)
+	}
+	minorUnits, err := strconv.ParseInt(parts[2]+parts[3]+strings.Repeat("0", scale-len(parts[3])), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("'%s' is out of range", amount)
+	}
+	if parts[1] == "-" {
+		minorUnits = -minorUnits
+	}
+	return Money{Amount: minorUnits, Currency: code}, nil
+}
+
+// String returns the amount as a decimal number with the scale of the currency, e.g. "120.50".
+func (m Money) String() string {
+	scale := CurrencyScale(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := fmt.Sprintf("%0*d", scale+1, amount)
+	if scale == 0 {
+		return sign + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns the sum of amounts, which have to be in the same currency.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns the difference of amounts, which have to be in the same currency.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Fraction returns the amount multiplied by numerator/denominator, rounded half away from zero to minor units.
+func (m Money) Fraction(numerator int64, denominator int64) Money {
+	product := m.Amount * numerator
+	quotient, remainder := product/denominator, product%denominator
+	if 2*abs(remainder) >= abs(denominator) {
+		if (product < 0) != (denominator < 0) {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return Money{Amount: quotient, Currency: m.Currency}
+}
+
+func abs(number int64) int64 {
+	if number < 0 {
+		return -number
+	}
+	return number
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.Currency})
+}
+
+// UnmarshalJSON accepts only amounts given as decimal strings along with their currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var money struct {
+		Amount   *string `json:"amount"`
+		Currency string  `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &money); err != nil {
+		return err
+	}
+	if money.Amount == nil {
+		return errors.New("money amount is missing")
+	}
+	if money.Currency == "" {
+		parts := amountPattern.FindStringSubmatch(*money.Amount)
+		if parts == nil || strings.Trim(parts[2]+parts[3], "0") != "" {
+			return errors.New("money currency is missing")
+		}
+		*m = Money{}
+		return nil
+	}
+	parsed, err := ParseMoney(*money.Amount, money.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
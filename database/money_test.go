@@ -0,0 +1,79 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, test := range []struct {
+		amount   string
+		currency string
+		expected Money
+	}{
+		{"120.50", "PLN", Money{Amount: 12050, Currency: "PLN"}},
+		{"120.5", "PLN", Money{Amount: 12050, Currency: "PLN"}},
+		{"7", "EUR", Money{Amount: 700, Currency: "EUR"}},
+		{"-0.01", "PLN", Money{Amount: -1, Currency: "PLN"}},
+		{"1500", "JPY", Money{Amount: 1500, Currency: "JPY"}},
+		{"1.125", "KWD", Money{Amount: 1125, Currency: "KWD"}},
+	} {
+		money, err := ParseMoney(test.amount, test.currency)
+
+		assert.NoError(t, err, test.amount)
+		assert.Equal(t, test.expected, money, test.amount)
+	}
+
+	for _, test := range []struct {
+		amount   string
+		currency string
+		err      string
+	}{
+		{"12.345", "PLN", "'12.345' has more than 2 decimal places of PLN"},
+		{"1.5", "JPY", "'1.5' has more than 0 decimal places of JPY"},
+		{"1,50", "PLN", "'1,50' is not a decimal amount"},
+		{"", "PLN", "'' is not a decimal amount"},
+		{"1e3", "PLN", "'1e3' is not a decimal amount"},
+		{"99999999999999999999", "PLN", "'99999999999999999999' is out of range"},
+		{"1", "pln", "'pln' is not an ISO 4217 currency code"},
+		{"1", "ZZZ", "'ZZZ' is not an ISO 4217 currency code"},
+	} {
+		_, err := ParseMoney(test.amount, test.currency)
+
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "120.50", Money{Amount: 12050, Currency: "PLN"}.String())
+	assert.Equal(t, "0.05", Money{Amount: 5, Currency: "PLN"}.String())
+	assert.Equal(t, "-0.05", Money{Amount: -5, Currency: "PLN"}.String())
+	assert.Equal(t, "1500", Money{Amount: 1500, Currency: "JPY"}.String())
+	assert.Equal(t, "0.00", Money{}.String())
+}
+
+func TestMoneyFraction(t *testing.T) {
+	total := Money{Amount: 30000, Currency: "PLN"}
+
+	assert.Equal(t, Money{Amount: 5610, Currency: "PLN"}, total.Fraction(23, 123))
+	assert.Equal(t, Money{Amount: 3, Currency: "PLN"}, Money{Amount: 5, Currency: "PLN"}.Fraction(1, 2))
+	assert.Equal(t, Money{Amount: -3, Currency: "PLN"}, Money{Amount: -5, Currency: "PLN"}.Fraction(1, 2))
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(Money{Amount: 12050, Currency: "PLN"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "120.50", "currency": "PLN"}`, string(data))
+
+	var money Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "120.50", "currency": "PLN"}`), &money))
+	assert.Equal(t, Money{Amount: 12050, Currency: "PLN"}, money)
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "0.00", "currency": ""}`), &money))
+	assert.Equal(t, Money{}, money)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 120.5, "currency": "PLN"}`), &money))
+	assert.EqualError(t, json.Unmarshal([]byte(`{"amount": "1.00"}`), &money), "money currency is missing")
+	assert.EqualError(t, json.Unmarshal([]byte(`{"currency": "PLN"}`), &money), "money amount is missing")
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentMethod is the way the customer paid in.
+type PaymentMethod string
+
+const (
+	PaymentCash     PaymentMethod = "cash"
+	PaymentCard     PaymentMethod = "card"
+	PaymentTransfer PaymentMethod = "transfer"
+)
+
+var PaymentMethods = []PaymentMethod{PaymentCash, PaymentCard, PaymentTransfer}
+
+func (m PaymentMethod) IsValid() bool {
+	for _, method := range PaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment is an entry of the ledger of payments for a single purchase or repair, so a deposit paid when
+// glasses are ordered and the rest paid at pickup are separate payments. Refunds return the amount to
+// the customer. Payments are never changed or deleted, a mistaken payment is corrected by a refund.
+type Payment struct {
+	ID         string        `gorm:"primaryKey"                      json:"id"`
+	CustomerID string        `gorm:"size:256;index"                  json:"customer_id"`
+	PurchaseID *string       `gorm:"size:256;index"                  json:"purchase_id"`
+	RepairID   *string       `gorm:"size:256;index"                  json:"repair_id"`
+	Method     PaymentMethod `gorm:"size:16"                         json:"method"`
+	Amount     Money         `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Refund     bool          `gorm:"not null;default:false"          json:"refund"`
+	PaidAt     time.Time     `                                       json:"paid_at"`
+	CreatedAt  time.Time     `                                       json:"created_at"`
+}
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const number = `([+-]?\d+(?:[.,]\d+)?)`
+
+var (
+	eyeLabelPattern = regexp.MustCompile(`(?i)(?:^|[\s;|,])(OD|OS|R|L|P)\b\s*[:=]?\s*`)
+	eyePattern      = regexp.MustCompile(
+		`(?i)^(?:sph\s*)?` + number +
+			`(?:\s*(?:/|\s)\s*(?:cyl\s*)?` + number + `)?` +
+			`(?:\s*(?:x|ax|axis)\s*(\d{1,3}))?` +
+			`(?:\s*add\s*` + number + `)?` +
+			`(?:\s*(?:prism|pr)\s*` + number + `\s*(BI|BO|BU|BD))?$`,
+	)
+	pdPattern = regexp.MustCompile(`^` + number + `(?:\s*/\s*` + number + `)?$`)
+)
+
+func parseNumber(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return f
+}
+
+// ParseEyePrescription parses a single eye notation such as "-1.25 -0.50 x 180 add +2.00".
+func ParseEyePrescription(value string) (EyePrescription, bool) {
+	groups := eyePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if groups == nil {
+		return EyePrescription{}, false
+	}
+	axis, _ := strconv.Atoi(groups[3])
+	return EyePrescription{
+		Sphere:   parseNumber(groups[1]),
+		Cylinder: parseNumber(groups[2]),
+		Axis:     axis,
+		Add:      parseNumber(groups[4]),
+		Prism:    parseNumber(groups[5]),
+		Base:     strings.ToUpper(groups[6]),
+	}, true
+}
+
+// ParseLensPower parses free-text lens power such as "R: -1.25 -0.50 x 180; L: -1.00".
+// Right eye may be labeled as R, OD or P (Polish "prawe"), left eye as L or OS.
+func ParseLensPower(value string) (LensPower, bool) {
+	var lensPower LensPower
+	labels := eyeLabelPattern.FindAllStringSubmatchIndex(value, -1)
+	if len(labels) == 0 || len(labels) > 2 {
+		return lensPower, false
+	}
+	seen := make(map[bool]bool)
+	for index, label := range labels {
+		end := len(value)
+		if index+1 < len(labels) {
+			end = labels[index+1][0]
+		}
+		eye, ok := ParseEyePrescription(strings.Trim(value[label[1]:end], " \t;|,"))
+		if !ok {
+			return LensPower{}, false
+		}
+		isLeft := strings.EqualFold(value[label[2]:label[3]], "L") ||
+			strings.EqualFold(value[label[2]:label[3]], "OS")
+		if seen[isLeft] {
+			return LensPower{}, false
+		}
+		seen[isLeft] = true
+		if isLeft {
+			lensPower.Left = eye
+		} else {
+			lensPower.Right = eye
+		}
+	}
+	return lensPower, true
+}
+
+// ParsePupillaryDistance parses binocular ("62") or monocular right/left ("31.5/30.5") distance.
+func ParsePupillaryDistance(value string) (PupillaryDistance, bool) {
+	groups := pdPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if groups == nil {
+		return PupillaryDistance{}, false
+	}
+	if groups[2] == "" {
+		return PupillaryDistance{Binocular: parseNumber(groups[1])}, true
+	}
+	right, left := parseNumber(groups[1]), parseNumber(groups[2])
+	return PupillaryDistance{Binocular: right + left, Right: right, Left: left}, true
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLensPower(t *testing.T) {
+	t.Run("test parse both eyes", func(t *testing.T) {
+		lensPower, ok := ParseLensPower("R: -1.25 -0.50 x 180; L: +0,75/-0.25x5 add +2.00")
+
+		assert.True(t, ok)
+		assert.Equal(t, LensPower{
+			Right: EyePrescription{Sphere: -1.25, Cylinder: -0.5, Axis: 180},
+			Left:  EyePrescription{Sphere: 0.75, Cylinder: -0.25, Axis: 5, Add: 2},
+		}, lensPower)
+	})
+
+	t.Run("test parse eye with prism", func(t *testing.T) {
+		lensPower, ok := ParseLensPower("OD sph -2.00 prism 1.5 BI OS -2")
+
+		assert.True(t, ok)
+		assert.Equal(t, LensPower{
+			Right: EyePrescription{Sphere: -2, Prism: 1.5, Base: "BI"},
+			Left:  EyePrescription{Sphere: -2},
+		}, lensPower)
+	})
+
+	t.Run("test parse polish labels", func(t *testing.T) {
+		lensPower, ok := ParseLensPower("P -1 L -1.5")
+
+		assert.True(t, ok)
+		assert.Equal(t, LensPower{Right: EyePrescription{Sphere: -1}, Left: EyePrescription{Sphere: -1.5}}, lensPower)
+	})
+
+	t.Run("test cannot parse free text", func(t *testing.T) {
+		for _, value := range []string{"", "LensPower", "R: strong", "R -1 R -2"} {
+			_, ok := ParseLensPower(value)
+			assert.False(t, ok, value)
+		}
+	})
+}
+
+func TestParsePupillaryDistance(t *testing.T) {
+	pd, ok := ParsePupillaryDistance("62")
+	assert.True(t, ok)
+	assert.Equal(t, PupillaryDistance{Binocular: 62}, pd)
+
+	pd, ok = ParsePupillaryDistance("31,5 / 30.5")
+	assert.True(t, ok)
+	assert.Equal(t, PupillaryDistance{Binocular: 62, Right: 31.5, Left: 30.5}, pd)
+
+	_, ok = ParsePupillaryDistance("CustomPD")
+	assert.False(t, ok)
+}
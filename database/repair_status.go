@@ -0,0 +1,76 @@
+package database
+
+import "time"
+
+type RepairStatus string
+
+const (
+	RepairReported        RepairStatus = "reported"
+	RepairInProgress      RepairStatus = "in_progress"
+	RepairWaitingForParts RepairStatus = "waiting_for_parts"
+	RepairReadyForPickup  RepairStatus = "ready_for_pickup"
+	RepairCollected       RepairStatus = "collected"
+	RepairCancelled       RepairStatus = "cancelled"
+)
+
+var RepairStatuses = []RepairStatus{
+	RepairReported,
+	RepairInProgress,
+	RepairWaitingForParts,
+	RepairReadyForPickup,
+	RepairCollected,
+	RepairCancelled,
+}
+
+// OpenRepairStatuses are statuses of repairs which are still in the workshop queue.
+var OpenRepairStatuses = []RepairStatus{
+	RepairReported,
+	RepairInProgress,
+	RepairWaitingForParts,
+	RepairReadyForPickup,
+}
+
+var repairStatusTransitions = map[RepairStatus][]RepairStatus{
+	RepairReported:        {RepairInProgress, RepairCancelled},
+	RepairInProgress:      {RepairWaitingForParts, RepairReadyForPickup, RepairCancelled},
+	RepairWaitingForParts: {RepairInProgress, RepairCancelled},
+	RepairReadyForPickup:  {RepairCollected, RepairInProgress, RepairCancelled},
+}
+
+func (s RepairStatus) IsValid() bool {
+	for _, status := range RepairStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (s RepairStatus) CanTransitionTo(next RepairStatus) bool {
+	for _, status := range repairStatusTransitions[s] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus changes status of the repair and records when the repair entered it. The work is started
+// only once, so a repair going back to in progress keeps the time it was first started at.
+func (r *Repair) SetStatus(status RepairStatus, at time.Time) {
+	r.Status = status
+	switch status {
+	case RepairInProgress:
+		if r.InProgressAt == nil {
+			r.InProgressAt = &at
+		}
+	case RepairWaitingForParts:
+		r.WaitingForPartsAt = &at
+	case RepairReadyForPickup:
+		r.ReadyForPickupAt = &at
+	case RepairCollected:
+		r.CollectedAt = &at
+	case RepairCancelled:
+		r.CancelledAt = &at
+	}
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairSetStatus(t *testing.T) {
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	waiting := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	resumed := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	repair := &Repair{Status: RepairReported}
+
+	repair.SetStatus(RepairInProgress, started)
+	repair.SetStatus(RepairWaitingForParts, waiting)
+	repair.SetStatus(RepairInProgress, resumed)
+
+	assert.Equal(t, RepairInProgress, repair.Status)
+	assert.Equal(t, &started, repair.InProgressAt)
+	assert.Equal(t, &waiting, repair.WaitingForPartsAt)
+}
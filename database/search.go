@@ -0,0 +1,39 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldedRunes are letters which do not decompose to a base letter and a diacritical mark.
+var foldedRunes = map[rune]rune{'ł': 'l', 'đ': 'd', 'ø': 'o', 'ħ': 'h', 'ı': 'i'}
+
+// Fold lowercases the text and strips diacritics, so "Łukasz Żółć" becomes "lukasz zolc".
+// Every character is folded to exactly one character, so offsets in folded text are valid in the original.
+func Fold(text string) []rune {
+	folded := make([]rune, 0, len(text))
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		if base, ok := foldedRunes[r]; ok {
+			r = base
+		} else if decomposed := []rune(norm.NFD.String(string(r))); !unicode.Is(unicode.Mn, decomposed[0]) {
+			r = decomposed[0]
+		}
+		folded = append(folded, r)
+	}
+	return folded
+}
+
+// CustomerSearchText is what customers are narrowed down by before they are searched: their folded names
+// followed by digits of their telephone number, e.g. "lukasz zolc 600100200".
+func CustomerSearchText(firstName string, lastName string, telephoneNumber string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, telephoneNumber)
+	return strings.Join([]string{string(Fold(firstName)), string(Fold(lastName)), digits}, " ")
+}
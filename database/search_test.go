@@ -0,0 +1,16 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "lukasz zolc-brzeczyszczykiewicz", string(Fold("Łukasz Żółć-Brzęczyszczykiewicz")))
+	assert.Equal(t, "jose muller", string(Fold("José Müller")))
+}
+
+func TestCustomerSearchText(t *testing.T) {
+	assert.Equal(t, "lukasz zolc 48600100200", CustomerSearchText("Łukasz", "Żółć", "+48 600-100-200"))
+}
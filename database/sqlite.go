@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/glebarez/go-sqlite"
+	gormsqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteDialector is a pure Go SQLite dialector which translates constraint violations
+// to GORM errors, the same way MySQL dialector does.
+type sqliteDialector struct {
+	*gormsqlite.Dialector
+}
+
+func openSQLite(dsn string) gorm.Dialector {
+	return &sqliteDialector{Dialector: &gormsqlite.Dialector{DSN: dsn}}
+}
+
+func (d *sqliteDialector) Translate(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		if code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	return err
+}
+
+func isInMemorySQLite(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, sqliteScheme) && strings.Contains(databaseURL, ":memory:")
+}
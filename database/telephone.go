@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+// DefaultTelephoneRegion is the region of telephone numbers given without the country calling code,
+// unless TELEPHONE_REGION environment variable sets another one.
+const DefaultTelephoneRegion = "PL"
+
+// IsTelephoneRegion tells whether the ISO 3166-1 alpha-2 region code has a country calling code.
+func IsTelephoneRegion(region string) bool {
+	return libphonenumber.GetCountryCodeForRegion(region) != 0
+}
+
+// GetTelephoneRegion returns the region set in TELEPHONE_REGION environment variable, e.g. "PL" or "DE".
+func GetTelephoneRegion() string {
+	region := strings.ToUpper(os.Getenv("TELEPHONE_REGION"))
+	if region == "" {
+		return DefaultTelephoneRegion
+	}
+	if !IsTelephoneRegion(region) {
+		panic(fmt.Sprintf("unsupported TELEPHONE_REGION '%s', expected region code like 'PL'", region))
+	}
+	return region
+}
+
+// NormalizeTelephoneNumber parses telephone number written in any format, such as "+48 600 100 200",
+// "0048600100200" or "600-100-200", numbers without the country calling code belong to the region.
+// It returns the number in E.164 format along with the form to display it in, which is the national
+// format for numbers of the region and the international one for others.
+func NormalizeTelephoneNumber(number string, region string) (string, string, bool) {
+	parsed, err := libphonenumber.Parse(number, region)
+	if err != nil || !libphonenumber.IsPossibleNumber(parsed) {
+		return "", "", false
+	}
+	display := libphonenumber.Format(parsed, libphonenumber.INTERNATIONAL)
+	if int(parsed.GetCountryCode()) == libphonenumber.GetCountryCodeForRegion(region) {
+		display = libphonenumber.Format(parsed, libphonenumber.NATIONAL)
+	}
+	return libphonenumber.Format(parsed, libphonenumber.E164), display, true
+}
+
+// TelephoneNumberQuery returns the number to search normalized telephone numbers by. Complete numbers
+// are normalized, so they are found in any format, while parts of numbers are searched for as given.
+func TelephoneNumberQuery(number string, region string) string {
+	parsed, err := libphonenumber.Parse(number, region)
+	if err != nil || !libphonenumber.IsValidNumber(parsed) {
+		return number
+	}
+	return libphonenumber.Format(parsed, libphonenumber.E164)
+}
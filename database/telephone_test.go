@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTelephoneNumber(t *testing.T) {
+	for _, number := range []string{"+48 600 100 200", "0048600100200", "600-100-200", "(600) 100 200"} {
+		t.Run("test normalize "+number, func(t *testing.T) {
+			normalized, display, ok := NormalizeTelephoneNumber(number, "PL")
+
+			assert.True(t, ok)
+			assert.Equal(t, "+48600100200", normalized)
+			assert.Equal(t, "600 100 200", display)
+		})
+	}
+
+	t.Run("test normalize number of another region", func(t *testing.T) {
+		normalized, display, ok := NormalizeTelephoneNumber("+44 20 7946 0958", "PL")
+
+		assert.True(t, ok)
+		assert.Equal(t, "+442079460958", normalized)
+		assert.Equal(t, "+44 20 7946 0958", display)
+	})
+
+	for _, number := range []string{"", "call me", "12", "+48 600 100 200 300 400"} {
+		t.Run("test cannot normalize "+number, func(t *testing.T) {
+			_, _, ok := NormalizeTelephoneNumber(number, "PL")
+
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestTelephoneNumberQuery(t *testing.T) {
+	assert.Equal(t, "+48600100200", TelephoneNumberQuery("0048 600-100-200", "PL"))
+	assert.Equal(t, "+49301234567", TelephoneNumberQuery("030 1234567", "DE"))
+	assert.Equal(t, "100 200", TelephoneNumberQuery("100 200", "PL"))
+	assert.Equal(t, "", TelephoneNumberQuery("", "PL"))
+}
+
+func TestGetTelephoneRegion(t *testing.T) {
+	t.Setenv("TELEPHONE_REGION", "")
+	assert.Equal(t, DefaultTelephoneRegion, GetTelephoneRegion())
+
+	t.Setenv("TELEPHONE_REGION", "de")
+	assert.Equal(t, "DE", GetTelephoneRegion())
+
+	t.Setenv("TELEPHONE_REGION", "XX")
+	assert.Panics(t, func() { GetTelephoneRegion() })
+}
@@ -16,32 +16,45 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/customers": {
+        "/api/audit": {
             "get": {
-                "description": "Returns full list of existing customers",
+                "description": "Returns changes of customers, purchases and repairs, the latest first",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "list-customers"
+                    "list-audit"
                 ],
-                "summary": "Get list of customers",
+                "summary": "Get audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "first name search",
-                        "name": "firstName",
+                        "description": "ID of changed customer, purchase or repair",
+                        "name": "entityID",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "last name search",
-                        "name": "lastName",
+                        "description": "author of changes",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time of the earliest change",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time of the latest change",
+                        "name": "to",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 10,
+                        "default": 50,
                         "description": "list length",
                         "name": "limit",
                         "in": "query"
@@ -54,38 +67,86 @@ const docTemplate = `{
                         "in": "query"
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AuditLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/catalog/frames": {
+            "get": {
+                "description": "Returns frames of the catalog ordered by SKU, along with quantities in stock",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "list-frames"
+                ],
+                "summary": "Get list of frames",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "search by words found in SKU, brand, model or color",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "SKU or barcode of the frame",
+                        "name": "code",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.Customer"
+                                "$ref": "#/definitions/database.Frame"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Create customer object",
+                "description": "Adds a frame with the quantity initially in stock, the SKU and the barcode cannot be shared",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "create-customer"
+                    "create-frame"
                 ],
-                "summary": "Create customer",
+                "summary": "Add a frame to the catalog",
                 "parameters": [
                     {
-                        "description": "Customer details",
-                        "name": "customerDetails",
+                        "description": "Frame details",
+                        "name": "frameDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreateCustomerRequest"
+                            "$ref": "#/definitions/server.CreateFrameRequest"
                         }
                     }
                 ],
@@ -93,33 +154,34 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.Customer"
+                            "$ref": "#/definitions/database.Frame"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/customers/{customerID}": {
+        "/api/catalog/frames/{productID}": {
             "get": {
-                "description": "Returns customer details by ID",
+                "description": "Returns a frame of the catalog by ID",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "get-customer"
+                    "get-frame"
                 ],
-                "summary": "Get customer",
+                "summary": "Get a frame",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
+                        "description": "Frame ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
                     }
@@ -128,47 +190,51 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Customer"
+                            "$ref": "#/definitions/database.Frame"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Edit customer details by ID",
-                "produces": [
+                "description": "Changes details of a frame of the catalog by ID, the stock is changed by stock adjustments only",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "edit-customer"
+                    "update-frame"
                 ],
-                "summary": "Edit customer",
+                "summary": "Edit a frame",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
+                        "description": "Frame ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "New customer details",
-                        "name": "customerDetails",
+                        "description": "New frame details",
+                        "name": "frameDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.EditCustomerDetailsRequest"
+                            "$ref": "#/definitions/server.EditFrameRequest"
                         }
                     }
                 ],
@@ -176,74 +242,107 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Customer"
+                            "$ref": "#/definitions/database.Frame"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete customer details and it's relations by ID",
+            }
+        },
+        "/api/catalog/frames/{productID}/stock": {
+            "post": {
+                "description": "Adds delivered frames to the stock, negative quantities write frames off",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "delete-customer"
+                    "adjust-frame-stock"
                 ],
-                "summary": "Delete customer",
+                "summary": "Adjust stock of a frame",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
+                        "description": "Frame ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Quantity added to the stock",
+                        "name": "adjustment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.StockAdjustmentRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Frame"
+                        }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "out of stock",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/customers/{customerID}/purchases": {
+        "/api/catalog/lens-types": {
             "get": {
-                "description": "Returns full list of purchases for a specific customer by ID",
+                "description": "Returns lens types of the catalog ordered by SKU, along with pairs of lenses in stock",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "get-customer-purchases"
+                    "list-lens-types"
                 ],
-                "summary": "Get list of purchases",
+                "summary": "Get list of lens types",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
-                        "in": "path",
-                        "required": true
+                        "description": "search by words found in SKU, name, material or coating",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "SKU or barcode of the lens type",
+                        "name": "code",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -252,216 +351,181 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.Purchase"
+                                "$ref": "#/definitions/database.LensType"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Creates a new purchase for a customer by ID",
+                "description": "Adds a lens type with pairs of lenses initially in stock, the SKU and the barcode cannot be shared",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "create-customer-purchase"
+                    "create-lens-type"
                 ],
-                "summary": "Create a purchase for a customer",
+                "summary": "Add a lens type to the catalog",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Purchase details",
-                        "name": "purchaseDetails",
+                        "description": "Lens type details",
+                        "name": "lensTypeDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreatePurchaseRequest"
+                            "$ref": "#/definitions/server.CreateLensTypeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.Purchase"
+                            "$ref": "#/definitions/database.LensType"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/customers/{customerID}/purchases/{purchaseID}": {
-            "put": {
-                "description": "Updates a purchase for a customer by ID",
+        "/api/catalog/lens-types/{productID}": {
+            "get": {
+                "description": "Returns a lens type of the catalog by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "update-customer-purchase"
+                    "get-lens-type"
                 ],
-                "summary": "Update a purchase",
+                "summary": "Get a lens type",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Purchase ID",
-                        "name": "purchaseID",
+                        "description": "Lens type ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New purchase details",
-                        "name": "customerDetails",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.EditPurchaseRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Purchase"
+                            "$ref": "#/definitions/database.LensType"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Deletes a purchase by ID",
+            "put": {
+                "description": "Changes details of a lens type of the catalog by ID, the stock is changed by stock adjustments only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "delete-customer-purchase"
+                    "update-lens-type"
                 ],
-                "summary": "Delete a purchase",
+                "summary": "Edit a lens type",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
+                        "description": "Lens type ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Purchase ID",
-                        "name": "purchaseID",
-                        "in": "path",
-                        "required": true
+                        "description": "New lens type details",
+                        "name": "lensTypeDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditLensTypeRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.LensType"
+                        }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/customers/{customerID}/repairs": {
-            "get": {
-                "description": "Returns full list of repairs for a specific customer by ID",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "get-customer-repairs"
-                ],
-                "summary": "Get list of repairs",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Repair"
-                            }
-                        }
-                    }
-                }
-            },
+        "/api/catalog/lens-types/{productID}/stock": {
             "post": {
-                "description": "Creates a new repair for a customer by ID",
+                "description": "Adds delivered pairs of lenses to the stock, negative quantities write them off",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "create-customer-repair"
+                    "adjust-lens-type-stock"
                 ],
-                "summary": "Create a repair for a customer",
+                "summary": "Adjust stock of a lens type",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customerID",
+                        "description": "Lens type ID",
+                        "name": "productID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Repair details",
-                        "name": "repairDetails",
+                        "description": "Pairs added to the stock",
+                        "name": "adjustment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreateRepairRequest"
+                            "$ref": "#/definitions/server.StockAdjustmentRequest"
                         }
                     }
                 ],
@@ -469,31 +533,270 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Repair"
+                            "$ref": "#/definitions/database.LensType"
                         }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "out of stock",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/customers/{customerID}/repairs/{repairID}": {
-            "delete": {
-                "description": "Deletes a repair by ID",
+        "/api/customers": {
+            "get": {
+                "description": "Returns customers matching all given filters along with the number of all matching customers.\nEach word of the q search has to be found in the name, email or telephone number of the customer.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "delete-customer-repair"
+                    "list-customers"
                 ],
-                "summary": "Delete a repair",
+                "summary": "Get list of customers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "search by words found in name, email or telephone number",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "first name search",
+                        "name": "firstName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last name search",
+                        "name": "lastName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "telephone number search, in any format",
+                        "name": "telephoneNumber",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "email search",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "earliest creation date, YYYY-MM-DD",
+                        "name": "createdFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "latest creation date, YYYY-MM-DD",
+                        "name": "createdTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "customers with or without repairs in the workshop queue",
+                        "name": "hasOpenRepairs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "customers with purchases since the date, YYYY-MM-DD",
+                        "name": "purchasedSince",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "last_name,-created_at",
+                        "description": "comma separated fields, prefixed with minus to sort descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of another page, used instead of offset",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "list length",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "list offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted customers",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CustomerPageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create customer object",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer"
+                ],
+                "summary": "Create customer",
+                "parameters": [
+                    {
+                        "description": "Customer details",
+                        "name": "customerDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/search": {
+            "get": {
+                "description": "Finds customers by names despite typos and missing diacritics, e.g. \"Wisniewsky\" finds \"Wiśniewski\",\nwords of digits are found in telephone numbers. Best matches come first, scored from 0 to 1,\nwith highlights marking matched characters of first_name, last_name and telephone_number.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "search-customers"
+                ],
+                "summary": "Search customers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "searched names or telephone number digits",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "number of best matches",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CustomerSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/unpaid": {
+            "get": {
+                "description": "Returns customers who owe an outstanding amount for their purchases and repairs, ordered by names,\nwith totals in currencies they owe in",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "list-unpaid-balances"
+                ],
+                "summary": "Get customers with unpaid balances",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repositories.UnpaidBalance"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}": {
+            "get": {
+                "description": "Returns customer details by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer"
+                ],
+                "summary": "Get customer",
                 "parameters": [
                     {
                         "type": "string",
@@ -502,36 +805,2676 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted customers",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Customer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the customer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Edit customer details by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "edit-customer"
+                ],
+                "summary": "Edit customer",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Repair ID",
-                        "name": "repairID",
+                        "description": "Customer ID",
+                        "name": "customerID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the customer",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New customer details",
+                        "name": "customerDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditCustomerDetailsRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Customer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the customer"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "invalid request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "IMPLEMENTED BUT DOCS TODO",
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
+            },
+            "delete": {
+                "description": "Delete customer details and it's relations by ID",
+                "produces": [
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "delete-customer"
+                ],
+                "summary": "Delete customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the customer",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes only customer details given in the JSON merge patch (RFC 7396)",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "edit-customer"
+                ],
+                "summary": "Patch customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the customer",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Customer details to change",
+                        "name": "customerDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditCustomerDetailsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Customer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the customer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/balance": {
+            "get": {
+                "description": "Returns amounts due, paid and outstanding for each purchase and repair of a customer by ID,\nalong with their totals per currency. Deleted purchases and repairs are left out,\ncancelled repairs are not due. Negative outstanding amounts are owed to the customer.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-balance"
+                ],
+                "summary": "Get balance of a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repositories.CustomerBalance"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/invoices": {
+            "get": {
+                "description": "Returns invoices issued to a customer by ID, the latest issued first",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-invoices"
+                ],
+                "summary": "Get list of invoices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Invoice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Issues an invoice for purchases and repairs of a customer by ID, each of which can be invoiced once.\nInvoices are numbered with a sequence without gaps which starts over every year, e.g. FV/2024/0001.\nAn invoice cannot be issued before the latest invoice of the year, cancelled repairs are not invoiced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer-invoice"
+                ],
+                "summary": "Issue an invoice to a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Invoiced purchases and repairs",
+                        "name": "invoiceDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateInvoiceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already invoiced or issued before the latest invoice",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/payments": {
+            "get": {
+                "description": "Returns payments and refunds of a customer by ID, the latest paid first",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-payments"
+                ],
+                "summary": "Get list of payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Payment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Records a payment or a refund of a purchase or a repair of a customer by ID, e.g. a deposit paid\nwhen glasses are ordered. Payments cannot exceed the outstanding balance of what they pay for,\nnor refunds the amount paid for it. Payments cannot be changed, a mistaken one is refunded instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer-payment"
+                ],
+                "summary": "Record a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment details",
+                        "name": "paymentDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreatePaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "balance exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/prescriptions": {
+            "get": {
+                "description": "Returns prescriptions history for a specific customer by ID, latest first",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-prescriptions"
+                ],
+                "summary": "Get list of prescriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Prescription"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new prescription for a customer by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer-prescription"
+                ],
+                "summary": "Create a prescription for a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Prescription details",
+                        "name": "prescriptionDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreatePrescriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/prescriptions/{prescriptionID}": {
+            "get": {
+                "description": "Returns prescription details by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-prescription"
+                ],
+                "summary": "Get a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a prescription for a customer by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "update-customer-prescription"
+                ],
+                "summary": "Update a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New prescription details",
+                        "name": "prescriptionDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditPrescriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a prescription by ID, purchases made from it are kept",
+                "produces": [
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "delete-customer-prescription"
+                ],
+                "summary": "Delete a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/purchases": {
+            "get": {
+                "description": "Returns full list of purchases for a specific customer by ID, the latest purchased first.\nWhen cursor or limit is given, a page of purchases is returned in {\"data\", \"next_cursor\", \"prev_cursor\"}.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-purchases"
+                ],
+                "summary": "Get list of purchases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of another page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "page length",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted purchases",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Purchase"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new purchase for a customer by ID. The frame and the lens type picked from the catalog\nare taken out of stock, items out of stock are sold only on backorder, with stock_warnings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer-purchase"
+                ],
+                "summary": "Create a purchase for a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Purchase details",
+                        "name": "purchaseDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreatePurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Purchase"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "out of stock",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/purchases/{purchaseID}": {
+            "get": {
+                "description": "Returns purchase of a customer by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-purchase"
+                ],
+                "summary": "Get a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Purchase ID",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted purchases",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Purchase"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a purchase for a customer by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "update-customer-purchase"
+                ],
+                "summary": "Update a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Purchase ID",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the purchase",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New purchase details",
+                        "name": "customerDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditPurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Purchase"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a purchase by ID",
+                "produces": [
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "delete-customer-purchase"
+                ],
+                "summary": "Delete a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Purchase ID",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the purchase",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes only purchase details given in the JSON merge patch (RFC 7396).\nWhen another prescription is given without lens power or PD, they are taken from the prescription.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "update-customer-purchase"
+                ],
+                "summary": "Patch a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Purchase ID",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the purchase",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Purchase details to change",
+                        "name": "purchaseDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditPurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Purchase"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/purchases/{purchaseID}/restore": {
+            "post": {
+                "description": "Restores deleted purchase of an existing customer by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "restore-customer-purchase"
+                ],
+                "summary": "Restore a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Purchase ID",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Purchase"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/repairs": {
+            "get": {
+                "description": "Returns full list of repairs for a specific customer by ID, the latest created first.\nWhen cursor or limit is given, a page of repairs is returned in {\"data\", \"next_cursor\", \"prev_cursor\"}.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-repairs"
+                ],
+                "summary": "Get list of repairs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of another page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "page length",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted repairs",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Repair"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new repair for a customer by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "create-customer-repair"
+                ],
+                "summary": "Create a repair for a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Repair details",
+                        "name": "repairDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRepairRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Repair"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/repairs/{repairID}": {
+            "get": {
+                "description": "Returns repair of a customer by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-customer-repair"
+                ],
+                "summary": "Get a repair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repair ID",
+                        "name": "repairID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted repairs",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Repair"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the repair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates repair details for a customer by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "update-customer-repair"
+                ],
+                "summary": "Update a repair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repair ID",
+                        "name": "repairID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the repair",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New repair details",
+                        "name": "repairDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EditRepairRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Repair"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the repair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a repair by ID",
+                "produces": [
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "delete-customer-repair"
+                ],
+                "summary": "Delete a repair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repair ID",
+                        "name": "repairID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the repair",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes only repair details given in the JSON merge patch (RFC 7396).\nAllowed status transitions are:\nreported -\u003e in_progress, cancelled;\nin_progress -\u003e waiting_for_parts, ready_for_pickup, cancelled;\nwaiting_for_parts -\u003e in_progress, cancelled;\nready_for_pickup -\u003e collected, in_progress, cancelled.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "update-customer-repair"
+                ],
+                "summary": "Patch a repair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repair ID",
+                        "name": "repairID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the repair",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Repair details to change",
+                        "name": "repairDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PatchRepairRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Repair"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the repair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "status transition not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "resource changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/repairs/{repairID}/restore": {
+            "post": {
+                "description": "Restores deleted repair of an existing customer by ID",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "restore-customer-repair"
+                ],
+                "summary": "Restore a repair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repair ID",
+                        "name": "repairID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Repair"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the repair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/customers/{customerID}/restore": {
+            "post": {
+                "description": "Restores deleted customer together with purchases and repairs deleted with them",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "restore-customer"
+                ],
+                "summary": "Restore customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Customer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the customer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/inventory/reorder-suggestions": {
+            "get": {
+                "description": "Returns frames and lens types expected to fall below their reorder thresholds within the lead time,\ngiven sales of recent purchases, grouped by suppliers with quantities recommended to reorder.\nSuggestions of the last run of the reorder job are returned unless refresh is set.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "list-reorder-suggestions"
+                ],
+                "summary": "Get reorder suggestions",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "compute suggestions from the current stock",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repositories.ReorderSuggestions"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invoices/{invoiceID}": {
+            "get": {
+                "description": "Returns invoice by ID along with its items",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-invoice"
+                ],
+                "summary": "Get an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invoices/{invoiceID}.pdf": {
+            "get": {
+                "description": "Returns invoice by ID as an A4 PDF document, labelled in the language accepted by the client",
+                "produces": [
+                    "application/pdf",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "get-invoice"
+                ],
+                "summary": "Print an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of labels, en or pl",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/repairs": {
+            "get": {
+                "description": "Returns repairs of all customers filtered by status, the longest waiting first.\nWhen no status is given, all repairs which are not collected nor cancelled are returned.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "list-repairs"
+                ],
+                "summary": "Get workshop queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "comma separated list of statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include deleted repairs",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Repair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "database.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "street": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.AuditAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "restore"
+            ],
+            "x-enum-varnames": [
+                "AuditCreate",
+                "AuditUpdate",
+                "AuditDelete",
+                "AuditRestore"
+            ]
+        },
+        "database.AuditChanges": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/database.FieldChange"
+            }
+        },
+        "database.AuditEntity": {
+            "type": "string",
+            "enum": [
+                "customer",
+                "purchase",
+                "repair",
+                "invoice",
+                "payment",
+                "frame",
+                "lens_type"
+            ],
+            "x-enum-varnames": [
+                "AuditCustomer",
+                "AuditPurchase",
+                "AuditRepair",
+                "AuditInvoice",
+                "AuditPayment",
+                "AuditFrame",
+                "AuditLensType"
+            ]
+        },
+        "database.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/database.AuditAction"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "changes": {
+                    "$ref": "#/definitions/database.AuditChanges"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "$ref": "#/definitions/database.AuditEntity"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.ContactChannel": {
+            "type": "string",
+            "enum": [
+                "telephone",
+                "sms",
+                "email",
+                "post"
+            ],
+            "x-enum-varnames": [
+                "ContactTelephone",
+                "ContactSMS",
+                "ContactEmail",
+                "ContactPost"
+            ]
+        },
+        "database.Customer": {
+            "type": "object",
+            "required": [
+                "first_name",
+                "last_name",
+                "telephone_number"
+            ],
+            "properties": {
+                "address": {
+                    "$ref": "#/definitions/database.Address"
+                },
+                "birth_date": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "preferred_contact": {
+                    "$ref": "#/definitions/database.ContactChannel"
+                },
+                "telephone_number": {
+                    "type": "string"
+                },
+                "telephone_number_e164": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.EyePrescription": {
+            "type": "object",
+            "properties": {
+                "add": {
+                    "type": "number"
+                },
+                "axis": {
+                    "type": "integer"
+                },
+                "base": {
+                    "type": "string"
+                },
+                "cylinder": {
+                    "type": "number"
+                },
+                "prism": {
+                    "type": "number"
+                },
+                "sphere": {
+                    "type": "number"
+                }
+            }
+        },
+        "database.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "database.Frame": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "brand": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "reorder_threshold": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "supplier": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Invoice": {
+            "type": "object",
+            "properties": {
+                "buyer": {
+                    "$ref": "#/definitions/database.InvoiceParty"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.InvoiceItem"
+                    }
+                },
+                "net": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "seller": {
+                    "$ref": "#/definitions/database.InvoiceParty"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "total": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "vat": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.InvoiceItem": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "net": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "purchase_id": {
+                    "type": "string"
+                },
+                "repair_id": {
+                    "type": "string"
+                },
+                "total": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "unit_price": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "vat": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "vat_rate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.InvoiceParty": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "$ref": "#/definitions/database.Address"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tax_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.LensPower": {
+            "type": "object",
+            "properties": {
+                "left": {
+                    "$ref": "#/definitions/database.EyePrescription"
+                },
+                "right": {
+                    "$ref": "#/definitions/database.EyePrescription"
+                }
+            }
+        },
+        "database.LensType": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "coating": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "material": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reorder_threshold": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "supplier": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Money": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "120.50"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "PLN"
+                }
+            }
+        },
+        "database.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "$ref": "#/definitions/database.PaymentMethod"
+                },
+                "paid_at": {
+                    "type": "string"
+                },
+                "purchase_id": {
+                    "type": "string"
+                },
+                "refund": {
+                    "type": "boolean"
+                },
+                "repair_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PaymentMethod": {
+            "type": "string",
+            "enum": [
+                "cash",
+                "card",
+                "transfer"
+            ],
+            "x-enum-varnames": [
+                "PaymentCash",
+                "PaymentCard",
+                "PaymentTransfer"
+            ]
+        },
+        "database.Prescription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "issued_by": {
+                    "type": "string"
+                },
+                "lens_power": {
+                    "$ref": "#/definitions/database.LensPower"
+                },
+                "pd": {
+                    "$ref": "#/definitions/database.PupillaryDistance"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PupillaryDistance": {
+            "type": "object",
+            "properties": {
+                "binocular": {
+                    "type": "number"
+                },
+                "left": {
+                    "type": "number"
+                },
+                "right": {
+                    "type": "number"
+                }
+            }
+        },
+        "database.Purchase": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "frame_id": {
+                    "type": "string"
+                },
+                "frame_model": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lens_power": {
+                    "$ref": "#/definitions/database.LensPower"
+                },
+                "lens_type": {
+                    "type": "string"
+                },
+                "lens_type_id": {
+                    "type": "string"
+                },
+                "pd": {
+                    "$ref": "#/definitions/database.PupillaryDistance"
+                },
+                "prescription_id": {
+                    "type": "string"
+                },
+                "price": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "purchase_type": {
+                    "type": "string"
+                },
+                "purchased_at": {
+                    "type": "string"
+                },
+                "stock_warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vat": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "vat_rate": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Repair": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "collected_at": {
+                    "type": "string"
+                },
+                "cost": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "in_progress_at": {
+                    "type": "string"
+                },
+                "ready_for_pickup_at": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/database.RepairStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "waiting_for_parts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.RepairStatus": {
+            "type": "string",
+            "enum": [
+                "reported",
+                "in_progress",
+                "waiting_for_parts",
+                "ready_for_pickup",
+                "collected",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "RepairReported",
+                "RepairInProgress",
+                "RepairWaitingForParts",
+                "RepairReadyForPickup",
+                "RepairCollected",
+                "RepairCancelled"
+            ]
+        },
+        "repositories.Balance": {
+            "type": "object",
+            "properties": {
+                "due": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "outstanding": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "paid": {
+                    "$ref": "#/definitions/database.Money"
+                }
+            }
+        },
+        "repositories.CustomerBalance": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "purchases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.ItemBalance"
+                    }
+                },
+                "repairs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.ItemBalance"
+                    }
+                },
+                "totals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.Balance"
+                    }
+                }
+            }
+        },
+        "repositories.CustomerMatch": {
+            "type": "object",
+            "properties": {
+                "customer": {
+                    "$ref": "#/definitions/database.Customer"
+                },
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.MatchSpan"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "repositories.ItemBalance": {
+            "type": "object",
+            "properties": {
+                "due": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "outstanding": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "paid": {
+                    "$ref": "#/definitions/database.Money"
+                },
+                "purchase_id": {
+                    "type": "string"
+                },
+                "repair_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "repositories.MatchSpan": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repositories.ReorderItem": {
+            "type": "object",
+            "properties": {
+                "daily_sales": {
+                    "type": "number"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "frame"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reorder_threshold": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "sold": {
+                    "type": "integer"
+                },
+                "stock": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repositories.ReorderSuggestions": {
+            "type": "object",
+            "properties": {
+                "computed_at": {
+                    "type": "string"
+                },
+                "suppliers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.SupplierReorder"
+                    }
+                }
+            }
+        },
+        "repositories.SupplierReorder": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.ReorderItem"
+                    }
+                },
+                "supplier": {
+                    "type": "string"
+                }
+            }
+        },
+        "repositories.UnpaidBalance": {
+            "type": "object",
+            "properties": {
+                "customer": {
+                    "$ref": "#/definitions/database.Customer"
+                },
+                "totals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.Balance"
+                    }
+                }
+            }
+        },
+        "server.AddressRequest": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "PL"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "street": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.AuditEntry"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
             }
-        }
-    },
-    "definitions": {
-        "database.Customer": {
+        },
+        "server.CreateCustomerRequest": {
             "type": "object",
             "required": [
                 "first_name",
@@ -539,151 +3482,283 @@ const docTemplate = `{
                 "telephone_number"
             ],
             "properties": {
-                "created_at": {
+                "address": {
+                    "$ref": "#/definitions/server.AddressRequest"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1980-01-01"
+                },
+                "email": {
                     "type": "string"
                 },
                 "first_name": {
                     "type": "string"
                 },
-                "id": {
+                "last_name": {
                     "type": "string"
                 },
-                "last_name": {
+                "notes": {
                     "type": "string"
                 },
+                "preferred_contact": {
+                    "type": "string",
+                    "example": "sms"
+                },
                 "telephone_number": {
                     "type": "string"
+                }
+            }
+        },
+        "server.CreateFrameRequest": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "example": "8056597000000"
                 },
-                "updated_at": {
-                    "type": "string"
+                "brand": {
+                    "type": "string",
+                    "example": "Ray-Ban"
+                },
+                "color": {
+                    "type": "string",
+                    "example": "Black"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "RB5154"
+                },
+                "reorder_threshold": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "size": {
+                    "type": "string",
+                    "example": "51-21-145"
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "RB5154-BLK-51"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "supplier": {
+                    "type": "string",
+                    "example": "Luxottica"
                 }
             }
         },
-        "database.Purchase": {
+        "server.CreateInvoiceRequest": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "buyer_tax_id": {
+                    "type": "string",
+                    "example": "5260250274"
                 },
-                "customer_id": {
-                    "type": "string"
+                "issued_at": {
+                    "type": "string",
+                    "example": "2024-01-31"
                 },
-                "frame_model": {
-                    "type": "string"
+                "purchase_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "id": {
-                    "type": "string"
+                "repair_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "lens_power": {
+                "repair_vat_rate": {
+                    "type": "integer",
+                    "example": 23
+                }
+            }
+        },
+        "server.CreateLensTypeRequest": {
+            "type": "object",
+            "properties": {
+                "barcode": {
                     "type": "string"
                 },
-                "lens_type": {
-                    "type": "string"
+                "coating": {
+                    "type": "string",
+                    "example": "Anti-reflective"
                 },
-                "pd": {
-                    "type": "string"
+                "material": {
+                    "type": "string",
+                    "example": "Polycarbonate"
                 },
-                "purchase_type": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "example": "Single vision"
                 },
-                "purchased_at": {
-                    "type": "string"
+                "reorder_threshold": {
+                    "type": "integer",
+                    "example": 5
                 },
-                "updated_at": {
-                    "type": "string"
+                "sku": {
+                    "type": "string",
+                    "example": "SV-PC-AR"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "supplier": {
+                    "type": "string",
+                    "example": "Hoya"
                 }
             }
         },
-        "database.Repair": {
+        "server.CreatePaymentRequest": {
             "type": "object",
             "properties": {
-                "cost": {
-                    "type": "number"
+                "amount": {
+                    "$ref": "#/definitions/server.MoneyRequest"
                 },
-                "created_at": {
-                    "type": "string"
+                "method": {
+                    "type": "string",
+                    "example": "card"
                 },
-                "customer_id": {
-                    "type": "string"
+                "paid_at": {
+                    "type": "string",
+                    "example": "2024-01-31T15:04:05Z"
                 },
-                "description": {
+                "purchase_id": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "refund": {
+                    "type": "boolean"
                 },
-                "reported_at": {
+                "repair_id": {
                     "type": "string"
                 }
             }
         },
-        "server.CreateCustomerRequest": {
+        "server.CreatePrescriptionRequest": {
             "type": "object",
             "required": [
-                "first_name",
-                "last_name",
-                "telephone_number"
+                "issued_by"
             ],
             "properties": {
-                "first_name": {
-                    "type": "string"
+                "expires_at": {
+                    "type": "string",
+                    "example": "2022-01-01"
                 },
-                "last_name": {
-                    "type": "string"
+                "issued_at": {
+                    "type": "string",
+                    "example": "2021-01-01"
                 },
-                "telephone_number": {
+                "issued_by": {
                     "type": "string"
+                },
+                "lens_power": {
+                    "$ref": "#/definitions/server.LensPowerRequest"
+                },
+                "pd": {
+                    "$ref": "#/definitions/server.PupillaryDistanceRequest"
                 }
             }
         },
         "server.CreatePurchaseRequest": {
             "type": "object",
             "required": [
-                "frame_model",
-                "lens_power",
-                "lens_type",
-                "pd",
-                "purchase_type",
-                "purchased_at"
+                "purchase_type"
             ],
             "properties": {
+                "backorder": {
+                    "type": "boolean"
+                },
+                "discount": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
+                "frame_id": {
+                    "type": "string"
+                },
                 "frame_model": {
                     "type": "string"
                 },
                 "lens_power": {
-                    "type": "string"
+                    "$ref": "#/definitions/server.LensPowerRequest"
                 },
                 "lens_type": {
                     "type": "string"
                 },
+                "lens_type_id": {
+                    "type": "string"
+                },
                 "pd": {
+                    "$ref": "#/definitions/server.PupillaryDistanceRequest"
+                },
+                "prescription_id": {
                     "type": "string"
                 },
+                "price": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
                 "purchase_type": {
                     "type": "string"
                 },
                 "purchased_at": {
-                    "description": "TODO - when invalid date specified it returns field is required",
-                    "type": "string"
+                    "type": "string",
+                    "example": "2021-01-01"
+                },
+                "vat_rate": {
+                    "type": "integer",
+                    "example": 23
                 }
             }
         },
         "server.CreateRepairRequest": {
             "type": "object",
             "required": [
-                "cost",
-                "description",
-                "reported_at"
+                "description"
             ],
             "properties": {
                 "cost": {
-                    "type": "string"
+                    "$ref": "#/definitions/server.MoneyRequest"
                 },
                 "description": {
                     "type": "string"
                 },
                 "reported_at": {
+                    "type": "string",
+                    "example": "2021-01-01"
+                }
+            }
+        },
+        "server.CustomerPageResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Customer"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.CustomerSearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.CustomerMatch"
+                    }
                 }
             }
         },
@@ -695,46 +3770,322 @@ const docTemplate = `{
                 "telephone_number"
             ],
             "properties": {
+                "address": {
+                    "$ref": "#/definitions/server.AddressRequest"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1980-01-01"
+                },
+                "email": {
+                    "type": "string"
+                },
                 "first_name": {
                     "type": "string"
                 },
                 "last_name": {
                     "type": "string"
                 },
+                "notes": {
+                    "type": "string"
+                },
+                "preferred_contact": {
+                    "type": "string",
+                    "example": "sms"
+                },
                 "telephone_number": {
                     "type": "string"
                 }
             }
         },
+        "server.EditFrameRequest": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "example": "8056597000000"
+                },
+                "brand": {
+                    "type": "string",
+                    "example": "Ray-Ban"
+                },
+                "color": {
+                    "type": "string",
+                    "example": "Black"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "RB5154"
+                },
+                "reorder_threshold": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "size": {
+                    "type": "string",
+                    "example": "51-21-145"
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "RB5154-BLK-51"
+                },
+                "supplier": {
+                    "type": "string",
+                    "example": "Luxottica"
+                }
+            }
+        },
+        "server.EditLensTypeRequest": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "coating": {
+                    "type": "string",
+                    "example": "Anti-reflective"
+                },
+                "material": {
+                    "type": "string",
+                    "example": "Polycarbonate"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Single vision"
+                },
+                "reorder_threshold": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "SV-PC-AR"
+                },
+                "supplier": {
+                    "type": "string",
+                    "example": "Hoya"
+                }
+            }
+        },
+        "server.EditPrescriptionRequest": {
+            "type": "object",
+            "required": [
+                "issued_by"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2022-01-01"
+                },
+                "issued_at": {
+                    "type": "string",
+                    "example": "2021-01-01"
+                },
+                "issued_by": {
+                    "type": "string"
+                },
+                "lens_power": {
+                    "$ref": "#/definitions/server.LensPowerRequest"
+                },
+                "pd": {
+                    "$ref": "#/definitions/server.PupillaryDistanceRequest"
+                }
+            }
+        },
         "server.EditPurchaseRequest": {
             "type": "object",
             "required": [
-                "frame_model",
-                "lens_power",
-                "lens_type",
-                "pd",
-                "purchase_type",
-                "purchased_at"
+                "purchase_type"
             ],
             "properties": {
+                "discount": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
                 "frame_model": {
                     "type": "string"
                 },
                 "lens_power": {
-                    "type": "string"
+                    "$ref": "#/definitions/server.LensPowerRequest"
                 },
                 "lens_type": {
                     "type": "string"
                 },
                 "pd": {
+                    "$ref": "#/definitions/server.PupillaryDistanceRequest"
+                },
+                "prescription_id": {
                     "type": "string"
                 },
+                "price": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
                 "purchase_type": {
                     "type": "string"
                 },
                 "purchased_at": {
-                    "description": "TODO - when invalid date specified it returns field is required",
+                    "type": "string",
+                    "example": "2021-01-01"
+                },
+                "vat_rate": {
+                    "type": "integer",
+                    "example": 23
+                }
+            }
+        },
+        "server.EditRepairRequest": {
+            "type": "object",
+            "required": [
+                "description"
+            ],
+            "properties": {
+                "cost": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string",
+                    "example": "2021-01-01"
+                }
+            }
+        },
+        "server.EyePrescriptionRequest": {
+            "type": "object",
+            "properties": {
+                "add": {
+                    "type": "number"
+                },
+                "axis": {
+                    "type": "integer"
+                },
+                "base": {
+                    "type": "string"
+                },
+                "cylinder": {
+                    "type": "number"
+                },
+                "prism": {
+                    "type": "number"
+                },
+                "sphere": {
+                    "type": "number"
+                }
+            }
+        },
+        "server.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "required"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "The 'first_name' is required"
+                }
+            }
+        },
+        "server.LensPowerRequest": {
+            "type": "object",
+            "properties": {
+                "left": {
+                    "$ref": "#/definitions/server.EyePrescriptionRequest"
+                },
+                "right": {
+                    "$ref": "#/definitions/server.EyePrescriptionRequest"
+                }
+            }
+        },
+        "server.MoneyRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "120.50"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "PLN"
+                }
+            }
+        },
+        "server.PatchRepairRequest": {
+            "type": "object",
+            "required": [
+                "description"
+            ],
+            "properties": {
+                "cost": {
+                    "$ref": "#/definitions/server.MoneyRequest"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string",
+                    "example": "2021-01-01"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/server.FieldError"
+                        }
+                    }
+                },
+                "instance": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Resource not found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/not-found"
+                }
+            }
+        },
+        "server.PupillaryDistanceRequest": {
+            "type": "object",
+            "properties": {
+                "binocular": {
+                    "type": "number"
+                },
+                "left": {
+                    "type": "number"
+                },
+                "right": {
+                    "type": "number"
+                }
+            }
+        },
+        "server.StockAdjustmentRequest": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "example": 10
                 }
             }
         }
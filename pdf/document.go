@@ -0,0 +1,186 @@
+// Package pdf writes simple PDF documents made of text and lines, such as invoices, without any dependencies.
+// Text is set in the standard Helvetica fonts, which every PDF viewer provides, so no fonts are embedded.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page size of A4 in points, which are 1/72 of an inch.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+var baseFonts = map[Font]string{Regular: "Helvetica", Bold: "Helvetica-Bold"}
+
+// Document is a PDF document of A4 pages. Positions are given in points from the top left corner of the page.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+}
+
+func NewDocument(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage starts a new page, which following text and lines are drawn on.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws the text with its baseline at y.
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(
+		d.page(),
+		"BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1,
+		number(size),
+		number(x),
+		number(PageHeight-y),
+		escape(encode(text)),
+	)
+}
+
+// TextRight draws the text ending at x, e.g. to align amounts in a column.
+func (d *Document) TextRight(x, y float64, font Font, size float64, text string) {
+	d.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a straight line of the given width.
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(
+		d.page(),
+		"%s w %s %s m %s %s l S\n",
+		number(width),
+		number(x1),
+		number(PageHeight-y1),
+		number(x2),
+		number(PageHeight-y2),
+	)
+}
+
+// number formats the number with up to two decimal places, which is precise enough for positions in points.
+func number(value float64) string {
+	formatted := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+	if formatted == "-0" {
+		return "0"
+	}
+	return formatted
+}
+
+// escape escapes characters delimiting PDF strings, bytes outside of ASCII are written as octal codes.
+func escape(text []byte) string {
+	var escaped strings.Builder
+	for _, b := range text {
+		switch {
+		case b == '(' || b == ')' || b == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteByte(b)
+		case b < 32 || b > 126:
+			fmt.Fprintf(&escaped, "\\%03o", b)
+		default:
+			escaped.WriteByte(b)
+		}
+	}
+	return escaped.String()
+}
+
+// writer numbers objects of the document and keeps their offsets for the cross-reference table.
+type writer struct {
+	buffer  bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) object(format string, args ...any) int {
+	w.offsets = append(w.offsets, w.buffer.Len())
+	fmt.Fprintf(&w.buffer, "%d 0 obj\n", len(w.offsets))
+	fmt.Fprintf(&w.buffer, format, args...)
+	w.buffer.WriteString("\nendobj\n")
+	return len(w.offsets)
+}
+
+func (w *writer) stream(content []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(content); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	w.object("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes())
+	return nil
+}
+
+// WriteTo writes the document in PDF 1.4 format, page contents are compressed.
+func (d *Document) WriteTo(out io.Writer) (int64, error) {
+	d.page()
+	w := &writer{}
+	w.buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// objects referred to before they are written have fixed numbers
+	const catalog, pages, encoding, firstFont = 1, 2, 3, 4
+	w.object("<< /Type /Catalog /Pages %d 0 R >>", pages)
+	pageNumbers := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageNumbers[i] = fmt.Sprintf("%d 0 R", firstFont+len(baseFonts)+2*i)
+	}
+	w.object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageNumbers, " "), len(d.pages))
+	w.object("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [%s] >>", differences())
+	var fonts []string
+	for font := Regular; int(font) < len(baseFonts); font++ {
+		object := w.object(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding %d 0 R >>",
+			baseFonts[font],
+			encoding,
+		)
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", font+1, object))
+	}
+	for _, page := range d.pages {
+		w.object(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pages,
+			number(PageWidth),
+			number(PageHeight),
+			strings.Join(fonts, " "),
+			len(w.offsets)+2,
+		)
+		if err := w.stream(page.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	info := w.object("<< /Title (%s) /Producer (customer-manager) >>", escape(encode(d.title)))
+	xref := w.buffer.Len()
+	fmt.Fprintf(&w.buffer, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		&w.buffer,
+		"trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1,
+		catalog,
+		info,
+		xref,
+	)
+	written, err := out.Write(w.buffer.Bytes())
+	return int64(written), err
+}
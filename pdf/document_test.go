@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeDocument(t *testing.T, document *Document) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	written, err := document.WriteTo(&out)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), written)
+	return out.Bytes()
+}
+
+// pageContents returns uncompressed contents of all pages of the document.
+func pageContents(t *testing.T, document []byte) []string {
+	t.Helper()
+	var contents []string
+	streams := regexp.MustCompile(`(?s)/FlateDecode >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(document, -1)
+	for _, stream := range streams {
+		reader, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func TestDocument(t *testing.T) {
+	t.Run("test write document", func(t *testing.T) {
+		document := NewDocument("Faktura VAT FV/2024/0001")
+		document.Text(40, 60, Bold, 18, "Faktura (kopia)")
+		document.Line(40, 70, 555.28, 70, 0.5)
+		document.AddPage()
+		document.TextRight(555.28, 100, Regular, 10, "Do zapłaty: 12,50 zł")
+
+		out := writeDocument(t, document)
+
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+		assert.Contains(t, string(out), "/Type /Pages /Kids [6 0 R 8 0 R] /Count 2")
+		assert.Contains(t, string(out), "/Title (Faktura VAT FV/2024/0001)")
+		assert.Equal(t, []string{
+			"BT /F2 18 Tf 40 781.89 Td (Faktura \\(kopia\\)) Tj ET\n0.5 w 40 771.89 m 555.28 771.89 l S\n",
+			"BT /F1 10 Tf 467.46 741.89 Td (Do zap\\213aty: 12,50 z\\213) Tj ET\n",
+		}, pageContents(t, out))
+	})
+
+	t.Run("test cross-reference table points at objects", func(t *testing.T) {
+		out := writeDocument(t, NewDocument("Invoice"))
+
+		xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+		start, err := strconv.Atoi(string(xref[1]))
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[start:], []byte("xref\n0 9\n")))
+		offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[start:], -1)
+		assert.Len(t, offsets, 8)
+		for i, offset := range offsets {
+			position, err := strconv.Atoi(string(offset[1]))
+			assert.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(out[position:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+		}
+	})
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, []byte{0x9C, 0xF3, 0x8B, 0x86, ' ', 0x80, ' ', '?'}, encode("Żółć\t€ ✓"))
+	assert.Equal(t, "\\(a\\\\b\\)\\234", escape([]byte{'(', 'a', '\\', 'b', ')', 0x9C}))
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 22.78, TextWidth(Regular, 10, "Hello"), 0.001)
+	assert.InDelta(t, 24.45, TextWidth(Bold, 10, "Hello"), 0.001)
+	assert.Equal(t, TextWidth(Regular, 10, "Zazolc gesla jazn"), TextWidth(Regular, 10, "Zażółć gęślą jaźń"))
+	assert.Equal(t, TextWidth(Regular, 10, "L"), TextWidth(Regular, 10, "Ł"))
+}
@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// polishGlyphs are Polish letters missing from WinAnsiEncoding, they replace rarely used characters
+// of the encoding and are given by their glyph names, which standard fonts have.
+var polishGlyphs = []struct {
+	letter rune
+	code   byte
+	glyph  string
+}{
+	{'Ą', 0x81, "Aogonek"},
+	{'ą', 0x82, "aogonek"},
+	{'Ć', 0x83, "Cacute"},
+	{'ć', 0x86, "cacute"},
+	{'Ę', 0x87, "Eogonek"},
+	{'ę', 0x88, "eogonek"},
+	{'Ł', 0x89, "Lslash"},
+	{'ł', 0x8B, "lslash"},
+	{'Ń', 0x8C, "Nacute"},
+	{'ń', 0x8D, "nacute"},
+	{'Ś', 0x8F, "Sacute"},
+	{'ś', 0x90, "sacute"},
+	{'Ź', 0x98, "Zacute"},
+	{'ź', 0x9B, "zacute"},
+	{'Ż', 0x9C, "Zdotaccent"},
+	{'ż', 0x9D, "zdotaccent"},
+}
+
+// winAnsiCodes are characters of WinAnsiEncoding which are not at their Unicode code points.
+var winAnsiCodes = map[rune]byte{
+	'€': 0x80, '„': 0x84, '…': 0x85, 'Š': 0x8A, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, 'š': 0x9A, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+func init() {
+	for _, glyph := range polishGlyphs {
+		winAnsiCodes[glyph.letter] = glyph.code
+	}
+}
+
+// differences returns the Differences array of the encoding which maps codes to Polish letters.
+func differences() string {
+	entries := make([]string, len(polishGlyphs))
+	for i, glyph := range polishGlyphs {
+		entries[i] = fmt.Sprintf("%d /%s", glyph.code, glyph.glyph)
+	}
+	return strings.Join(entries, " ")
+}
+
+// encode converts the text to codes of the encoding, characters which cannot be encoded become '?'.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch code, ok := winAnsiCodes[r]; {
+		case ok:
+			encoded = append(encoded, code)
+		case r < ' ':
+			encoded = append(encoded, ' ')
+		case r < 0x7F || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// Widths of ASCII characters, from space to tilde, in thousandths of the font size, as given
+// in Adobe font metrics of the standard fonts.
+var asciiWidths = map[Font][95]int{
+	Regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	Bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// defaultWidth is the width of characters outside of ASCII, other than letters with diacritics.
+const defaultWidth = 556
+
+// charWidth returns the width of the character, letters with diacritics are as wide as their base letters.
+func charWidth(font Font, r rune) int {
+	switch r {
+	case 'Ł':
+		r = 'L'
+	case 'ł':
+		r = 'l'
+	default:
+		r = []rune(norm.NFD.String(string(r)))[0]
+	}
+	if r < ' ' || r > '~' {
+		return defaultWidth
+	}
+	return asciiWidths[font][r-' ']
+}
+
+// TextWidth returns the width of the text set in the font of the given size, in points.
+func TextWidth(font Font, size float64, text string) float64 {
+	width := 0
+	for _, r := range text {
+		width += charWidth(font, r)
+	}
+	return float64(width) * size / 1000
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SystemActor is recorded as the actor of changes made without an actor in the context, e.g. by commands.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns context which makes repositories record the given actor as the author of changes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func getActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// recordAudit stores the change of an entity in the audit log. It has to be given the transaction
+// of the change, so the change is not persisted without its audit entry. Updates which did not change
+// any field are not recorded.
+func recordAudit(
+	ctx context.Context,
+	tx *gorm.DB,
+	action database.AuditAction,
+	entity database.AuditEntity,
+	entityID string,
+	before any,
+	after any,
+) error {
+	changes, err := database.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if action == database.AuditUpdate && len(changes) == 0 {
+		return nil
+	}
+	return tx.Create(&database.AuditEntry{
+		Action:     action,
+		EntityType: entity,
+		EntityID:   entityID,
+		Actor:      getActor(ctx),
+		Changes:    changes,
+	}).Error
+}
+
+// updateWithAudit loads the entity by ID, applies the update to it and records the difference between
+// the entity before and after the update, all in a single transaction. The update is given a copy
+// of the loaded entity. The entity reloaded after the update is returned, gorm.ErrRecordNotFound
+// is returned when the entity does not exist.
+func updateWithAudit[T any](
+	ctx context.Context,
+	db *gorm.DB,
+	entity database.AuditEntity,
+	entityID string,
+	update func(tx *gorm.DB, current *T) error,
+) (error, *T) {
+	var after T
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before T
+		if err := tx.Where("id = ?", entityID).First(&before).Error; err != nil {
+			return err
+		}
+		current := before
+		if err := update(tx, &current); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", entityID).First(&after).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditUpdate, entity, entityID, &before, &after)
+	})
+	if err != nil {
+		return err, nil
+	}
+	return nil, &after
+}
+
+// AuditFilter narrows down audit entries, zero values do not filter. From and To bound creation time
+// of entries inclusively, zero Limit returns all entries.
+type AuditFilter struct {
+	EntityID string
+	Actor    string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+type DBAuditRepository struct {
+	DB *gorm.DB
+}
+
+// ListBy returns audit entries matching the filter, the latest first, and the number of all matching entries.
+func (d *DBAuditRepository) ListBy(ctx context.Context, filter AuditFilter) (error, []database.AuditEntry, int) {
+	query := d.DB.WithContext(ctx).Model(&database.AuditEntry{})
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return err, nil, 0
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var entries []database.AuditEntry
+	result := query.Order("created_at desc").Offset(filter.Offset).Find(&entries)
+	return result.Error, entries, int(total)
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuditActions(entries []database.AuditEntry) []database.AuditAction {
+	var actions []database.AuditAction
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func TestDBAuditRepository(t *testing.T) {
+	ctx := WithActor(context.Background(), "alice")
+	auditRepository := DBAuditRepository{db}
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	repairRepository := DBRepairRepository{db}
+
+	clearRecords(t, db)
+
+	t.Run("test record customer changes with actor and diff", func(t *testing.T) {
+		err, customer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		telephoneNumberE164 := "+48987654321"
+		err, _ = customerRepository.Update(ctx, &database.Customer{
+			ID:                  customer.ID,
+			FirstName:           customer.FirstName,
+			LastName:            customer.LastName,
+			TelephoneNumber:     "987654321",
+			TelephoneNumberE164: &telephoneNumberE164,
+			Version:             customer.Version,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, customer.ID, customer.Version+1))
+
+		err, entries, total := auditRepository.ListBy(ctx, AuditFilter{EntityID: customer.ID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.ElementsMatch(
+			t,
+			[]database.AuditAction{database.AuditCreate, database.AuditUpdate, database.AuditDelete},
+			getAuditActions(entries),
+		)
+		assert.True(t, sort.SliceIsSorted(entries, func(i, j int) bool {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}), "latest entries should be first")
+		for _, entry := range entries {
+			assert.Equal(t, "alice", entry.Actor)
+			assert.Equal(t, database.AuditCustomer, entry.EntityType)
+			switch entry.Action {
+			case database.AuditCreate:
+				assert.Equal(t, database.FieldChange{New: "John"}, entry.Changes["first_name"])
+			case database.AuditUpdate:
+				assert.Equal(t, database.AuditChanges{
+					"telephone_number":      {Old: "123456789", New: "987654321"},
+					"telephone_number_e164": {Old: "+48123456789", New: "+48987654321"},
+				}, entry.Changes)
+			case database.AuditDelete:
+				assert.Equal(t, database.FieldChange{Old: "987654321"}, entry.Changes["telephone_number"])
+			}
+		}
+		clearRecords(t, db)
+	})
+
+	t.Run("test record deletion and restore of customer purchases and repairs", func(t *testing.T) {
+		err, customer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, purchase := purchaseRepository.Create(ctx, &database.Customer{ID: customer.ID}, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		err, repair := repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+		err, repair = repairRepository.UpdateStatus(ctx, repair.ID, database.RepairInProgress, repair.Version)
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, customer.ID, customer.Version))
+		err, _ = customerRepository.Restore(ctx, customer.ID)
+		assert.NoError(t, err)
+
+		err, purchaseEntries, _ := auditRepository.ListBy(ctx, AuditFilter{EntityID: purchase.ID})
+		assert.NoError(t, err)
+		assert.ElementsMatch(
+			t,
+			[]database.AuditAction{database.AuditCreate, database.AuditDelete, database.AuditRestore},
+			getAuditActions(purchaseEntries),
+		)
+		err, repairEntries, _ := auditRepository.ListBy(ctx, AuditFilter{EntityID: repair.ID})
+		assert.NoError(t, err)
+		assert.ElementsMatch(
+			t,
+			[]database.AuditAction{
+				database.AuditCreate,
+				database.AuditUpdate,
+				database.AuditDelete,
+				database.AuditRestore,
+			},
+			getAuditActions(repairEntries),
+		)
+		for _, entry := range repairEntries {
+			if entry.Action == database.AuditUpdate {
+				assert.Equal(t, database.FieldChange{Old: "reported", New: "in_progress"}, entry.Changes["status"])
+			}
+			if entry.Action == database.AuditRestore {
+				assert.Contains(t, entry.Changes, "deleted_at")
+				assert.Nil(t, entry.Changes["deleted_at"].New)
+			}
+		}
+		clearRecords(t, db)
+	})
+
+	t.Run("test filter entries by actor and time range", func(t *testing.T) {
+		err, customer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		bobCtx := WithActor(context.Background(), "bob")
+		assert.NoError(t, customerRepository.DeleteByID(bobCtx, customer.ID, customer.Version))
+
+		err, entries, total := auditRepository.ListBy(ctx, AuditFilter{Actor: "bob"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []database.AuditAction{database.AuditDelete}, getAuditActions(entries))
+
+		err, entries, total = auditRepository.ListBy(ctx, AuditFilter{From: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, entries)
+
+		err, entries, total = auditRepository.ListBy(ctx, AuditFilter{
+			From:  time.Now().Add(-time.Hour),
+			To:    time.Now().Add(time.Hour),
+			Limit: 1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Len(t, entries, 1)
+		clearRecords(t, db)
+	})
+
+	t.Run("test record changes without actor as system", func(t *testing.T) {
+		err, customer := customerRepository.Create(context.Background(), getCustomerFixture(t))
+		assert.NoError(t, err)
+
+		err, entries, _ := auditRepository.ListBy(ctx, AuditFilter{EntityID: customer.ID})
+
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, SystemActor, entries[0].Actor)
+		clearRecords(t, db)
+	})
+
+	t.Run("test no entries are recorded when unit of work is rolled back", func(t *testing.T) {
+		err := (&DBUnitOfWork{db}).Do(ctx, func(repositories *Repositories) error {
+			err, _ := repositories.Customers.Create(ctx, getCustomerFixture(t))
+			if err != nil {
+				return err
+			}
+			return errors.New("something went wrong")
+		})
+
+		assert.Error(t, err)
+		err, entries, _ := auditRepository.ListBy(ctx, AuditFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ProductNotFoundError struct {
+	Kind string
+	ID   string
+}
+
+func (p *ProductNotFoundError) Error() string {
+	return fmt.Sprintf("%s with ID '%s' does not exist", p.Kind, p.ID)
+}
+
+// DuplicatedProductCodeError is returned when the SKU or the barcode of a product is already taken by another
+// product of the same kind.
+type DuplicatedProductCodeError struct {
+	Kind string
+	SKU  string
+}
+
+func (d *DuplicatedProductCodeError) Error() string {
+	return fmt.Sprintf("%s with SKU '%s' cannot be saved as its SKU or barcode is already taken", d.Kind, d.SKU)
+}
+
+// OutOfStockError is returned when more items of a product are taken than there are in stock.
+type OutOfStockError struct {
+	Kind  string
+	ID    string
+	SKU   string
+	Stock int
+}
+
+func (o *OutOfStockError) Error() string {
+	if o.Stock <= 0 {
+		return fmt.Sprintf("%s with SKU '%s' is out of stock", o.Kind, o.SKU)
+	}
+	return fmt.Sprintf("%s with SKU '%s' has only %d in stock", o.Kind, o.SKU, o.Stock)
+}
+
+// ProductFilter narrows the catalog down to products having all words of Q in their SKU or details,
+// and to the product of the given SKU or barcode, e.g. scanned at the counter.
+type ProductFilter struct {
+	Q    string
+	Code string
+}
+
+// catalogKind describes products of one kind of the catalog.
+type catalogKind struct {
+	name   string
+	entity database.AuditEntity
+	// purchaseColumn references products of the kind from purchases
+	purchaseColumn string
+	// searched are columns searched for words of the query besides the SKU
+	searched []string
+	// fields can be changed by Update, while the stock is only adjusted by AdjustStock
+	fields []string
+}
+
+func kindOf[T CatalogProduct]() catalogKind {
+	var product T
+	switch any(product).(type) {
+	case database.Frame:
+		return catalogKind{
+			name:           "frame",
+			entity:         database.AuditFrame,
+			purchaseColumn: "frame_id",
+			searched:       []string{"brand", "model", "color"},
+			fields:         []string{"SKU", "Barcode", "Supplier", "ReorderThreshold", "Brand", "Model", "Color", "Size"},
+		}
+	default:
+		return catalogKind{
+			name:           "lens type",
+			entity:         database.AuditLensType,
+			purchaseColumn: "lens_type_id",
+			searched:       []string{"name", "material", "coating"},
+			fields:         []string{"SKU", "Barcode", "Supplier", "ReorderThreshold", "Name", "Material", "Coating"},
+		}
+	}
+}
+
+func productOf[T CatalogProduct](product *T) *database.Product {
+	return any(product).(interface{ GetProduct() *database.Product }).GetProduct()
+}
+
+// DBCatalogRepository stores products of one kind of the catalog, either frames or lens types.
+type DBCatalogRepository[T CatalogProduct] struct {
+	DB *gorm.DB
+}
+
+func (d *DBCatalogRepository[T]) Create(ctx context.Context, product *T) (error, *T) {
+	kind := kindOf[T]()
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, kind.entity, productOf(product).ID, nil, product)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &DuplicatedProductCodeError{Kind: kind.name, SKU: productOf(product).SKU}, nil
+	}
+	if err != nil {
+		return err, nil
+	}
+	return nil, product
+}
+
+// List returns products matching the filter ordered by their SKU.
+func (d *DBCatalogRepository[T]) List(ctx context.Context, filter ProductFilter) (error, []T) {
+	kind := kindOf[T]()
+	query := d.DB.WithContext(ctx)
+	for _, word := range strings.Fields(strings.ToLower(filter.Q)) {
+		condition := d.DB.Where("LOWER(sku) LIKE ?", contains(word))
+		for _, column := range kind.searched {
+			condition = condition.Or(fmt.Sprintf("LOWER(%s) LIKE ?", column), contains(word))
+		}
+		query = query.Where(condition)
+	}
+	if filter.Code != "" {
+		query = query.Where("sku = ? OR barcode = ?", filter.Code, filter.Code)
+	}
+	products := []T{}
+	result := query.Order("sku asc").Find(&products)
+	return result.Error, products
+}
+
+func (d *DBCatalogRepository[T]) GetByID(ctx context.Context, productID string) (error, *T) {
+	var product T
+	result := d.DB.WithContext(ctx).Where("id = ?", productID).First(&product)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &ProductNotFoundError{Kind: kindOf[T]().name, ID: productID}, nil
+	}
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &product
+}
+
+// Update changes details of the product, but not its stock.
+func (d *DBCatalogRepository[T]) Update(ctx context.Context, product *T) (error, *T) {
+	kind := kindOf[T]()
+	details := productOf(product)
+	err, updated := updateWithAudit(ctx, d.DB, kind.entity, details.ID, func(tx *gorm.DB, _ *T) error {
+		return tx.Model(product).Select(kind.fields).Updates(product).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &ProductNotFoundError{Kind: kind.name, ID: details.ID}, nil
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &DuplicatedProductCodeError{Kind: kind.name, SKU: details.SKU}, nil
+	}
+	return err, updated
+}
+
+// AdjustStock adds the quantity to the stock of the product, negative quantities take items from the stock.
+// Unless allowNegative is set, OutOfStockError is returned when there are fewer items in stock than taken.
+// The stock is changed by a single conditional update, so concurrent changes cannot oversell the product.
+func (d *DBCatalogRepository[T]) AdjustStock(
+	ctx context.Context,
+	productID string,
+	quantity int,
+	allowNegative bool,
+) (error, *T) {
+	kind := kindOf[T]()
+	err, adjusted := updateWithAudit(ctx, d.DB, kind.entity, productID, func(tx *gorm.DB, current *T) error {
+		query := tx.Model(new(T)).Where("id = ?", productID)
+		if !allowNegative {
+			query = query.Where("stock + ? >= 0", quantity)
+		}
+		result := query.Update("stock", gorm.Expr("stock + ?", quantity))
+		if result.Error == nil && result.RowsAffected == 0 {
+			product := productOf(current)
+			return &OutOfStockError{Kind: kind.name, ID: productID, SKU: product.SKU, Stock: product.Stock}
+		}
+		return result.Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &ProductNotFoundError{Kind: kind.name, ID: productID}, nil
+	}
+	return err, adjusted
+}
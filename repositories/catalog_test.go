@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getFrameFixture(sku string, stock int) *database.Frame {
+	return &database.Frame{
+		Product: database.Product{SKU: sku, Stock: stock},
+		Brand:   "Ray-Ban",
+		Model:   "RB5154",
+		Color:   "Black",
+		Size:    "51-21-145",
+	}
+}
+
+func TestDBCatalogRepository(t *testing.T) {
+	ctx := context.Background()
+	frameRepository := DBCatalogRepository[database.Frame]{db}
+	lensTypeRepository := DBCatalogRepository[database.LensType]{db}
+
+	t.Run("test create and list products", func(t *testing.T) {
+		clearRecords(t, db)
+		barcode := "5901234123457"
+		frame := getFrameFixture("RB5154-BLK-51", 3)
+		frame.Barcode = &barcode
+		err, frame := frameRepository.Create(ctx, frame)
+		assert.NoError(t, err)
+		other := getFrameFixture("OA-1001", 1)
+		other.Brand, other.Model, other.Color = "Oakley", "Holbrook", "Matte Grey"
+		err, _ = frameRepository.Create(ctx, other)
+		assert.NoError(t, err)
+		err, _ = lensTypeRepository.Create(ctx, &database.LensType{
+			Product:  database.Product{SKU: "SV-PC-AR"},
+			Name:     "Single vision",
+			Material: "Polycarbonate",
+			Coating:  "Anti-reflective",
+		})
+		assert.NoError(t, err)
+
+		err, frames := frameRepository.List(ctx, ProductFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"OA-1001", "RB5154-BLK-51"}, []string{frames[0].SKU, frames[1].SKU})
+		err, frames = frameRepository.List(ctx, ProductFilter{Q: "ray black"})
+		assert.NoError(t, err)
+		assert.Len(t, frames, 1)
+		assert.Equal(t, "Ray-Ban RB5154 Black 51-21-145", frames[0].Description())
+		err, frames = frameRepository.List(ctx, ProductFilter{Code: barcode})
+		assert.NoError(t, err)
+		assert.Len(t, frames, 1)
+		assert.Equal(t, frame.ID, frames[0].ID)
+		err, lensTypes := lensTypeRepository.List(ctx, ProductFilter{Q: "polycarbonate"})
+		assert.NoError(t, err)
+		assert.Len(t, lensTypes, 1)
+		assert.Equal(t, "Single vision Polycarbonate Anti-reflective", lensTypes[0].Description())
+		err, entries, _ := (&DBAuditRepository{db}).ListBy(ctx, AuditFilter{EntityID: frame.ID})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, database.AuditFrame, entries[0].EntityType)
+	})
+
+	t.Run("test duplicated SKU", func(t *testing.T) {
+		clearRecords(t, db)
+		err, _ := frameRepository.Create(ctx, getFrameFixture("RB5154-BLK-51", 0))
+		assert.NoError(t, err)
+
+		err, _ = frameRepository.Create(ctx, getFrameFixture("RB5154-BLK-51", 0))
+
+		assert.EqualError(t, err, "frame with SKU 'RB5154-BLK-51' cannot be saved as its SKU or barcode is already taken")
+		assert.IsType(t, &DuplicatedProductCodeError{}, err)
+	})
+
+	t.Run("test update keeps the stock", func(t *testing.T) {
+		clearRecords(t, db)
+		err, frame := frameRepository.Create(ctx, getFrameFixture("RB5154-BLK-51", 3))
+		assert.NoError(t, err)
+		frame.Color = "Havana"
+		frame.Stock = 100
+
+		err, updated := frameRepository.Update(ctx, frame)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Havana", updated.Color)
+		assert.Equal(t, 3, updated.Stock)
+
+		err, _ = frameRepository.Update(ctx, &database.Frame{Product: database.Product{ID: "missing"}})
+
+		assert.IsType(t, &ProductNotFoundError{}, err)
+	})
+
+	t.Run("test adjust stock", func(t *testing.T) {
+		clearRecords(t, db)
+		err, frame := frameRepository.Create(ctx, getFrameFixture("RB5154-BLK-51", 1))
+		assert.NoError(t, err)
+
+		err, adjusted := frameRepository.AdjustStock(ctx, frame.ID, -1, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, adjusted.Stock)
+
+		err, _ = frameRepository.AdjustStock(ctx, frame.ID, -1, false)
+
+		assert.EqualError(t, err, "frame with SKU 'RB5154-BLK-51' is out of stock")
+		assert.IsType(t, &OutOfStockError{}, err)
+
+		err, adjusted = frameRepository.AdjustStock(ctx, frame.ID, -1, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, -1, adjusted.Stock)
+
+		err, adjusted = frameRepository.AdjustStock(ctx, frame.ID, 5, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, adjusted.Stock)
+
+		err, _ = frameRepository.AdjustStock(ctx, frame.ID, -5, false)
+
+		assert.EqualError(t, err, "frame with SKU 'RB5154-BLK-51' has only 4 in stock")
+
+		err, _ = lensTypeRepository.AdjustStock(ctx, frame.ID, 1, false)
+
+		assert.EqualError(t, err, "lens type with ID '"+frame.ID+"' does not exist")
+	})
+
+	clearRecords(t, db)
+}
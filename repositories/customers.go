@@ -1,13 +1,16 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type DBCustomerRepository struct {
@@ -35,63 +38,404 @@ func (d *DuplicatedTelephoneNumberError) Error() string {
 	)
 }
 
-func (d *DBCustomerRepository) Create(customer *database.Customer) (error, *database.Customer) {
-	err := d.DB.Create(&customer).Error
+func (d *DBCustomerRepository) Create(ctx context.Context, customer *database.Customer) (error, *database.Customer) {
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&customer).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, database.AuditCustomer, customer.ID, nil, customer)
+	})
 	if errors.Is(err, gorm.ErrDuplicatedKey) {
 		return &DuplicatedTelephoneNumberError{customer}, nil
 	}
 	return err, customer
 }
 
-func (d *DBCustomerRepository) DeleteByID(customerID string) error {
-	result := d.DB.Select(clause.Associations).Delete(&database.Customer{ID: customerID})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return &CustomerNotFoundError{CustomerID: customerID}
+// DeleteByID soft deletes the customer together with their purchases and repairs, all of them get
+// the same deletion time so Restore can bring back exactly the records deleted with the customer.
+// Prescriptions are kept until the customer is purged. The customer is deleted only if it still has
+// the given version.
+func (d *DBCustomerRepository) DeleteByID(ctx context.Context, customerID string, version int) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var customer database.Customer
+		result := tx.Where("id = ?", customerID).First(&customer)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &CustomerNotFoundError{CustomerID: customerID}
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if customer.Version != version {
+			return &VersionConflictError{ID: customerID, Version: version}
+		}
+		var purchases []database.Purchase
+		if err := tx.Where("customer_id = ?", customerID).Find(&purchases).Error; err != nil {
+			return err
+		}
+		var repairs []database.Repair
+		if err := tx.Where("customer_id = ?", customerID).Find(&repairs).Error; err != nil {
+			return err
+		}
+
+		err := recordAudit(ctx, tx, database.AuditDelete, database.AuditCustomer, customerID, &customer, nil)
+		if err != nil {
+			return err
+		}
+		for _, purchase := range purchases {
+			err := recordAudit(ctx, tx, database.AuditDelete, database.AuditPurchase, purchase.ID, &purchase, nil)
+			if err != nil {
+				return err
+			}
+		}
+		for _, repair := range repairs {
+			err := recordAudit(ctx, tx, database.AuditDelete, database.AuditRepair, repair.ID, &repair, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		deletedAt := time.Now().UTC()
+		result = tx.Model(&customer).Where("version = ?", version).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &VersionConflictError{ID: customerID, Version: version}
+		}
+		for _, model := range []any{&database.Purchase{}, &database.Repair{}} {
+			err := tx.Model(model).Where("customer_id = ?", customerID).Update("deleted_at", deletedAt).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Restore brings back soft deleted customer along with purchases and repairs deleted together with them,
+// incrementing versions of all restored records.
+func (d *DBCustomerRepository) Restore(ctx context.Context, customerID string) (error, *database.Customer) {
+	var customer database.Customer
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ?", customerID).First(&customer)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &CustomerNotFoundError{CustomerID: customerID}
+		}
+		if result.Error != nil || !customer.DeletedAt.Valid {
+			return result.Error
+		}
+		deletedWithCustomer := tx.Unscoped().
+			Where("customer_id = ? AND deleted_at = ?", customerID, customer.DeletedAt.Time)
+		var purchases []database.Purchase
+		if err := deletedWithCustomer.Session(&gorm.Session{}).Find(&purchases).Error; err != nil {
+			return err
+		}
+		var repairs []database.Repair
+		if err := deletedWithCustomer.Session(&gorm.Session{}).Find(&repairs).Error; err != nil {
+			return err
+		}
+
+		for _, purchase := range purchases {
+			restored := purchase
+			restored.DeletedAt = gorm.DeletedAt{}
+			restored.Version++
+			err := recordAudit(ctx, tx, database.AuditRestore, database.AuditPurchase, purchase.ID, &purchase, &restored)
+			if err != nil {
+				return err
+			}
+		}
+		for _, repair := range repairs {
+			restored := repair
+			restored.DeletedAt = gorm.DeletedAt{}
+			restored.Version++
+			err := recordAudit(ctx, tx, database.AuditRestore, database.AuditRepair, repair.ID, &repair, &restored)
+			if err != nil {
+				return err
+			}
+		}
+		deleted := customer
+		customer.DeletedAt = gorm.DeletedAt{}
+		customer.Version++
+		err := recordAudit(ctx, tx, database.AuditRestore, database.AuditCustomer, customerID, &deleted, &customer)
+		if err != nil {
+			return err
+		}
+
+		for _, model := range []any{&database.Purchase{}, &database.Repair{}} {
+			err := tx.Unscoped().Model(model).
+				Where("customer_id = ? AND deleted_at = ?", customerID, deleted.DeletedAt.Time).
+				Updates(restoredColumns).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Model(&database.Customer{ID: customerID}).Updates(restoredColumns).Error
+	})
+	if err != nil {
+		return err, nil
 	}
-	return nil
+	return nil, &customer
+}
+
+// CustomerSortFields are fields customers can be sorted by.
+var CustomerSortFields = []string{"first_name", "last_name", "email", "created_at", "updated_at"}
+
+// CustomerFilter narrows down customers, zero values do not filter. Q holds words each of which has to be
+// found in the name, email or telephone number of the customer, other text fields have to be contained
+// in the respective customer details. CreatedFrom bounds creation time inclusively and CreatedBefore
+// exclusively. HasOpenRepairs keeps customers with or without repairs in the workshop queue, while
+// PurchasedSince keeps customers who purchased anything since the given day. Customers are sorted
+// by first name unless Sort is given. The listed page follows Cursor when it is given, otherwise
+// Offset customers are skipped, zero Limit returns all customers.
+type CustomerFilter struct {
+	Q               string
+	FirstName       string
+	LastName        string
+	TelephoneNumber string
+	Email           string
+	CreatedFrom     time.Time
+	CreatedBefore   time.Time
+	HasOpenRepairs  *bool
+	PurchasedSince  time.Time
+	Sort            []SortField
+	Cursor          *Cursor
+	Limit           int
+	Offset          int
 }
 
+// ListBy returns the page of customers matching the filter and the number of all matching customers.
+// Telephone numbers are matched by digits of their normalized form, so they can be searched for in any format.
 func (d *DBCustomerRepository) ListBy(
-	customerFirstName string,
-	customerLastName string,
-	limit int,
-	offset int,
-) (error, []database.Customer, int) {
+	ctx context.Context,
+	filter CustomerFilter,
+) (error, Page[database.Customer], int) {
+	query := withContext(ctx, d.DB).Model(&database.Customer{})
+	for _, word := range strings.Fields(strings.ToLower(filter.Q)) {
+		condition := d.DB.Where("LOWER(first_name) LIKE ?", contains(word)).
+			Or("LOWER(last_name) LIKE ?", contains(word)).
+			Or("LOWER(email) LIKE ?", contains(word))
+		if digits := telephoneDigits(word); digits != "" {
+			condition = condition.Or("telephone_number_e164 LIKE ?", contains(digits))
+		}
+		query = query.Where(condition)
+	}
+	if filter.FirstName != "" {
+		query = query.Where("LOWER(first_name) LIKE ?", contains(strings.ToLower(filter.FirstName)))
+	}
+	if filter.LastName != "" {
+		query = query.Where("LOWER(last_name) LIKE ?", contains(strings.ToLower(filter.LastName)))
+	}
+	if digits := telephoneDigits(filter.TelephoneNumber); digits != "" {
+		query = query.Where("telephone_number_e164 LIKE ?", contains(digits))
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) LIKE ?", contains(strings.ToLower(filter.Email)))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.HasOpenRepairs != nil {
+		openRepairs := d.DB.Model(&database.Repair{}).Select("1").
+			Where("repairs.customer_id = customers.id AND repairs.status IN ?", database.OpenRepairStatuses)
+		if *filter.HasOpenRepairs {
+			query = query.Where("EXISTS (?)", openRepairs)
+		} else {
+			query = query.Where("NOT EXISTS (?)", openRepairs)
+		}
+	}
+	if !filter.PurchasedSince.IsZero() {
+		purchases := d.DB.Model(&database.Purchase{}).Select("1").
+			Where("purchases.customer_id = customers.id AND purchases.purchased_at >= ?", filter.PurchasedSince)
+		query = query.Where("EXISTS (?)", purchases)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return err, Page[database.Customer]{}, 0
+	}
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = []SortField{{Field: "first_name"}}
+	}
+	for _, field := range sort {
+		if !slices.Contains(CustomerSortFields, field.Field) {
+			return fmt.Errorf("customers cannot be sorted by '%s'", field.Field), Page[database.Customer]{}, 0
+		}
+	}
+	err, page := paginate[database.Customer](ctx, query, sort, filter.Cursor, filter.Offset, filter.Limit)
+	return err, page, int(total)
+}
+
+// CustomerMatch is a customer found by Search along with the score ranking the match
+// and spans of customer fields which matched the search.
+type CustomerMatch struct {
+	Customer database.Customer `json:"customer"`
+	Score    MatchScore        `json:"score"`
+	Spans    []MatchSpan       `json:"highlights"`
+}
+
+const searchBatchSize = 500
+
+// Search finds customers whose names resemble words of the query despite typos or missing diacritics,
+// e.g. "Wisniewsky" finds "Wiśniewski", while words of digits are found in telephone numbers.
+// Customers are narrowed down to candidates by their search text in SQL first, then candidates are read
+// in batches and matched in Go, so matching behaves the same on every database. At most limit best
+// matching customers are returned, best matches first.
+func (d *DBCustomerRepository) Search(ctx context.Context, query string, limit int) (error, []CustomerMatch) {
+	searched := strings.Fields(query)
+	if len(searched) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	var matches []CustomerMatch
+	var batch []candidate
+	result := d.searchCandidates(withContext(ctx, d.DB).Model(&database.Customer{}), searched).
+		Select("id", "first_name", "last_name", "telephone_number", "telephone_number_e164").
+		FindInBatches(&batch, searchBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, customer := range batch {
+				score, spans := matchCandidate(searched, customer)
+				if score > 0 {
+					matches = append(matches, CustomerMatch{
+						Customer: database.Customer{
+							ID:        customer.ID,
+							FirstName: customer.FirstName,
+							LastName:  customer.LastName,
+						},
+						Score: score,
+						Spans: spans,
+					})
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Customer.LastName != matches[j].Customer.LastName {
+			return matches[i].Customer.LastName < matches[j].Customer.LastName
+		}
+		return matches[i].Customer.FirstName < matches[j].Customer.FirstName
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	ids := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.Customer.ID
+	}
 	var customers []database.Customer
-	var result *gorm.DB
-	firstName := strings.ToLower(customerFirstName)
-	lastName := strings.ToLower(customerLastName)
-	if firstName == "" && lastName == "" {
-		result = d.DB.Offset(offset).Limit(limit).Order("first_name asc").Find(&customers)
-	} else {
-		firstNameQuery := fmt.Sprintf("%%%s%%", firstName)
-		lastNameQuery := fmt.Sprintf("%%%s%%", lastName)
+	if err := withContext(ctx, d.DB).Where("id IN ?", ids).Find(&customers).Error; err != nil {
+		return err, nil
+	}
+	for i := range matches {
+		for _, customer := range customers {
+			if customer.ID == matches[i].Customer.ID {
+				matches[i].Customer = customer
+			}
+		}
+	}
+	return nil, matches
+}
 
-		result = d.DB.Where("LOWER(first_name) LIKE ? AND LOWER(last_name) LIKE ?",
-			firstNameQuery, lastNameQuery).Offset(offset).Limit(limit).Order("first_name asc").Find(&customers)
+// searchCandidates narrows customers down to those whose search text may match every searched word.
+// It never leaves out a customer who matches, so it may let through customers who do not.
+func (d *DBCustomerRepository) searchCandidates(query *gorm.DB, searched []string) *gorm.DB {
+	for _, word := range searched {
+		if digits := telephoneDigits(word); len(digits) >= minTelephoneDigits && digits == strings.TrimPrefix(word, "+") {
+			query = query.Where("search_text LIKE ? OR telephone_number_e164 LIKE ?", contains(digits), contains(digits))
+			continue
+		}
+		pieces := searchPieces(database.Fold(word))
+		if len(pieces) == 0 {
+			continue
+		}
+		condition := d.DB.Where("search_text LIKE ?", contains(pieces[0]))
+		for _, piece := range pieces[1:] {
+			condition = condition.Or("search_text LIKE ?", contains(piece))
+		}
+		query = query.Where(condition)
 	}
+	return query
+}
 
-	var total int64
-	d.DB.Model(&database.Customer{}).Count(&total)
-	return result.Error, customers, int(total)
+func contains(part string) string {
+	return fmt.Sprintf("%%%s%%", part)
+}
+
+func telephoneDigits(telephoneNumber string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, telephoneNumber)
 }
 
-func (d *DBCustomerRepository) GetByID(customerID string) (error, *database.Customer) {
+func (d *DBCustomerRepository) GetByID(ctx context.Context, customerID string) (error, *database.Customer) {
 	var customer database.Customer
-	result := d.DB.Where("id = ?", customerID).First(&customer)
+	result := withContext(ctx, d.DB).Where("id = ?", customerID).First(&customer)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return &CustomerNotFoundError{CustomerID: customerID}, nil
 	}
-	return result.Error, &customer
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &customer
 }
 
-func (d *DBCustomerRepository) Update(customer *database.Customer) (error, *database.Customer) {
-	result := d.DB.Model(customer).Select("FirstName", "LastName", "TelephoneNumber").Updates(customer)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+var customerFields = updatableFields{
+	"FirstName":        {"FirstName"},
+	"LastName":         {"LastName"},
+	"TelephoneNumber":  {"TelephoneNumber", "TelephoneNumberE164"},
+	"Email":            {"Email"},
+	"Address":          {"address_street", "address_city", "address_postal_code", "address_country"},
+	"BirthDate":        {"BirthDate"},
+	"PreferredContact": {"PreferredContact"},
+	"Notes":            {"Notes"},
+}
+
+// updateSearchText recomputes the search text of the customer from their names and telephone number.
+func updateSearchText(tx *gorm.DB, customerID string) error {
+	var customer database.Customer
+	err := tx.Select("first_name", "last_name", "telephone_number").Where("id = ?", customerID).Take(&customer).Error
+	if err != nil {
+		return err
+	}
+	searchText := database.CustomerSearchText(customer.FirstName, customer.LastName, customer.TelephoneNumber)
+	return tx.Model(&database.Customer{}).Where("id = ?", customerID).UpdateColumn("search_text", searchText).Error
+}
+
+// Update changes customer details if the customer still has the version set in given customer.
+// When fields are given, only they are changed, otherwise all customer details are.
+func (d *DBCustomerRepository) Update(
+	ctx context.Context,
+	customer *database.Customer,
+	fields ...string,
+) (error, *database.Customer) {
+	err, columns := customerFields.columns(fields)
+	if err != nil {
+		return err, nil
+	}
+	err, updated := updateWithAudit(ctx, d.DB, database.AuditCustomer, customer.ID,
+		func(tx *gorm.DB, _ *database.Customer) error {
+			if err := updateVersion(tx, customer.ID, customer, &customer.Version, columns...); err != nil {
+				return err
+			}
+			return updateSearchText(tx, customer.ID)
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return &CustomerNotFoundError{CustomerID: customer.ID}, nil
 	}
-	return result.Error, customer
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &DuplicatedTelephoneNumberError{customer}, nil
+	}
+	return err, updated
 }
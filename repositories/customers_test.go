@@ -1,8 +1,8 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
-	"fmt"
 	"testing"
 	"time"
 
@@ -11,24 +11,26 @@ import (
 )
 
 func TestDBCustomerRepository(t *testing.T) {
+	ctx := context.Background()
 	customerRepository := DBCustomerRepository{db}
 	repairRepository := DBRepairRepository{db}
 	purchaseRepository := DBPurchaseRepository{db}
 
-	customer := &database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"}
+	customer := getCustomerFixture(t)
 	purchase := &database.Purchase{
 		FrameModel: "Model1", LensType: "LensType1",
-		LensPower: "LensPower", PD: "CustomPD", PurchasedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		LensPower: getLensPowerFixture(t), PD: database.PupillaryDistance{Binocular: 62},
+		PurchasedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 	repair := &database.Repair{
 		Description: "some issue with the thing",
-		Cost:        12.32,
+		Cost:        database.Money{Amount: 1232, Currency: "PLN"},
 		ReportedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	clearRecords(t, db)
 	t.Run("test create customer", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(customer)
+		err, dbCustomer := customerRepository.Create(ctx, customer)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "John", dbCustomer.FirstName)
@@ -38,11 +40,10 @@ func TestDBCustomerRepository(t *testing.T) {
 	})
 
 	t.Run("test cannot create customer with the same name and telephone number", func(t *testing.T) {
-		err, _ := customerRepository.Create(customer)
+		err, _ := customerRepository.Create(ctx, customer)
 		assert.NoError(t, err)
 
-		err, _ = customerRepository.Create(customer)
-		fmt.Println("DUPA", err)
+		err, _ = customerRepository.Create(ctx, customer)
 
 		dbCustomers := getAllCustomers(t, db)
 
@@ -53,14 +54,14 @@ func TestDBCustomerRepository(t *testing.T) {
 	})
 
 	t.Run("test delete customer", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(customer)
+		err, dbCustomer := customerRepository.Create(ctx, customer)
 		assert.NoError(t, err)
-		err, _ = purchaseRepository.Create(customer, purchase)
+		err, _ = purchaseRepository.Create(ctx, customer, purchase)
 		assert.NoError(t, err)
-		err, _ = repairRepository.Create(customer, repair)
+		err, _ = repairRepository.Create(ctx, customer, repair)
 		assert.NoError(t, err)
 
-		err = customerRepository.DeleteByID(dbCustomer.ID)
+		err = customerRepository.DeleteByID(ctx, dbCustomer.ID, dbCustomer.Version)
 		assert.NoError(t, err)
 
 		assert.Equal(t, 0, len(getAllCustomers(t, db)))
@@ -70,9 +71,74 @@ func TestDBCustomerRepository(t *testing.T) {
 		clearRecords(t, db)
 	})
 
+	t.Run("test restore deleted customer with their purchases and repairs", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbPurchase := purchaseRepository.Create(ctx, &database.Customer{ID: dbCustomer.ID}, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		err, removedPurchase := purchaseRepository.Create(
+			ctx,
+			&database.Customer{ID: dbCustomer.ID},
+			getPurchaseFixture(t),
+		)
+		assert.NoError(t, err)
+		err, _ = repairRepository.Create(ctx, &database.Customer{ID: dbCustomer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, purchaseRepository.DeleteByID(ctx, removedPurchase.ID, removedPurchase.Version))
+		assert.NoError(t, customerRepository.DeleteByID(ctx, dbCustomer.ID, dbCustomer.Version))
+
+		err, restoredCustomer := customerRepository.Restore(ctx, dbCustomer.ID)
+
+		assert.NoError(t, err)
+		assert.False(t, restoredCustomer.DeletedAt.Valid)
+		assert.Equal(t, dbCustomer.Version+1, restoredCustomer.Version)
+		dbCustomers := getAllCustomers(t, db)
+		assert.Len(t, dbCustomers, 1)
+		assert.Equal(t, dbCustomer.Version+1, dbCustomers[0].Version)
+		dbRepairs := getAllRepairs(t, db)
+		assert.Len(t, dbRepairs, 1)
+		assert.Equal(t, 2, dbRepairs[0].Version)
+		dbPurchases := getAllPurchases(t, db)
+		assert.Len(t, dbPurchases, 1, "purchase removed before the customer should stay deleted")
+		assert.Equal(t, dbPurchase.ID, dbPurchases[0].ID)
+		assert.Equal(t, dbPurchase.Version+1, dbPurchases[0].Version)
+		clearRecords(t, db)
+	})
+
+	t.Run("test restore not existing customer", func(t *testing.T) {
+		invalidID := uuid.NewString()
+
+		err, restoredCustomer := customerRepository.Restore(ctx, invalidID)
+
+		assert.Equal(t, err, &CustomerNotFoundError{CustomerID: invalidID})
+		assert.Nil(t, restoredCustomer)
+	})
+
+	t.Run("test deleted customers are listed only when including deleted", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, dbCustomer.ID, dbCustomer.Version))
+
+		err, page, total := customerRepository.ListBy(ctx, CustomerFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+		assert.Equal(t, 0, total)
+		err, _ = customerRepository.GetByID(ctx, dbCustomer.ID)
+		assert.Equal(t, err, &CustomerNotFoundError{CustomerID: dbCustomer.ID})
+
+		err, page, total = customerRepository.ListBy(IncludeDeleted(ctx), CustomerFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, 1, total)
+		err, deletedCustomer := customerRepository.GetByID(IncludeDeleted(ctx), dbCustomer.ID)
+		assert.NoError(t, err)
+		assert.True(t, deletedCustomer.DeletedAt.Valid)
+		clearRecords(t, db)
+	})
+
 	t.Run("test delete not existing customer", func(t *testing.T) {
 		invalidID := uuid.NewString()
-		err := customerRepository.DeleteByID(invalidID)
+		err := customerRepository.DeleteByID(ctx, invalidID, 1)
 		assert.Equal(t, err, &CustomerNotFoundError{CustomerID: invalidID})
 		assert.Equal(t, 0, len(getAllCustomers(t, db)))
 	})
@@ -86,6 +152,7 @@ func TestDBCustomerRepository(t *testing.T) {
 		}
 		for _, customerData := range customersData {
 			err, customer := customerRepository.Create(
+				ctx,
 				&database.Customer{
 					ID:              "customerID",
 					FirstName:       customerData.FirstName,
@@ -97,13 +164,13 @@ func TestDBCustomerRepository(t *testing.T) {
 			customers = append(customers, *customer)
 		}
 
-		err, dbCustomers, total := customerRepository.ListBy("", "Do", 10, 0)
+		err, page, total := customerRepository.ListBy(ctx, CustomerFilter{LastName: "Do", Limit: 10})
 
 		assert.NoError(t, err)
-		assertCustomer(t, &customers[0], &dbCustomers[0])
-		assertCustomer(t, &customers[1], &dbCustomers[1])
-		assert.Len(t, dbCustomers, 2)
-		assert.Equal(t, 3, total)
+		assertCustomer(t, &customers[0], &page.Items[0])
+		assertCustomer(t, &customers[1], &page.Items[1])
+		assert.Len(t, page.Items, 2)
+		assert.Equal(t, 2, total)
 
 		clearRecords(t, db)
 	})
@@ -116,6 +183,7 @@ func TestDBCustomerRepository(t *testing.T) {
 		}
 		for _, customerData := range customersData {
 			err, customer := customerRepository.Create(
+				ctx,
 				&database.Customer{
 					ID:              "customerID",
 					FirstName:       customerData.FirstName,
@@ -127,35 +195,234 @@ func TestDBCustomerRepository(t *testing.T) {
 			customers = append(customers, *customer)
 		}
 
-		err, dbCustomers, total := customerRepository.ListBy("", "", 1, 2)
+		err, page, total := customerRepository.ListBy(ctx, CustomerFilter{Limit: 1, Offset: 2})
 
 		assert.NoError(t, err)
-		assertCustomer(t, &customers[2], &dbCustomers[0])
-		assert.Len(t, dbCustomers, 1)
+		assertCustomer(t, &customers[2], &page.Items[0])
+		assert.Len(t, page.Items, 1)
 		assert.Equal(t, 3, total)
 
 		clearRecords(t, db)
 	})
 
+	t.Run("test get customers by telephone number in any format", func(t *testing.T) {
+		var customers []database.Customer
+		for _, telephoneNumber := range []string{"+48600100200", "+48123456789"} {
+			telephoneNumberE164 := telephoneNumber
+			err, customer := customerRepository.Create(ctx, &database.Customer{
+				FirstName:           "John",
+				LastName:            "Doe",
+				TelephoneNumber:     telephoneNumber,
+				TelephoneNumberE164: &telephoneNumberE164,
+			})
+			assert.NoError(t, err)
+			customers = append(customers, *customer)
+		}
+
+		for _, telephoneNumber := range []string{"+48600100200", "600-100-200", "100 200"} {
+			err, page, _ := customerRepository.ListBy(
+				ctx,
+				CustomerFilter{TelephoneNumber: telephoneNumber, Limit: 10},
+			)
+
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, 1, telephoneNumber)
+			assertCustomer(t, &customers[0], &page.Items[0])
+		}
+		clearRecords(t, db)
+	})
+
+	t.Run("test get customers by email", func(t *testing.T) {
+		var customers []database.Customer
+		for _, email := range []string{"John.Doe@example.com", "john@example.org"} {
+			customer := getCustomerFixture(t)
+			customer.TelephoneNumberE164 = nil
+			customer.Email = email
+			err, customer := customerRepository.Create(ctx, customer)
+			assert.NoError(t, err)
+			customers = append(customers, *customer)
+		}
+
+		err, page, _ := customerRepository.ListBy(ctx, CustomerFilter{Email: "doe@EXAMPLE", Limit: 10})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assertCustomer(t, &customers[0], &page.Items[0])
+		clearRecords(t, db)
+	})
+
+	createCustomers := func(t *testing.T, customers ...database.Customer) []database.Customer {
+		t.Helper()
+		created := make([]database.Customer, 0, len(customers))
+		for _, customer := range customers {
+			err, dbCustomer := customerRepository.Create(ctx, &customer)
+			assert.NoError(t, err)
+			created = append(created, *dbCustomer)
+		}
+		return created
+	}
+	customerIDs := func(customers []database.Customer) []string {
+		var ids []string
+		for _, customer := range customers {
+			ids = append(ids, customer.ID)
+		}
+		return ids
+	}
+
+	t.Run("test search customers by words found in any of their details", func(t *testing.T) {
+		telephoneNumberE164 := "+48600100200"
+		customers := createCustomers(t,
+			database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "1"},
+			database.Customer{FirstName: "Jane", LastName: "Doe", TelephoneNumber: "2", Email: "jane@example.com"},
+			database.Customer{
+				FirstName:           "John",
+				LastName:            "Smith",
+				TelephoneNumber:     "600 100 200",
+				TelephoneNumberE164: &telephoneNumberE164,
+			},
+		)
+
+		for q, expected := range map[string][]string{
+			"john doe":          {customers[0].ID},
+			"DOE":               {customers[1].ID, customers[0].ID},
+			"example.com":       {customers[1].ID},
+			"john 600-100":      {customers[2].ID},
+			"john doe 600-100":  nil,
+			"   ":               {customers[1].ID, customers[0].ID, customers[2].ID},
+			"jane@example.com ": {customers[1].ID},
+		} {
+			err, page, total := customerRepository.ListBy(
+				ctx,
+				CustomerFilter{Q: q, Sort: []SortField{{Field: "last_name"}, {Field: "first_name"}}},
+			)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, customerIDs(page.Items), q)
+			assert.Equal(t, len(expected), total, q)
+		}
+		clearRecords(t, db)
+	})
+
+	t.Run("test sort customers by multiple fields", func(t *testing.T) {
+		customers := createCustomers(t,
+			database.Customer{
+				FirstName: "Alice", LastName: "Doe", TelephoneNumber: "1",
+				CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			database.Customer{
+				FirstName: "Bob", LastName: "Doe", TelephoneNumber: "2",
+				CreatedAt: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			database.Customer{
+				FirstName: "Xin", LastName: "Smith", TelephoneNumber: "3",
+				CreatedAt: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		)
+
+		err, page, _ := customerRepository.ListBy(ctx, CustomerFilter{
+			Sort: []SortField{{Field: "last_name", Descending: true}, {Field: "created_at", Descending: true}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{customers[2].ID, customers[1].ID, customers[0].ID}, customerIDs(page.Items))
+		clearRecords(t, db)
+	})
+
+	t.Run("test sort customers by unknown field", func(t *testing.T) {
+		err, page, _ := customerRepository.ListBy(
+			ctx,
+			CustomerFilter{Sort: []SortField{{Field: "first_name; DROP TABLE customers"}}},
+		)
+
+		assert.EqualError(t, err, "customers cannot be sorted by 'first_name; DROP TABLE customers'")
+		assert.Empty(t, page.Items)
+	})
+
+	t.Run("test filter customers created within range", func(t *testing.T) {
+		customers := createCustomers(t,
+			database.Customer{
+				FirstName: "Alice", LastName: "Doe", TelephoneNumber: "1",
+				CreatedAt: time.Date(2023, 1, 31, 23, 59, 0, 0, time.UTC),
+			},
+			database.Customer{
+				FirstName: "Bob", LastName: "Doe", TelephoneNumber: "2",
+				CreatedAt: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			database.Customer{
+				FirstName: "Xin", LastName: "Doe", TelephoneNumber: "3",
+				CreatedAt: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		)
+
+		err, page, total := customerRepository.ListBy(ctx, CustomerFilter{
+			CreatedFrom:   time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			CreatedBefore: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{customers[1].ID}, customerIDs(page.Items))
+		assert.Equal(t, 1, total)
+		clearRecords(t, db)
+	})
+
+	t.Run("test filter customers by open repairs and recent purchases", func(t *testing.T) {
+		customers := createCustomers(t,
+			database.Customer{FirstName: "Alice", LastName: "Doe", TelephoneNumber: "1"},
+			database.Customer{FirstName: "Bob", LastName: "Doe", TelephoneNumber: "2"},
+			database.Customer{FirstName: "Xin", LastName: "Doe", TelephoneNumber: "3"},
+		)
+		err, _ := repairRepository.Create(ctx, &customers[0], getRepairFixture(t))
+		assert.NoError(t, err)
+		cancelledRepair := getRepairFixture(t)
+		cancelledRepair.Status = database.RepairCancelled
+		err, _ = repairRepository.Create(ctx, &customers[1], cancelledRepair)
+		assert.NoError(t, err)
+		err, _ = purchaseRepository.Create(ctx, &customers[1], getPurchaseFixture(t))
+		assert.NoError(t, err)
+		recentPurchase := getPurchaseFixture(t)
+		recentPurchase.PurchasedAt = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+		err, _ = purchaseRepository.Create(ctx, &customers[2], recentPurchase)
+		assert.NoError(t, err)
+		hasOpenRepairs := true
+		hasNoOpenRepairs := false
+
+		err, withOpenRepairs, _ := customerRepository.ListBy(ctx, CustomerFilter{HasOpenRepairs: &hasOpenRepairs})
+		assert.NoError(t, err)
+		err, withoutOpenRepairs, _ := customerRepository.ListBy(ctx, CustomerFilter{HasOpenRepairs: &hasNoOpenRepairs})
+		assert.NoError(t, err)
+		err, purchasedRecently, _ := customerRepository.ListBy(
+			ctx,
+			CustomerFilter{PurchasedSince: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{customers[0].ID}, customerIDs(withOpenRepairs.Items))
+		assert.Equal(t, []string{customers[1].ID, customers[2].ID}, customerIDs(withoutOpenRepairs.Items))
+		assert.Equal(t, []string{customers[2].ID}, customerIDs(purchasedRecently.Items))
+		clearRecords(t, db)
+	})
+
 	t.Run("test get all customers when no records ", func(t *testing.T) {
-		err, dbCustomers, total := customerRepository.ListBy("", "", 10, 0)
+		err, page, total := customerRepository.ListBy(ctx, CustomerFilter{Limit: 10})
 
 		assert.NoError(t, err)
-		assert.Len(t, dbCustomers, 0)
+		assert.Len(t, page.Items, 0)
 		assert.Equal(t, 0, total)
 	})
 
 	t.Run("test get customer by its id", func(t *testing.T) {
 		err, expectedCustomer := customerRepository.Create(
+			ctx,
 			&database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"},
 		)
 		assert.NoError(t, err)
 		err, _ = customerRepository.Create(
+			ctx,
 			&database.Customer{FirstName: "Jane", LastName: "Doe", TelephoneNumber: "987456123"},
 		)
 		assert.NoError(t, err)
 
-		err, currentCustomer := customerRepository.GetByID(expectedCustomer.ID)
+		err, currentCustomer := customerRepository.GetByID(ctx, expectedCustomer.ID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedCustomer.ID, currentCustomer.ID)
@@ -169,19 +436,37 @@ func TestDBCustomerRepository(t *testing.T) {
 	t.Run("test get customer by its id but not found", func(t *testing.T) {
 		invalidID := "4a923682-b051-47c1-b37a-666544d71419"
 		err, _ := customerRepository.Create(
+			ctx,
 			&database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"},
 		)
 		assert.NoError(t, err)
 
-		err, currentCustomer := customerRepository.GetByID(invalidID)
+		err, currentCustomer := customerRepository.GetByID(ctx, invalidID)
 
 		assert.Equal(t, err, &CustomerNotFoundError{CustomerID: invalidID})
 		assert.Nil(t, currentCustomer)
 		clearRecords(t, db)
 	})
 
+	t.Run("test get customer by its id with cancelled context", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(
+			ctx,
+			&database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"},
+		)
+		assert.NoError(t, err)
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err, currentCustomer := customerRepository.GetByID(cancelledCtx, existingCustomer.ID)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, currentCustomer)
+		clearRecords(t, db)
+	})
+
 	t.Run("test edit customer details", func(t *testing.T) {
 		err, existingCustomer := customerRepository.Create(
+			ctx,
 			&database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"},
 		)
 		assert.NoError(t, err)
@@ -190,17 +475,137 @@ func TestDBCustomerRepository(t *testing.T) {
 			FirstName:       "Bob",
 			LastName:        "Toe",
 			TelephoneNumber: "897564321",
+			Version:         existingCustomer.Version,
 		}
 
-		err, returnedCustomer := customerRepository.Update(updatedCustomer)
+		err, returnedCustomer := customerRepository.Update(ctx, updatedCustomer)
 
-		_, dbCustomer := customerRepository.GetByID(returnedCustomer.ID)
+		_, dbCustomer := customerRepository.GetByID(ctx, returnedCustomer.ID)
 		assertCustomer(t, updatedCustomer, dbCustomer)
 		assertCustomer(t, updatedCustomer, returnedCustomer)
+		assert.Equal(t, 2, dbCustomer.Version)
+		assert.Equal(t, 2, returnedCustomer.Version)
+		assert.False(t, returnedCustomer.CreatedAt.IsZero())
+		assert.True(t, dbCustomer.CreatedAt.Equal(returnedCustomer.CreatedAt))
+		assert.True(t, dbCustomer.UpdatedAt.Equal(returnedCustomer.UpdatedAt))
+		clearRecords(t, db)
+	})
+
+	t.Run("test edit customer contact profile only", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		birthDate := time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC)
+		updatedCustomer := *existingCustomer
+		updatedCustomer.FirstName = "Bob"
+		updatedCustomer.Address = database.Address{Street: "Długa 1", City: "Kraków", PostalCode: "30-001", Country: "PL"}
+		updatedCustomer.BirthDate = &birthDate
+		updatedCustomer.PreferredContact = database.ContactPost
+
+		err, returnedCustomer := customerRepository.Update(ctx, &updatedCustomer, "Address", "BirthDate", "PreferredContact")
+
+		assert.NoError(t, err)
+		updatedCustomer.FirstName = existingCustomer.FirstName
+		assertCustomer(t, &updatedCustomer, returnedCustomer)
+		assert.True(t, birthDate.Equal(*returnedCustomer.BirthDate))
+		clearRecords(t, db)
+	})
+
+	t.Run("test edit customer details changed in the meantime", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		firstUpdate := &database.Customer{
+			ID:              existingCustomer.ID,
+			FirstName:       "Bob",
+			LastName:        "Toe",
+			TelephoneNumber: "897564321",
+			Version:         existingCustomer.Version,
+		}
+		secondUpdate := *firstUpdate
+		secondUpdate.FirstName = "Alice"
+		err, _ = customerRepository.Update(ctx, firstUpdate)
+		assert.NoError(t, err)
+
+		err, _ = customerRepository.Update(ctx, &secondUpdate)
+
+		assert.Equal(t, &VersionConflictError{ID: existingCustomer.ID, Version: 1}, err)
+		_, dbCustomer := customerRepository.GetByID(ctx, existingCustomer.ID)
+		assert.Equal(t, "Bob", dbCustomer.FirstName)
+		assert.Equal(t, 2, dbCustomer.Version)
+		err = customerRepository.DeleteByID(ctx, existingCustomer.ID, 1)
+		assert.Equal(t, &VersionConflictError{ID: existingCustomer.ID, Version: 1}, err)
+		assert.Len(t, getAllCustomers(t, db), 1)
+		clearRecords(t, db)
+	})
+
+	t.Run("test cannot edit customer telephone number to the one of another customer", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		otherTelephoneNumberE164 := "+48897564321"
+		err, otherCustomer := customerRepository.Create(ctx, &database.Customer{
+			FirstName:           "Bob",
+			LastName:            "Toe",
+			TelephoneNumber:     "897564321",
+			TelephoneNumberE164: &otherTelephoneNumberE164,
+		})
+		assert.NoError(t, err)
+		otherCustomer.TelephoneNumber = "123 456 789"
+		otherCustomer.TelephoneNumberE164 = existingCustomer.TelephoneNumberE164
+
+		err, _ = customerRepository.Update(ctx, otherCustomer, "TelephoneNumber")
+
+		assert.EqualError(t, err, "customer 'Bob Toe' cannot have telephone number '123 456 789' as already taken.")
+		_, dbCustomer := customerRepository.GetByID(ctx, otherCustomer.ID)
+		assert.Equal(t, "897564321", dbCustomer.TelephoneNumber)
+		assert.Equal(t, 1, dbCustomer.Version)
+		clearRecords(t, db)
+	})
+
+	t.Run("test edit only given customer details", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+
+		err, returnedCustomer := customerRepository.Update(
+			ctx,
+			&database.Customer{ID: existingCustomer.ID, FirstName: "Bob", Version: existingCustomer.Version},
+			"FirstName",
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bob", returnedCustomer.FirstName)
+		assert.Equal(t, existingCustomer.LastName, returnedCustomer.LastName)
+		assert.Equal(t, existingCustomer.TelephoneNumber, returnedCustomer.TelephoneNumber)
+		_, dbCustomer := customerRepository.GetByID(ctx, existingCustomer.ID)
+		assert.Equal(t, "Bob", dbCustomer.FirstName)
+		assert.Equal(t, existingCustomer.LastName, dbCustomer.LastName)
+		assert.Equal(t, existingCustomer.TelephoneNumber, dbCustomer.TelephoneNumber)
+		clearRecords(t, db)
+	})
+
+	t.Run("test edit customer field which cannot be updated", func(t *testing.T) {
+		err, existingCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+
+		err, _ = customerRepository.Update(
+			ctx,
+			&database.Customer{ID: existingCustomer.ID, Version: existingCustomer.Version},
+			"CreatedAt",
+		)
+
+		assert.EqualError(t, err, "field 'CreatedAt' cannot be updated")
 		clearRecords(t, db)
 	})
 
 	t.Run("test edit customer details but not found", func(t *testing.T) {
-		// TODO
+		err, returnedCustomer := customerRepository.Update(ctx, &database.Customer{
+			ID:              "4a923682-1234-47c1-b37a-666544d71419",
+			FirstName:       "Bob",
+			LastName:        "Toe",
+			TelephoneNumber: "897564321",
+			Version:         1,
+		})
+
+		assert.Equal(t, &CustomerNotFoundError{CustomerID: "4a923682-1234-47c1-b37a-666544d71419"}, err)
+		assert.Nil(t, returnedCustomer)
+		assert.Empty(t, getAllCustomers(t, db))
 	})
 }
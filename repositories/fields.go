@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+)
+
+// updatableFields maps fields of an entity, which can be given to Update, to their columns.
+type updatableFields map[string][]string
+
+// columns returns columns of the given fields, or columns of all fields when none are given.
+func (u updatableFields) columns(fields []string) (error, []string) {
+	if len(fields) == 0 {
+		for field := range u {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+	var columns []string
+	for _, field := range fields {
+		fieldColumns, ok := u[field]
+		if !ok {
+			return fmt.Errorf("field '%s' cannot be updated", field), nil
+		}
+		columns = append(columns, fieldColumns...)
+	}
+	return nil, columns
+}
+
+// moneyColumns returns columns of money embedded with the column prefix, e.g. "cost".
+func moneyColumns(prefix string) []string {
+	return []string{prefix + "_amount", prefix + "_currency"}
+}
@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"customer-manager/database"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// MatchSpan marks the matched part of a customer field. Start and End are character offsets
+// in the field value, End is exclusive.
+type MatchSpan struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// MatchScore ranks how well a customer matches the search, from 0 to 1 for the exact match.
+type MatchScore float64
+
+const (
+	prefixMatchScore    MatchScore = 0.9
+	minTelephoneDigits             = 3
+	minPrefixMatchRunes            = 2
+)
+
+// editDistance returns the optimal string alignment distance, which is the Levenshtein distance
+// counting a transposition of two adjacent characters, a common typo, as a single edit.
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = minInt(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+func minInt(values ...int) int {
+	minimum := values[0]
+	for _, value := range values[1:] {
+		if value < minimum {
+			minimum = value
+		}
+	}
+	return minimum
+}
+
+// allowedEdits is the number of typos tolerated in a word of the given length.
+func allowedEdits(length int) int {
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 5:
+		return 1
+	case length <= 10:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// searchPieces splits the folded searched word into pieces, at least one of which is found unchanged in every
+// word the searched one matches. A typo changes at most two adjacent characters, so with at most k typos
+// tolerated the word is split into 2k+1 pieces. Nil is returned for words too short to be split so.
+func searchPieces(searched []rune) []string {
+	edits := 0
+	// matching words differ in length by no more than the number of typos tolerated for the longer one
+	for length := len(searched); allowedEdits(length) >= length-len(searched); length++ {
+		if allowedEdits(length) > edits {
+			edits = allowedEdits(length)
+		}
+	}
+	count := 2*edits + 1
+	if len(searched) < count {
+		return nil
+	}
+	pieces := make([]string, count)
+	for i := range pieces {
+		pieces[i] = string(searched[i*len(searched)/count : (i+1)*len(searched)/count])
+	}
+	return pieces
+}
+
+// wordSimilarity scores how well the searched word matches the word, along with the number of matched
+// characters at the start of the word. Words match exactly, by prefix, or with a few typos.
+func wordSimilarity(searched, word []rune) (MatchScore, int) {
+	if string(searched) == string(word) {
+		return 1, len(word)
+	}
+	if len(searched) >= minPrefixMatchRunes && len(searched) < len(word) &&
+		string(word[:len(searched)]) == string(searched) {
+		return prefixMatchScore * MatchScore(len(searched)) / MatchScore(len(word)), len(searched)
+	}
+	longer := len(word)
+	if len(searched) > longer {
+		longer = len(searched)
+	}
+	distance := editDistance(searched, word)
+	if distance > allowedEdits(longer) {
+		return 0, 0
+	}
+	return 1 - MatchScore(distance)/MatchScore(longer), len(word)
+}
+
+// matchedWord is a word of a customer field along with its character offset.
+type matchedWord struct {
+	runes []rune
+	start int
+}
+
+// words splits folded text into words separated by spaces, hyphens or apostrophes,
+// so both parts of double-barrelled surnames are matched.
+func words(text []rune) []matchedWord {
+	var result []matchedWord
+	start := -1
+	for i := 0; i <= len(text); i++ {
+		separator := i == len(text) || unicode.IsSpace(text[i]) || text[i] == '-' || text[i] == '\''
+		if separator && start >= 0 {
+			result = append(result, matchedWord{runes: text[start:i], start: start})
+			start = -1
+		} else if !separator && start < 0 {
+			start = i
+		}
+	}
+	return result
+}
+
+// candidate holds customer details searched by the fuzzy matcher.
+type candidate struct {
+	ID                  string
+	FirstName           string
+	LastName            string
+	TelephoneNumber     string
+	TelephoneNumberE164 *string
+}
+
+// matchCandidate matches every searched word against the customer names, words made of digits
+// are searched for in the telephone number instead. The score is the average score of searched words,
+// the customer does not match unless all of the words do.
+func matchCandidate(searched []string, customer candidate) (MatchScore, []MatchSpan) {
+	fields := []struct {
+		name  string
+		words []matchedWord
+	}{
+		{"last_name", words(database.Fold(customer.LastName))},
+		{"first_name", words(database.Fold(customer.FirstName))},
+	}
+	var total MatchScore
+	var spans []MatchSpan
+	for _, word := range searched {
+		var best MatchScore
+		var bestSpan MatchSpan
+		if digits := telephoneDigits(word); len(digits) >= minTelephoneDigits && digits == strings.TrimPrefix(word, "+") {
+			best, bestSpan = matchTelephoneNumber(digits, customer)
+		} else {
+			searchedRunes := database.Fold(word)
+			for _, field := range fields {
+				for _, fieldWord := range field.words {
+					score, length := wordSimilarity(searchedRunes, fieldWord.runes)
+					if score > best {
+						best = score
+						bestSpan = MatchSpan{Field: field.name, Start: fieldWord.start, End: fieldWord.start + length}
+					}
+				}
+			}
+		}
+		if best == 0 {
+			return 0, nil
+		}
+		total += best
+		if !slices.Contains(spans, bestSpan) {
+			spans = append(spans, bestSpan)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Field != spans[j].Field {
+			return spans[i].Field < spans[j].Field
+		}
+		return spans[i].Start < spans[j].Start
+	})
+	return total / MatchScore(len(searched)), spans
+}
+
+// matchTelephoneNumber finds the digits in the displayed telephone number, which gets the matched span,
+// or in the E.164 form, which includes the country calling code omitted from national numbers.
+func matchTelephoneNumber(digits string, customer candidate) (MatchScore, MatchSpan) {
+	number := []rune(customer.TelephoneNumber)
+	var positions []int
+	for i, r := range number {
+		if r >= '0' && r <= '9' {
+			positions = append(positions, i)
+		}
+	}
+	if index := strings.Index(telephoneDigits(customer.TelephoneNumber), digits); index >= 0 {
+		return 1, MatchSpan{
+			Field: "telephone_number",
+			Start: positions[index],
+			End:   positions[index+len(digits)-1] + 1,
+		}
+	}
+	if customer.TelephoneNumberE164 != nil && strings.Contains(*customer.TelephoneNumberE164, digits) {
+		return 1, MatchSpan{Field: "telephone_number", Start: 0, End: len(number)}
+	}
+	return 0, MatchSpan{}
+}
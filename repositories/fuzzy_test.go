@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"kowalski", "kowalski", 0},
+		{"kowalsky", "kowalski", 1},
+		{"kowlaski", "kowalski", 1},
+		{"kolwaski", "kowalski", 2},
+		{"nowk", "nowak", 1},
+		{"", "abc", 3},
+	} {
+		assert.Equal(t, test.distance, editDistance([]rune(test.a), []rune(test.b)), test.a)
+	}
+}
+
+func TestMatchCandidate(t *testing.T) {
+	e164 := "+48600100200"
+	customer := candidate{
+		FirstName:           "Łukasz",
+		LastName:            "Wiśniewski-Kowalski",
+		TelephoneNumber:     "600 100 200",
+		TelephoneNumberE164: &e164,
+	}
+
+	t.Run("test misspelt name without diacritics", func(t *testing.T) {
+		score, spans := matchCandidate([]string{"lukasz", "wisniewsky"}, customer)
+
+		assert.InDelta(t, 0.95, float64(score), 0.001)
+		assert.Equal(t, []MatchSpan{
+			{Field: "first_name", Start: 0, End: 6},
+			{Field: "last_name", Start: 0, End: 10},
+		}, spans)
+	})
+
+	t.Run("test second part of double-barrelled surname by prefix", func(t *testing.T) {
+		score, spans := matchCandidate([]string{"Kowal"}, customer)
+
+		assert.InDelta(t, 0.5625, float64(score), 0.001)
+		assert.Equal(t, []MatchSpan{{Field: "last_name", Start: 11, End: 16}}, spans)
+	})
+
+	t.Run("test telephone number digits", func(t *testing.T) {
+		_, spans := matchCandidate([]string{"100200"}, customer)
+		assert.Equal(t, []MatchSpan{{Field: "telephone_number", Start: 4, End: 11}}, spans)
+
+		_, spans = matchCandidate([]string{"+48600"}, customer)
+		assert.Equal(t, []MatchSpan{{Field: "telephone_number", Start: 0, End: 11}}, spans)
+	})
+
+	t.Run("test every word has to match", func(t *testing.T) {
+		score, spans := matchCandidate([]string{"lukasz", "nowak"}, customer)
+
+		assert.Zero(t, score)
+		assert.Nil(t, spans)
+	})
+}
+
+func TestSearchPieces(t *testing.T) {
+	assert.Equal(t, []string{"al"}, searchPieces([]rune("al")))
+	assert.Equal(t, []string{"j", "a", "n"}, searchPieces([]rune("jan")))
+	assert.Nil(t, searchPieces([]rune("anna")))
+	assert.Equal(t, []string{"w", "i", "sn", "i", "ew", "s", "ky"}, searchPieces([]rune("wisniewsky")))
+	for _, test := range [][2]string{
+		{"wisniewsky", "wisniewski"},
+		{"wsiniewski", "wisniewski"},
+		{"kowlaski", "kowalski"},
+		{"kowal", "kowalski"},
+		{"nowk", "nowak"},
+		{"zielinsky", "zielinskiego"},
+	} {
+		searched, word := []rune(test[0]), []rune(test[1])
+		if score, _ := wordSimilarity(searched, word); score == 0 {
+			continue
+		}
+		pieces := searchPieces(searched)
+		found := pieces == nil
+		for _, piece := range pieces {
+			found = found || strings.Contains(test[1], piece)
+		}
+		assert.True(t, found, test)
+	}
+}
+
+func TestDBCustomerRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+
+	clearRecords(t, db)
+	for i, name := range [][2]string{
+		{"Jan", "Wiśniewski"},
+		{"Anna", "Wiśniewska"},
+		{"Jan", "Kowalski"},
+		{"Piotr", "Wiśniewski"},
+	} {
+		err, _ := customerRepository.Create(ctx, &database.Customer{
+			FirstName:       name[0],
+			LastName:        name[1],
+			TelephoneNumber: string(rune('1' + i)),
+		})
+		assert.NoError(t, err)
+	}
+	err, deleted := customerRepository.Create(ctx, &database.Customer{
+		FirstName: "Jan", LastName: "Wisniewski", TelephoneNumber: "9",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, customerRepository.DeleteByID(ctx, deleted.ID, deleted.Version))
+
+	t.Run("test best matches come first", func(t *testing.T) {
+		err, matches := customerRepository.Search(ctx, "jan wisniewsky", 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, "Wiśniewski", matches[0].Customer.LastName)
+		assert.Equal(t, "Jan", matches[0].Customer.FirstName)
+		assert.NotEmpty(t, matches[0].Customer.TelephoneNumber)
+	})
+
+	t.Run("test limit of matches", func(t *testing.T) {
+		err, matches := customerRepository.Search(ctx, "wisniewsky", 2)
+
+		assert.NoError(t, err)
+		assert.Len(t, matches, 2)
+		assert.Equal(t, "Anna Wiśniewska", matches[0].Customer.FirstName+" "+matches[0].Customer.LastName)
+		assert.Equal(t, "Jan Wiśniewski", matches[1].Customer.FirstName+" "+matches[1].Customer.LastName)
+		assert.Equal(t, matches[0].Score, matches[1].Score)
+	})
+
+	t.Run("test no matches", func(t *testing.T) {
+		err, matches := customerRepository.Search(ctx, "zielinski", 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run("test customer found by changed name", func(t *testing.T) {
+		err, customer := customerRepository.Create(ctx, &database.Customer{
+			FirstName: "Maria", LastName: "Nowak", TelephoneNumber: "600-100-200",
+		})
+		assert.NoError(t, err)
+		customer.LastName = "Zielińska"
+		err, _ = customerRepository.Update(ctx, customer, "LastName")
+		assert.NoError(t, err)
+
+		err, matches := customerRepository.Search(ctx, "zielinska", 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, customer.ID, matches[0].Customer.ID)
+		err, matches = customerRepository.Search(ctx, "nowak", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, matches)
+		err, matches = customerRepository.Search(ctx, "100200", 10)
+		assert.NoError(t, err)
+		assert.Len(t, matches, 1)
+	})
+	clearRecords(t, db)
+}
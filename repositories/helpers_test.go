@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"customer-manager/database"
+	"customer-manager/database/migrations"
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -13,7 +15,19 @@ import (
 
 func clearRecords(t *testing.T, db *gorm.DB) {
 	t.Helper()
-	tables := []string{"purchases", "repairs", "customers"}
+	tables := []string{
+		"purchases",
+		"prescriptions",
+		"repairs",
+		"customers",
+		"audit_entries",
+		"invoice_items",
+		"invoices",
+		"invoice_sequences",
+		"payments",
+		"frames",
+		"lens_types",
+	}
 	for _, name := range tables {
 		tx := db.Exec(fmt.Sprintf("DELETE FROM %s", name))
 		if tx.Error != nil {
@@ -22,7 +36,23 @@ func clearRecords(t *testing.T, db *gorm.DB) {
 	}
 }
 
-var db = database.GetDatabase(&gorm.Config{Logger: database.GetLogger(logger.Silent)})
+var db = getTestDatabase()
+
+// getTestDatabase connects to DATABASE_URL if set, otherwise to in-memory SQLite, and migrates the schema.
+func getTestDatabase() *gorm.DB {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = "sqlite://:memory:"
+	}
+	testDB, err := database.OpenDatabase(databaseURL, &gorm.Config{Logger: database.GetLogger(logger.Silent)})
+	if err != nil {
+		panic(err)
+	}
+	if _, err := migrations.Up(testDB); err != nil {
+		panic(err)
+	}
+	return testDB
+}
 
 func getAllCustomers(t *testing.T, db *gorm.DB) []database.Customer {
 	t.Helper()
@@ -71,6 +101,11 @@ func assertCustomer(t *testing.T, expected *database.Customer, actual *database.
 	assert.Equal(t, expected.FirstName, actual.FirstName)
 	assert.Equal(t, expected.LastName, actual.LastName)
 	assert.Equal(t, expected.TelephoneNumber, actual.TelephoneNumber)
+	assert.Equal(t, expected.TelephoneNumberE164, actual.TelephoneNumberE164)
+	assert.Equal(t, expected.Email, actual.Email)
+	assert.Equal(t, expected.Address, actual.Address)
+	assert.Equal(t, expected.PreferredContact, actual.PreferredContact)
+	assert.Equal(t, expected.Notes, actual.Notes)
 	assert.Equal(t, expected.Purchases, actual.Purchases)
 	assert.Equal(t, expected.Repairs, actual.Repairs)
 }
@@ -80,12 +115,47 @@ func assertPurchase(t *testing.T, expected *database.Purchase, actual *database.
 
 	assert.Equal(t, expected.FrameModel, actual.FrameModel)
 	assert.Equal(t, expected.LensPower, actual.LensPower)
+	assert.Equal(t, expected.PD, actual.PD)
 	assert.Equal(t, expected.LensType, actual.LensType)
 }
 
 func getCustomerFixture(t *testing.T) *database.Customer {
 	t.Helper()
-	return &database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"}
+	telephoneNumberE164 := "+48123456789"
+	return &database.Customer{
+		FirstName:           "John",
+		LastName:            "Doe",
+		TelephoneNumber:     "123456789",
+		TelephoneNumberE164: &telephoneNumberE164,
+	}
+}
+
+func getLensPowerFixture(t *testing.T) database.LensPower {
+	t.Helper()
+	return database.LensPower{
+		Right: database.EyePrescription{Sphere: -1.25, Cylinder: -0.5, Axis: 180},
+		Left:  database.EyePrescription{Sphere: -1, Add: 2, Prism: 1.5, Base: "BI"},
+	}
+}
+
+func getPrescriptionFixture(t *testing.T) *database.Prescription {
+	t.Helper()
+	return &database.Prescription{
+		LensPower: getLensPowerFixture(t),
+		PD:        database.PupillaryDistance{Right: 31, Left: 30.5},
+		IssuedBy:  "Dr. Anna Nowak",
+		IssuedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func getRepairFixture(t *testing.T) *database.Repair {
+	t.Helper()
+	return &database.Repair{
+		Description: "some issue with the thing",
+		Cost:        database.Money{Amount: 1232, Currency: "PLN"},
+		ReportedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
 }
 
 func getPurchaseFixture(t *testing.T) *database.Purchase {
@@ -93,8 +163,8 @@ func getPurchaseFixture(t *testing.T) *database.Purchase {
 	return &database.Purchase{
 		FrameModel:   "Model1",
 		LensType:     "LensType1",
-		LensPower:    "LensPower",
-		PD:           "CustomPD",
+		LensPower:    getLensPowerFixture(t),
+		PD:           database.PupillaryDistance{Binocular: 62},
 		PurchaseType: "CustomPurchaseType",
 		PurchasedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
@@ -1,24 +1,108 @@
 package repositories
 
-import "customer-manager/database"
+import (
+	"context"
+	"customer-manager/database"
+	"time"
+)
 
 type CustomerRepository interface {
-	Create(customer *database.Customer) (error, *database.Customer)
-	DeleteByID(customerID string) error
-	ListBy(customerFirstName string, customerLastName string, limit int, offset int) (error, []database.Customer, int)
-	GetByID(customerID string) (error, *database.Customer)
-	Update(customer *database.Customer) (error, *database.Customer)
+	Create(ctx context.Context, customer *database.Customer) (error, *database.Customer)
+	DeleteByID(ctx context.Context, customerID string, version int) error
+	ListBy(ctx context.Context, filter CustomerFilter) (error, Page[database.Customer], int)
+	Search(ctx context.Context, query string, limit int) (error, []CustomerMatch)
+	GetByID(ctx context.Context, customerID string) (error, *database.Customer)
+	Update(ctx context.Context, customer *database.Customer, fields ...string) (error, *database.Customer)
+	Restore(ctx context.Context, customerID string) (error, *database.Customer)
 }
 
 type PurchaseRepository interface {
-	Create(customer *database.Customer, purchase *database.Purchase) (error, *database.Purchase)
-	GetAll(customerID string) (error, []database.Purchase)
-	DeleteByID(purchaseID string) error
-	Update(customer *database.Purchase) (error, *database.Purchase)
+	Create(ctx context.Context, customer *database.Customer, purchase *database.Purchase) (error, *database.Purchase)
+	GetAll(ctx context.Context, customerID string) (error, []database.Purchase)
+	GetPage(ctx context.Context, customerID string, cursor *Cursor, limit int) (error, Page[database.Purchase])
+	GetByID(ctx context.Context, purchaseID string) (error, *database.Purchase)
+	DeleteByID(ctx context.Context, purchaseID string, version int) error
+	Update(ctx context.Context, purchase *database.Purchase, fields ...string) (error, *database.Purchase)
+	Restore(ctx context.Context, purchaseID string) (error, *database.Purchase)
+}
+
+type PrescriptionRepository interface {
+	Create(
+		ctx context.Context,
+		customer *database.Customer,
+		prescription *database.Prescription,
+	) (error, *database.Prescription)
+	GetAll(ctx context.Context, customerID string) (error, []database.Prescription)
+	GetByID(ctx context.Context, prescriptionID string) (error, *database.Prescription)
+	Update(ctx context.Context, prescription *database.Prescription) (error, *database.Prescription)
+	DeleteByID(ctx context.Context, prescriptionID string) error
 }
 
 type RepairRepository interface {
-	Create(customer *database.Customer, repair *database.Repair) (error, *database.Repair)
-	GetAll(customerID string) (error, []database.Repair)
-	DeleteByID(repairID string) error
+	Create(ctx context.Context, customer *database.Customer, repair *database.Repair) (error, *database.Repair)
+	GetAll(ctx context.Context, customerID string) (error, []database.Repair)
+	GetPage(ctx context.Context, customerID string, cursor *Cursor, limit int) (error, Page[database.Repair])
+	ListByStatus(ctx context.Context, statuses []database.RepairStatus) (error, []database.Repair)
+	GetByID(ctx context.Context, repairID string) (error, *database.Repair)
+	Update(ctx context.Context, repair *database.Repair, fields ...string) (error, *database.Repair)
+	UpdateStatus(
+		ctx context.Context,
+		repairID string,
+		status database.RepairStatus,
+		version int,
+	) (error, *database.Repair)
+	DeleteByID(ctx context.Context, repairID string, version int) error
+	Restore(ctx context.Context, repairID string) (error, *database.Repair)
+}
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *database.Invoice) (error, *database.Invoice)
+	GetAll(ctx context.Context, customerID string) (error, []database.Invoice)
+	GetByID(ctx context.Context, invoiceID string) (error, *database.Invoice)
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *database.Payment) (error, *database.Payment)
+	GetAll(ctx context.Context, customerID string) (error, []database.Payment)
+	GetBalance(ctx context.Context, customerID string) (error, *CustomerBalance)
+	ListUnpaid(ctx context.Context) (error, []UnpaidBalance)
+}
+
+// CatalogProduct is a kind of products of the catalog.
+type CatalogProduct interface {
+	database.Frame | database.LensType
+}
+
+type CatalogRepository[T CatalogProduct] interface {
+	Create(ctx context.Context, product *T) (error, *T)
+	List(ctx context.Context, filter ProductFilter) (error, []T)
+	GetByID(ctx context.Context, productID string) (error, *T)
+	Update(ctx context.Context, product *T) (error, *T)
+	AdjustStock(ctx context.Context, productID string, quantity int, allowNegative bool) (error, *T)
+}
+
+type InventoryRepository interface {
+	GetReorderSuggestions(ctx context.Context, policy ReorderPolicy, now time.Time) (error, *ReorderSuggestions)
+}
+
+type AuditRepository interface {
+	ListBy(ctx context.Context, filter AuditFilter) (error, []database.AuditEntry, int)
+}
+
+// Repositories groups repositories which share a single transaction within a unit of work.
+type Repositories struct {
+	Customers     CustomerRepository
+	Purchases     PurchaseRepository
+	Repairs       RepairRepository
+	Prescriptions PrescriptionRepository
+	Invoices      InvoiceRepository
+	Payments      PaymentRepository
+	Frames        CatalogRepository[database.Frame]
+	LensTypes     CatalogRepository[database.LensType]
+}
+
+type UnitOfWork interface {
+	// Do runs the callback with transaction-scoped repositories. The transaction is committed
+	// when the callback returns nil and rolled back otherwise, the callback error is returned.
+	Do(ctx context.Context, work func(repositories *Repositories) error) error
 }
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const day = 24 * time.Hour
+
+// ReorderPolicy tells when and how much of a product to reorder, given its sales per day measured
+// over the window of recent purchases. A product is reordered when its stock is expected to fall below
+// the reorder threshold before a delivery arrives, so much of it that the stock stays above the threshold
+// for the coverage period after the delivery.
+type ReorderPolicy struct {
+	Window   time.Duration
+	LeadTime time.Duration
+	Coverage time.Duration
+}
+
+var DefaultReorderPolicy = ReorderPolicy{Window: 90 * day, LeadTime: 14 * day, Coverage: 30 * day}
+
+// quantity returns how many items of the product to reorder, zero when it does not need reordering.
+// Sales are spread over the window evenly, partial items are rounded up.
+func (p ReorderPolicy) quantity(stock int, threshold int, sold int) int {
+	window := int64(p.Window / time.Hour)
+	if window <= 0 {
+		return 0
+	}
+	sold64 := int64(sold)
+	// stock - sold * lead time / window < threshold, multiplied by the window to keep integers exact
+	if int64(stock)*window-sold64*int64(p.LeadTime/time.Hour) >= int64(threshold)*window {
+		return 0
+	}
+	demand := (sold64*int64((p.LeadTime+p.Coverage)/time.Hour) + window - 1) / window
+	quantity := int(int64(threshold)+demand) - stock
+	if quantity < 1 {
+		return 1
+	}
+	return quantity
+}
+
+// ReorderItem is a product to reorder along with the quantity suggested, Kind is either "frame"
+// or "lens_type". Sold is the number of items sold within the window of the reorder policy.
+type ReorderItem struct {
+	Kind             string  `json:"kind"              example:"frame"`
+	ProductID        string  `json:"product_id"`
+	SKU              string  `json:"sku"`
+	Description      string  `json:"description"`
+	Stock            int     `json:"stock"`
+	ReorderThreshold int     `json:"reorder_threshold"`
+	Sold             int     `json:"sold"`
+	DailySales       float64 `json:"daily_sales"`
+	Quantity         int     `json:"quantity"`
+}
+
+// SupplierReorder lists products to reorder from a single supplier, the supplier is empty for products
+// without one.
+type SupplierReorder struct {
+	Supplier string        `json:"supplier"`
+	Items    []ReorderItem `json:"items"`
+}
+
+type ReorderSuggestions struct {
+	ComputedAt time.Time         `json:"computed_at"`
+	Suppliers  []SupplierReorder `json:"suppliers"`
+}
+
+type DBInventoryRepository struct {
+	DB *gorm.DB
+}
+
+// GetReorderSuggestions returns products of the catalog to reorder grouped by suppliers ordered by name,
+// products without a supplier go last. Sales are counted from purchases made within the window before now,
+// deleted purchases are left out.
+func (d *DBInventoryRepository) GetReorderSuggestions(
+	ctx context.Context,
+	policy ReorderPolicy,
+	now time.Time,
+) (error, *ReorderSuggestions) {
+	since := now.Add(-policy.Window).Truncate(day)
+	err, frames := reorderItems[database.Frame](d.DB.WithContext(ctx), policy, since)
+	if err != nil {
+		return err, nil
+	}
+	err, lensTypes := reorderItems[database.LensType](d.DB.WithContext(ctx), policy, since)
+	if err != nil {
+		return err, nil
+	}
+	suggestions := &ReorderSuggestions{ComputedAt: now, Suppliers: []SupplierReorder{}}
+	bySupplier := map[string]int{}
+	for _, item := range append(frames, lensTypes...) {
+		i, ok := bySupplier[item.supplier]
+		if !ok {
+			i = len(suggestions.Suppliers)
+			bySupplier[item.supplier] = i
+			suggestions.Suppliers = append(suggestions.Suppliers, SupplierReorder{Supplier: item.supplier})
+		}
+		suggestions.Suppliers[i].Items = append(suggestions.Suppliers[i].Items, item.ReorderItem)
+	}
+	sort.Slice(suggestions.Suppliers, func(i, j int) bool {
+		first, second := suggestions.Suppliers[i].Supplier, suggestions.Suppliers[j].Supplier
+		if first == "" || second == "" {
+			return second == ""
+		}
+		return first < second
+	})
+	return nil, suggestions
+}
+
+type supplierItem struct {
+	ReorderItem
+	supplier string
+}
+
+// reorderItems returns products of one kind of the catalog to reorder, ordered by SKU.
+func reorderItems[T CatalogProduct](db *gorm.DB, policy ReorderPolicy, since time.Time) (error, []supplierItem) {
+	kind := kindOf[T]()
+	var sales []struct {
+		ProductID string
+		Sold      int
+	}
+	err := db.Model(&database.Purchase{}).
+		Select(kind.purchaseColumn+" AS product_id, COUNT(*) AS sold").
+		Where(kind.purchaseColumn+" IS NOT NULL").
+		Where("purchased_at >= ?", since).
+		Group(kind.purchaseColumn).
+		Scan(&sales).Error
+	if err != nil {
+		return err, nil
+	}
+	sold := make(map[string]int, len(sales))
+	for _, sale := range sales {
+		sold[sale.ProductID] = sale.Sold
+	}
+	var products []T
+	if err := db.Order("sku asc").Find(&products).Error; err != nil {
+		return err, nil
+	}
+	var items []supplierItem
+	for i := range products {
+		product := productOf(&products[i])
+		quantity := policy.quantity(product.Stock, product.ReorderThreshold, sold[product.ID])
+		if quantity == 0 {
+			continue
+		}
+		items = append(items, supplierItem{
+			ReorderItem: ReorderItem{
+				Kind:             string(kind.entity),
+				ProductID:        product.ID,
+				SKU:              product.SKU,
+				Description:      any(&products[i]).(interface{ Description() string }).Description(),
+				Stock:            product.Stock,
+				ReorderThreshold: product.ReorderThreshold,
+				Sold:             sold[product.ID],
+				DailySales:       float64(sold[product.ID]) / (policy.Window.Hours() / 24),
+				Quantity:         quantity,
+			},
+			supplier: product.Supplier,
+		})
+	}
+	return nil, items
+}
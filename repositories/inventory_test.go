@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBInventoryRepository(t *testing.T) {
+	ctx := context.Background()
+	inventoryRepository := DBInventoryRepository{db}
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("test get reorder suggestions", func(t *testing.T) {
+		clearRecords(t, db)
+		selling := getFrameFixture("RB5154-BLK-51", 3)
+		selling.Supplier, selling.ReorderThreshold = "Luxottica", 2
+		assert.NoError(t, db.Create(selling).Error)
+		stocked := getFrameFixture("OA-1001", 5)
+		stocked.Supplier, stocked.ReorderThreshold = "Luxottica", 1
+		assert.NoError(t, db.Create(stocked).Error)
+		unsupplied := getFrameFixture("RB3025-GLD-58", 0)
+		unsupplied.ReorderThreshold = 1
+		assert.NoError(t, db.Create(unsupplied).Error)
+		lensType := &database.LensType{
+			Product: database.Product{SKU: "SV-PC-AR", Stock: 2, Supplier: "Hoya", ReorderThreshold: 2},
+			Name:    "Single vision",
+		}
+		assert.NoError(t, db.Create(lensType).Error)
+
+		customer := getCustomerFixture(t)
+		for i := 0; i < 9; i++ {
+			purchase := getPurchaseFixture(t)
+			purchase.FrameID = &selling.ID
+			purchase.PurchasedAt = time.Date(2024, 2, 1+i, 0, 0, 0, 0, time.UTC)
+			customer.Purchases = append(customer.Purchases, *purchase)
+		}
+		for _, purchasedAt := range []time.Time{
+			time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		} {
+			purchase := getPurchaseFixture(t)
+			purchase.LensTypeID = &lensType.ID
+			purchase.PurchasedAt = purchasedAt
+			customer.Purchases = append(customer.Purchases, *purchase)
+		}
+		err, customer := (&DBCustomerRepository{db}).Create(ctx, customer)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Delete(&customer.Purchases[len(customer.Purchases)-1]).Error)
+
+		err, suggestions := inventoryRepository.GetReorderSuggestions(ctx, DefaultReorderPolicy, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, now, suggestions.ComputedAt)
+		assert.Equal(t, []SupplierReorder{
+			{
+				Supplier: "Hoya",
+				Items: []ReorderItem{{
+					Kind:             "lens_type",
+					ProductID:        lensType.ID,
+					SKU:              "SV-PC-AR",
+					Description:      "Single vision",
+					Stock:            2,
+					ReorderThreshold: 2,
+					Sold:             3,
+					DailySales:       3.0 / 90,
+					Quantity:         2,
+				}},
+			},
+			{
+				Supplier: "Luxottica",
+				Items: []ReorderItem{{
+					Kind:             "frame",
+					ProductID:        selling.ID,
+					SKU:              "RB5154-BLK-51",
+					Description:      "Ray-Ban RB5154 Black 51-21-145",
+					Stock:            3,
+					ReorderThreshold: 2,
+					Sold:             9,
+					DailySales:       0.1,
+					Quantity:         4,
+				}},
+			},
+			{
+				Supplier: "",
+				Items: []ReorderItem{{
+					Kind:             "frame",
+					ProductID:        unsupplied.ID,
+					SKU:              "RB3025-GLD-58",
+					Description:      "Ray-Ban RB5154 Black 51-21-145",
+					Stock:            0,
+					ReorderThreshold: 1,
+					Quantity:         1,
+				}},
+			},
+		}, suggestions.Suppliers)
+	})
+
+	t.Run("test no reorder suggestions", func(t *testing.T) {
+		clearRecords(t, db)
+		assert.NoError(t, db.Create(getFrameFixture("RB5154-BLK-51", 0)).Error)
+
+		err, suggestions := inventoryRepository.GetReorderSuggestions(ctx, DefaultReorderPolicy, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []SupplierReorder{}, suggestions.Suppliers)
+	})
+}
+
+func TestReorderPolicyQuantity(t *testing.T) {
+	policy := ReorderPolicy{Window: 10 * day, LeadTime: 5 * day, Coverage: 10 * day}
+
+	for _, test := range []struct {
+		stock     int
+		threshold int
+		sold      int
+		expected  int
+	}{
+		{3, 2, 0, 0},  // stock above threshold without sales
+		{1, 2, 0, 1},  // stock below threshold without sales
+		{4, 2, 4, 0},  // stock lasting the lead time
+		{3, 2, 4, 5},  // stock running out within the lead time
+		{-2, 0, 1, 4}, // backordered stock
+	} {
+		quantity := policy.quantity(test.stock, test.threshold, test.sold)
+
+		assert.Equal(t, test.expected, quantity, test)
+	}
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceNotFoundError struct {
+	InvoiceID string
+}
+
+func (i *InvoiceNotFoundError) Error() string {
+	return fmt.Sprintf("invoice with ID '%s' does not exist", i.InvoiceID)
+}
+
+// AlreadyInvoicedError is returned when a purchase or repair is invoiced again. Entity and ID are not known
+// when the item was invoiced by a concurrent request.
+type AlreadyInvoicedError struct {
+	Entity        string
+	ID            string
+	InvoiceNumber string
+}
+
+func (a *AlreadyInvoicedError) Error() string {
+	if a.ID == "" {
+		return "some of given purchases and repairs are already invoiced"
+	}
+	return fmt.Sprintf("%s with ID '%s' is already invoiced on invoice '%s'", a.Entity, a.ID, a.InvoiceNumber)
+}
+
+// InvoiceBackdatedError is returned when an invoice is issued before the latest invoice of the same year,
+// which would break the order of dates along the sequence of numbers.
+type InvoiceBackdatedError struct {
+	IssuedAt       time.Time
+	LatestIssuedAt time.Time
+}
+
+func (i *InvoiceBackdatedError) Error() string {
+	return fmt.Sprintf(
+		"invoice cannot be issued on %s, before the latest invoice of the year issued on %s",
+		i.IssuedAt.Format(time.DateOnly), i.LatestIssuedAt.Format(time.DateOnly),
+	)
+}
+
+type DBInvoiceRepository struct {
+	DB *gorm.DB
+}
+
+// Create issues the invoice with the next number of the year it is issued in and calculates its totals.
+// The number is taken within the transaction creating the invoice, so the number of an invoice which failed
+// to be created is given to the next one, leaving no gaps in the sequence. Invoices cannot be issued before
+// the latest one of the year, so later numbers are never issued on earlier dates.
+func (d *DBInvoiceRepository) Create(ctx context.Context, invoice *database.Invoice) (error, *database.Invoice) {
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNotInvoiced(tx, invoice.Items); err != nil {
+			return err
+		}
+		err, sequence := nextInvoiceSequence(tx, invoice.IssuedAt.Year())
+		if err != nil {
+			return err
+		}
+		if err := checkNotBackdated(tx, invoice.IssuedAt); err != nil {
+			return err
+		}
+		invoice.SetNumber(sequence)
+		invoice.CalculateTotals()
+		if err := tx.Omit("Items").Create(invoice).Error; err != nil {
+			return err
+		}
+		for i := range invoice.Items {
+			invoice.Items[i].InvoiceID = invoice.ID
+			invoice.Items[i].Position = i + 1
+		}
+		// items are inserted on their own, as inserting associations would update items conflicting with them
+		err = tx.Omit(clause.Associations).Create(&invoice.Items).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return &AlreadyInvoicedError{}
+		}
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, database.AuditInvoice, invoice.ID, nil, invoice)
+	})
+	if err != nil {
+		return err, nil
+	}
+	return nil, invoice
+}
+
+// checkNotInvoiced returns AlreadyInvoicedError when any of purchases or repairs of items is already invoiced.
+func checkNotInvoiced(tx *gorm.DB, items []database.InvoiceItem) error {
+	var purchaseIDs, repairIDs []string
+	for _, item := range items {
+		if item.PurchaseID != nil {
+			purchaseIDs = append(purchaseIDs, *item.PurchaseID)
+		}
+		if item.RepairID != nil {
+			repairIDs = append(repairIDs, *item.RepairID)
+		}
+	}
+	var invoiced []struct {
+		PurchaseID *string
+		RepairID   *string
+		Number     string
+	}
+	err := tx.Table("invoice_items").
+		Select("invoice_items.purchase_id, invoice_items.repair_id, invoices.number").
+		Joins("JOIN invoices ON invoices.id = invoice_items.invoice_id").
+		Where("invoice_items.purchase_id IN ? OR invoice_items.repair_id IN ?", purchaseIDs, repairIDs).
+		Order("invoices.number asc").
+		Limit(1).
+		Scan(&invoiced).Error
+	if err != nil || len(invoiced) == 0 {
+		return err
+	}
+	if invoiced[0].PurchaseID != nil {
+		return &AlreadyInvoicedError{Entity: "purchase", ID: *invoiced[0].PurchaseID, InvoiceNumber: invoiced[0].Number}
+	}
+	return &AlreadyInvoicedError{Entity: "repair", ID: *invoiced[0].RepairID, InvoiceNumber: invoiced[0].Number}
+}
+
+// nextInvoiceSequence increments the last number of invoices issued in the year. The update keeps the row
+// locked until the transaction ends, so concurrent invoices wait for each other instead of sharing a number.
+func nextInvoiceSequence(tx *gorm.DB, year int) (error, int) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&database.InvoiceSequence{Year: year}).Error
+	if err != nil {
+		return err, 0
+	}
+	err = tx.Model(&database.InvoiceSequence{}).
+		Where("year = ?", year).
+		Update("last_number", gorm.Expr("last_number + 1")).Error
+	if err != nil {
+		return err, 0
+	}
+	var sequence database.InvoiceSequence
+	if err := tx.Where("year = ?", year).First(&sequence).Error; err != nil {
+		return err, 0
+	}
+	return nil, sequence.LastNumber
+}
+
+// checkNotBackdated returns InvoiceBackdatedError when an invoice of the year is issued after the given date.
+// It has to be called after the sequence of the year is locked by nextInvoiceSequence.
+func checkNotBackdated(tx *gorm.DB, issuedAt time.Time) error {
+	var latest []database.Invoice
+	err := tx.Where("year = ?", issuedAt.Year()).Order("issued_at desc").Limit(1).Find(&latest).Error
+	if err != nil || len(latest) == 0 || !latest[0].IssuedAt.After(issuedAt) {
+		return err
+	}
+	return &InvoiceBackdatedError{IssuedAt: issuedAt, LatestIssuedAt: latest[0].IssuedAt}
+}
+
+func orderedItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
+
+func (d *DBInvoiceRepository) GetByID(ctx context.Context, invoiceID string) (error, *database.Invoice) {
+	var invoice database.Invoice
+	result := d.DB.WithContext(ctx).Preload("Items", orderedItems).Where("id = ?", invoiceID).First(&invoice)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &InvoiceNotFoundError{InvoiceID: invoiceID}, nil
+	}
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &invoice
+}
+
+// GetAll returns invoices of the customer, the latest issued first.
+func (d *DBInvoiceRepository) GetAll(ctx context.Context, customerID string) (error, []database.Invoice) {
+	var invoices []database.Invoice
+	result := d.DB.WithContext(ctx).
+		Preload("Items", orderedItems).
+		Where("customer_id = ?", customerID).
+		Order("year desc, sequence desc").
+		Find(&invoices)
+	return result.Error, invoices
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBInvoiceRepository(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	repairRepository := DBRepairRepository{db}
+	invoiceRepository := DBInvoiceRepository{db}
+
+	clearRecords(t, db)
+	err, customer := customerRepository.Create(ctx, getCustomerFixture(t))
+	assert.NoError(t, err)
+	purchase := getPurchaseFixture(t)
+	purchase.Price = database.Money{Amount: 40000, Currency: "PLN"}
+	purchase.Discount = database.Money{Amount: 5000, Currency: "PLN"}
+	purchase.VATRate = 23
+	err, purchase = purchaseRepository.Create(ctx, &database.Customer{ID: customer.ID}, purchase)
+	assert.NoError(t, err)
+	repair := getRepairFixture(t)
+	repair.Cost = database.Money{Amount: 10000, Currency: "PLN"}
+	err, repair = repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, repair)
+	assert.NoError(t, err)
+	issue := func(issuedAt time.Time, items ...database.InvoiceItem) (error, *database.Invoice) {
+		return invoiceRepository.Create(ctx, &database.Invoice{
+			CustomerID: customer.ID,
+			Buyer:      database.InvoiceParty{Name: "John Doe"},
+			IssuedAt:   issuedAt,
+			Items:      items,
+		})
+	}
+
+	t.Run("test issue invoice for purchase and repair", func(t *testing.T) {
+		err, invoice := issue(
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			database.NewPurchaseInvoiceItem(purchase),
+			database.NewRepairInvoiceItem(repair, 23),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "FV/2024/0001", invoice.Number)
+		assert.Equal(t, database.Money{Amount: 36585, Currency: "PLN"}, invoice.Net)
+		assert.Equal(t, database.Money{Amount: 8415, Currency: "PLN"}, invoice.VAT)
+		assert.Equal(t, database.Money{Amount: 45000, Currency: "PLN"}, invoice.Total)
+
+		err, found := invoiceRepository.GetByID(ctx, invoice.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "FV/2024/0001", found.Number)
+		assert.Equal(t, "John Doe", found.Buyer.Name)
+		assert.Len(t, found.Items, 2)
+		assert.Equal(t, 1, found.Items[0].Position)
+		assert.Equal(t, &purchase.ID, found.Items[0].PurchaseID)
+		assert.Equal(t, "Model1, LensType1", found.Items[0].Description)
+		assert.Equal(t, database.Money{Amount: 35000, Currency: "PLN"}, found.Items[0].Total)
+		assert.Equal(t, database.Money{Amount: 6545, Currency: "PLN"}, found.Items[0].VAT)
+		assert.Equal(t, database.Money{Amount: 28455, Currency: "PLN"}, found.Items[0].Net)
+		assert.Equal(t, 2, found.Items[1].Position)
+		assert.Equal(t, &repair.ID, found.Items[1].RepairID)
+		assert.Equal(t, database.Money{Amount: 1870, Currency: "PLN"}, found.Items[1].VAT)
+	})
+
+	t.Run("test invoice purchase again", func(t *testing.T) {
+		err, _ := issue(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), database.NewPurchaseInvoiceItem(purchase))
+
+		assert.EqualError(
+			t,
+			err,
+			"purchase with ID '"+purchase.ID+"' is already invoiced on invoice 'FV/2024/0001'",
+		)
+	})
+
+	t.Run("test invoices are numbered without gaps within the year", func(t *testing.T) {
+		err, another := purchaseRepository.Create(ctx, &database.Customer{ID: customer.ID}, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		err, next := repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+
+		err, invoice := issue(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), database.NewPurchaseInvoiceItem(another))
+		assert.NoError(t, err)
+		assert.Equal(t, "FV/2024/0002", invoice.Number)
+
+		err, invoice = issue(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), database.NewRepairInvoiceItem(next, 8))
+		assert.NoError(t, err)
+		assert.Equal(t, "FV/2025/0001", invoice.Number)
+		assert.Equal(t, 2025, invoice.Year)
+		assert.Equal(t, 1, invoice.Sequence)
+	})
+
+	t.Run("test number of rolled back invoice is given to the next one", func(t *testing.T) {
+		err, another := repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+		workErr := errors.New("printer is out of paper")
+
+		err = (&DBUnitOfWork{db}).Do(ctx, func(repositories *Repositories) error {
+			err, invoice := repositories.Invoices.Create(ctx, &database.Invoice{
+				CustomerID: customer.ID,
+				IssuedAt:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+				Items:      []database.InvoiceItem{database.NewRepairInvoiceItem(another, 23)},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "FV/2025/0002", invoice.Number)
+			return workErr
+		})
+
+		assert.ErrorIs(t, err, workErr)
+		err, invoice := issue(time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC), database.NewRepairInvoiceItem(another, 23))
+		assert.NoError(t, err)
+		assert.Equal(t, "FV/2025/0002", invoice.Number)
+	})
+
+	t.Run("test invoice issued before the latest one of the year", func(t *testing.T) {
+		err, another := repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+
+		err, _ = issue(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), database.NewRepairInvoiceItem(another, 23))
+
+		var backdated *InvoiceBackdatedError
+		assert.ErrorAs(t, err, &backdated)
+		assert.EqualError(
+			t,
+			err,
+			"invoice cannot be issued on 2025-01-31, before the latest invoice of the year issued on 2025-02-02",
+		)
+	})
+
+	t.Run("test list invoices of customer", func(t *testing.T) {
+		err, invoices := invoiceRepository.GetAll(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		var numbers []string
+		for _, invoice := range invoices {
+			numbers = append(numbers, invoice.Number)
+		}
+		assert.Equal(t, []string{"FV/2025/0002", "FV/2025/0001", "FV/2024/0002", "FV/2024/0001"}, numbers)
+		assert.Len(t, invoices[3].Items, 2)
+	})
+
+	t.Run("test get invoice but not found", func(t *testing.T) {
+		err, _ := invoiceRepository.GetByID(ctx, "9f5bc5ac-e0e4-4c93-9c0b-64d3b1d1f7a6")
+
+		assert.EqualError(t, err, "invoice with ID '9f5bc5ac-e0e4-4c93-9c0b-64d3b1d1f7a6' does not exist")
+	})
+	clearRecords(t, db)
+}
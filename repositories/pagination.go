@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SortField orders listed records by the field, descending when Descending is set.
+type SortField struct {
+	Field      string `json:"f"`
+	Descending bool   `json:"d,omitempty"`
+}
+
+// Cursor points at the record a page of records starts after, or ends before when Backward is set.
+// It holds values of the record the page is sorted by, which includes the ID as the last field, so
+// the page can be found by comparing them instead of skipping preceding records. Cursor is valid
+// only for the sort order it was issued for.
+type Cursor struct {
+	Sort     []SortField `json:"s"`
+	Values   []string    `json:"v"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+// InvalidCursorError is returned when the cursor does not match the sort order of listed records.
+type InvalidCursorError struct{}
+
+func (i *InvalidCursorError) Error() string {
+	return "given cursor does not belong to this list, it was issued for another sort order"
+}
+
+// Page holds listed records along with cursors of the next and previous page, nil when there is no such page.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+// paginate lists a page of records of the query sorted by the fields, ties are broken by IDs so pages
+// of records do not overlap. The page follows the cursor when it is given, otherwise the offset is
+// skipped. Zero limit lists all records.
+func paginate[T any](
+	ctx context.Context,
+	query *gorm.DB,
+	sort []SortField,
+	cursor *Cursor,
+	offset int,
+	limit int,
+) (error, Page[T]) {
+	var page Page[T]
+	sort = append(slices.Clone(sort), SortField{Field: "id"})
+	var model T
+	statement := &gorm.Statement{DB: query}
+	if err := statement.Parse(&model); err != nil {
+		return err, page
+	}
+	fields := make([]*schema.Field, 0, len(sort))
+	for _, sortField := range sort {
+		field := statement.Schema.LookUpField(sortField.Field)
+		if field == nil {
+			return fmt.Errorf("records cannot be sorted by '%s'", sortField.Field), page
+		}
+		fields = append(fields, field)
+	}
+
+	backward := false
+	if cursor != nil {
+		if !slices.Equal(cursor.Sort, sort) || len(cursor.Values) != len(sort) {
+			return &InvalidCursorError{}, page
+		}
+		err, condition := keysetCondition(sort, fields, cursor)
+		if err != nil {
+			return err, page
+		}
+		query = query.Where(condition)
+		backward = cursor.Backward
+	} else {
+		query = query.Offset(offset)
+	}
+	var order clause.OrderBy
+	for _, sortField := range sort {
+		order.Columns = append(order.Columns, clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: sortField.Field},
+			Desc:   sortField.Descending != backward,
+		})
+	}
+	if limit > 0 {
+		query = query.Limit(limit + 1)
+	}
+	if err := query.Clauses(order).Find(&page.Items).Error; err != nil {
+		return err, page
+	}
+
+	hasMore := limit > 0 && len(page.Items) > limit
+	if hasMore {
+		page.Items = page.Items[:limit]
+	}
+	if backward {
+		for i, j := 0, len(page.Items)-1; i < j; i, j = i+1, j-1 {
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+	}
+	if len(page.Items) == 0 {
+		return nil, page
+	}
+	if (!backward && hasMore) || (backward && cursor != nil) {
+		page.Next = recordCursor(ctx, sort, fields, page.Items[len(page.Items)-1], false)
+	}
+	if (backward && hasMore) || (!backward && (cursor != nil || offset > 0)) {
+		page.Prev = recordCursor(ctx, sort, fields, page.Items[0], true)
+	}
+	return nil, page
+}
+
+// keysetCondition returns the condition of records following the cursor in the sort order. Records sorted
+// by fields a, b and c follow values x, y and z when a > x, or a = x and b > y, or a = x, b = y and c > z,
+// with the comparisons reversed for descending fields and for backward cursors.
+func keysetCondition(sort []SortField, fields []*schema.Field, cursor *Cursor) (error, clause.Expression) {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		err, value := parseCursorValue(field, cursor.Values[i])
+		if err != nil {
+			return err, nil
+		}
+		values[i] = value
+	}
+	var alternatives []clause.Expression
+	for i, sortField := range sort {
+		var conditions []clause.Expression
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, clause.Eq{Column: sortColumn(sort[j]), Value: values[j]})
+		}
+		if sortField.Descending != cursor.Backward {
+			conditions = append(conditions, clause.Lt{Column: sortColumn(sortField), Value: values[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: sortColumn(sortField), Value: values[i]})
+		}
+		alternatives = append(alternatives, clause.And(conditions...))
+	}
+	return nil, clause.Or(alternatives...)
+}
+
+func sortColumn(sortField SortField) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: sortField.Field}
+}
+
+// recordCursor returns the cursor pointing at the record.
+func recordCursor[T any](
+	ctx context.Context,
+	sort []SortField,
+	fields []*schema.Field,
+	record T,
+	backward bool,
+) *Cursor {
+	cursor := &Cursor{Sort: sort, Backward: backward}
+	for _, field := range fields {
+		value, _ := field.ValueOf(ctx, reflect.ValueOf(&record).Elem())
+		if date, ok := value.(time.Time); ok {
+			value = date.UTC().Format(time.RFC3339Nano)
+		}
+		cursor.Values = append(cursor.Values, fmt.Sprint(value))
+	}
+	return cursor
+}
+
+func parseCursorValue(field *schema.Field, value string) (error, any) {
+	if field.GORMDataType != schema.Time {
+		return nil, value
+	}
+	date, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return &InvalidCursorError{}, nil
+	}
+	return nil, date
+}
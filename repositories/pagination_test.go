@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	customerIDs := func(customers []database.Customer) []string {
+		var ids []string
+		for _, customer := range customers {
+			ids = append(ids, customer.ID)
+		}
+		return ids
+	}
+
+	clearRecords(t, db)
+	var customers []database.Customer
+	for i, lastName := range []string{"Doe", "Doe", "Doe", "Smith", "Smith"} {
+		err, customer := customerRepository.Create(ctx, &database.Customer{
+			FirstName:       "John",
+			LastName:        lastName,
+			TelephoneNumber: string(rune('1' + i)),
+			CreatedAt:       time.Date(2023, 1, 1+i%2, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+		customers = append(customers, *customer)
+	}
+	sort := []SortField{{Field: "last_name", Descending: true}, {Field: "created_at"}}
+	err, all, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort})
+	assert.NoError(t, err)
+	assert.Len(t, all.Items, 5)
+	assert.Nil(t, all.Next)
+	assert.Nil(t, all.Prev)
+
+	t.Run("test walk pages forward and back", func(t *testing.T) {
+		var pages [][]string
+		var cursor *Cursor
+		for {
+			err, page, total := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: cursor, Limit: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, 5, total)
+			assert.Equal(t, cursor == nil, page.Prev == nil)
+			pages = append(pages, customerIDs(page.Items))
+			if page.Next == nil {
+				break
+			}
+			cursor = page.Next
+		}
+		assert.Equal(t, [][]string{
+			customerIDs(all.Items[0:2]),
+			customerIDs(all.Items[2:4]),
+			customerIDs(all.Items[4:5]),
+		}, pages)
+
+		err, page, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: cursor, Limit: 2})
+		assert.NoError(t, err)
+		err, previous, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: page.Prev, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, customerIDs(all.Items[2:4]), customerIDs(previous.Items))
+		assert.NotNil(t, previous.Prev)
+		assert.NotNil(t, previous.Next)
+		err, first, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: previous.Prev, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, customerIDs(all.Items[0:2]), customerIDs(first.Items))
+		assert.Nil(t, first.Prev)
+	})
+
+	t.Run("test offset page returns cursors", func(t *testing.T) {
+		err, page, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Offset: 1, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, customerIDs(all.Items[1:3]), customerIDs(page.Items))
+
+		err, next, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: page.Next, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, customerIDs(all.Items[3:5]), customerIDs(next.Items))
+		err, previous, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Cursor: page.Prev, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, customerIDs(all.Items[0:1]), customerIDs(previous.Items))
+	})
+
+	t.Run("test cursor of another sort order", func(t *testing.T) {
+		err, page, _ := customerRepository.ListBy(ctx, CustomerFilter{Sort: sort, Limit: 2})
+		assert.NoError(t, err)
+
+		err, _, _ = customerRepository.ListBy(ctx, CustomerFilter{Cursor: page.Next, Limit: 2})
+
+		assert.Equal(t, &InvalidCursorError{}, err)
+	})
+
+	t.Run("test walk pages of purchases", func(t *testing.T) {
+		var purchaseIDs []string
+		for month := 1; month <= 3; month++ {
+			purchase := getPurchaseFixture(t)
+			purchase.PurchasedAt = time.Date(2023, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+			customer := customers[0]
+			err, purchase := purchaseRepository.Create(ctx, &customer, purchase)
+			assert.NoError(t, err)
+			purchaseIDs = append([]string{purchase.ID}, purchaseIDs...)
+		}
+
+		err, first := purchaseRepository.GetPage(ctx, customers[0].ID, nil, 2)
+		assert.NoError(t, err)
+		err, second := purchaseRepository.GetPage(ctx, customers[0].ID, first.Next, 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, purchaseIDs[0:2], []string{first.Items[0].ID, first.Items[1].ID})
+		assert.Equal(t, purchaseIDs[2:3], []string{second.Items[0].ID})
+		assert.Nil(t, second.Next)
+		assert.NotNil(t, second.Prev)
+	})
+	clearRecords(t, db)
+}
@@ -0,0 +1,264 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BalanceExceededError is returned when a payment exceeds the outstanding balance of the purchase or repair
+// it pays for, or when a refund exceeds the amount paid for it.
+type BalanceExceededError struct {
+	Entity    string
+	ID        string
+	Refund    bool
+	Available database.Money
+}
+
+func (b *BalanceExceededError) Error() string {
+	if b.Refund {
+		return fmt.Sprintf(
+			"refund exceeds %s %s paid for %s with ID '%s'",
+			b.Available.String(),
+			b.Available.Currency,
+			b.Entity,
+			b.ID,
+		)
+	}
+	return fmt.Sprintf(
+		"payment exceeds outstanding balance of %s %s of %s with ID '%s'",
+		b.Available.String(),
+		b.Available.Currency,
+		b.Entity,
+		b.ID,
+	)
+}
+
+// Balance compares the amount due with the amount paid, net of refunds, in a single currency.
+// The outstanding amount is negative when more was paid than is due.
+type Balance struct {
+	Due         database.Money `json:"due"`
+	Paid        database.Money `json:"paid"`
+	Outstanding database.Money `json:"outstanding"`
+}
+
+func newBalance(currency string, due int64, paid int64) Balance {
+	return Balance{
+		Due:         database.Money{Amount: due, Currency: currency},
+		Paid:        database.Money{Amount: paid, Currency: currency},
+		Outstanding: database.Money{Amount: due - paid, Currency: currency},
+	}
+}
+
+// ItemBalance is the balance of a single purchase or repair.
+type ItemBalance struct {
+	PurchaseID *string `json:"purchase_id,omitempty"`
+	RepairID   *string `json:"repair_id,omitempty"`
+	Balance
+}
+
+// CustomerBalance holds balances of purchases and repairs of the customer along with their totals,
+// one per currency the customer was charged in.
+type CustomerBalance struct {
+	CustomerID string        `json:"customer_id"`
+	Totals     []Balance     `json:"totals"`
+	Purchases  []ItemBalance `json:"purchases"`
+	Repairs    []ItemBalance `json:"repairs"`
+}
+
+// UnpaidBalance is a customer who has an outstanding balance, with totals in currencies they owe in.
+type UnpaidBalance struct {
+	Customer database.Customer `json:"customer"`
+	Totals   []Balance         `json:"totals"`
+}
+
+// ledgerRow is a sum of entries of the ledger, grouped by some of its columns.
+type ledgerRow struct {
+	CustomerID string
+	PurchaseID *string
+	RepairID   *string
+	Currency   string
+	Due        int64
+	Paid       int64
+}
+
+// ledgerEntries lists amounts due for purchases and repairs along with payments of them. Deleted purchases
+// and repairs are left out together with their payments, so are payments of ones which no longer exist,
+// while cancelled repairs are not due anymore.
+func ledgerEntries(db *gorm.DB) *gorm.DB {
+	return db.Raw(`
+SELECT purchases.customer_id, purchases.id AS purchase_id, NULL AS repair_id,
+	purchases.price_currency AS currency, purchases.price_amount - purchases.discount_amount AS due,
+	0 AS paid, purchases.purchased_at AS placed_at
+FROM purchases
+WHERE purchases.deleted_at IS NULL
+UNION ALL
+SELECT repairs.customer_id, NULL, repairs.id, repairs.cost_currency,
+	CASE WHEN repairs.status = ? THEN 0 ELSE repairs.cost_amount END, 0, repairs.reported_at
+FROM repairs
+WHERE repairs.deleted_at IS NULL
+UNION ALL
+SELECT payments.customer_id, payments.purchase_id, payments.repair_id, payments.amount_currency, 0,
+	CASE WHEN payments.refund THEN -payments.amount_amount ELSE payments.amount_amount END, NULL
+FROM payments
+LEFT JOIN purchases ON purchases.id = payments.purchase_id
+LEFT JOIN repairs ON repairs.id = payments.repair_id
+WHERE (payments.purchase_id IS NULL OR (purchases.id IS NOT NULL AND purchases.deleted_at IS NULL))
+	AND (payments.repair_id IS NULL OR (repairs.id IS NOT NULL AND repairs.deleted_at IS NULL))`,
+		database.RepairCancelled,
+	)
+}
+
+func ledger(db *gorm.DB) *gorm.DB {
+	return db.Table("(?) AS entries", ledgerEntries(db))
+}
+
+type DBPaymentRepository struct {
+	DB *gorm.DB
+}
+
+// Create records the payment of a purchase or a repair, in the currency it is due in. The payment cannot
+// exceed the outstanding balance of what it pays for, and a refund cannot exceed the amount paid for it.
+// The paid purchase or repair is locked until the payment is recorded, so that concurrent payments
+// cannot both be checked against the same balance.
+func (d *DBPaymentRepository) Create(ctx context.Context, payment *database.Payment) (error, *database.Payment) {
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var paid any = &database.Purchase{}
+		entity, id, query := "purchase", payment.PurchaseID, "purchase_id = ?"
+		if payment.RepairID != nil {
+			paid = &database.Repair{}
+			entity, id, query = "repair", payment.RepairID, "repair_id = ?"
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", *id).Find(paid).Error
+		if err != nil {
+			return err
+		}
+		var sum ledgerRow
+		err = ledger(tx).
+			Select("COALESCE(SUM(due), 0) AS due, COALESCE(SUM(paid), 0) AS paid").
+			Where(query, *id).
+			Where("currency = ?", payment.Amount.Currency).
+			Scan(&sum).Error
+		if err != nil {
+			return err
+		}
+		balance := newBalance(payment.Amount.Currency, sum.Due, sum.Paid)
+		available := balance.Outstanding
+		if payment.Refund {
+			available = balance.Paid
+		}
+		if payment.Amount.Amount > available.Amount {
+			return &BalanceExceededError{Entity: entity, ID: *id, Refund: payment.Refund, Available: available}
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, database.AuditPayment, payment.ID, nil, payment)
+	})
+	if err != nil {
+		return err, nil
+	}
+	return nil, payment
+}
+
+// GetAll returns payments of the customer, the latest paid first.
+func (d *DBPaymentRepository) GetAll(ctx context.Context, customerID string) (error, []database.Payment) {
+	var payments []database.Payment
+	result := d.DB.WithContext(ctx).Where("customer_id = ?", customerID).Order("paid_at desc").Find(&payments)
+	return result.Error, payments
+}
+
+// totals sums balances of ledger rows per currency, ordered by currency.
+func totals(rows []ledgerRow) []Balance {
+	sums := map[string]*ledgerRow{}
+	var currencies []string
+	for i := range rows {
+		sum, ok := sums[rows[i].Currency]
+		if !ok {
+			sum = &ledgerRow{Currency: rows[i].Currency}
+			sums[rows[i].Currency] = sum
+			currencies = append(currencies, rows[i].Currency)
+		}
+		sum.Due += rows[i].Due
+		sum.Paid += rows[i].Paid
+	}
+	sort.Strings(currencies)
+	balances := make([]Balance, 0, len(currencies))
+	for _, currency := range currencies {
+		balances = append(balances, newBalance(currency, sums[currency].Due, sums[currency].Paid))
+	}
+	return balances
+}
+
+// GetBalance returns balances of purchases and repairs of the customer, the latest purchased
+// or reported first.
+func (d *DBPaymentRepository) GetBalance(ctx context.Context, customerID string) (error, *CustomerBalance) {
+	var rows []ledgerRow
+	err := ledger(d.DB.WithContext(ctx)).
+		Select("purchase_id, repair_id, currency, SUM(due) AS due, SUM(paid) AS paid").
+		Where("customer_id = ?", customerID).
+		Group("purchase_id, repair_id, currency").
+		Order("MAX(placed_at) desc, purchase_id, repair_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err, nil
+	}
+	balance := &CustomerBalance{
+		CustomerID: customerID,
+		Totals:     totals(rows),
+		Purchases:  []ItemBalance{},
+		Repairs:    []ItemBalance{},
+	}
+	for _, row := range rows {
+		item := ItemBalance{
+			PurchaseID: row.PurchaseID,
+			RepairID:   row.RepairID,
+			Balance:    newBalance(row.Currency, row.Due, row.Paid),
+		}
+		if row.PurchaseID != nil {
+			balance.Purchases = append(balance.Purchases, item)
+		} else {
+			balance.Repairs = append(balance.Repairs, item)
+		}
+	}
+	return nil, balance
+}
+
+// ListUnpaid returns customers who have an outstanding balance in any currency, ordered by their names.
+// Only totals in currencies they owe in are given.
+func (d *DBPaymentRepository) ListUnpaid(ctx context.Context) (error, []UnpaidBalance) {
+	var rows []ledgerRow
+	err := ledger(d.DB.WithContext(ctx)).
+		Select("customer_id, currency, SUM(due) AS due, SUM(paid) AS paid").
+		Group("customer_id, currency").
+		Having("SUM(due) > SUM(paid)").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return err, []UnpaidBalance{}
+	}
+	rowsByCustomer := map[string][]ledgerRow{}
+	var customerIDs []string
+	for _, row := range rows {
+		if _, ok := rowsByCustomer[row.CustomerID]; !ok {
+			customerIDs = append(customerIDs, row.CustomerID)
+		}
+		rowsByCustomer[row.CustomerID] = append(rowsByCustomer[row.CustomerID], row)
+	}
+	var customers []database.Customer
+	err = d.DB.WithContext(ctx).
+		Where("id IN ?", customerIDs).
+		Order("last_name asc, first_name asc, id asc").
+		Find(&customers).Error
+	if err != nil {
+		return err, nil
+	}
+	unpaid := make([]UnpaidBalance, 0, len(customers))
+	for _, customer := range customers {
+		unpaid = append(unpaid, UnpaidBalance{Customer: customer, Totals: totals(rowsByCustomer[customer.ID])})
+	}
+	return nil, unpaid
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBPaymentRepository(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	repairRepository := DBRepairRepository{db}
+	paymentRepository := DBPaymentRepository{db}
+	pln := func(amount int64) database.Money {
+		return database.Money{Amount: amount, Currency: "PLN"}
+	}
+
+	clearRecords(t, db)
+	err, customer := customerRepository.Create(ctx, getCustomerFixture(t))
+	assert.NoError(t, err)
+	purchase := getPurchaseFixture(t)
+	purchase.Price = pln(120000)
+	purchase.Discount = pln(20000)
+	err, purchase = purchaseRepository.Create(ctx, &database.Customer{ID: customer.ID}, purchase)
+	assert.NoError(t, err)
+	repair := getRepairFixture(t)
+	repair.Cost = pln(5000)
+	err, repair = repairRepository.Create(ctx, &database.Customer{ID: customer.ID}, repair)
+	assert.NoError(t, err)
+	pay := func(customerID string, purchaseID *string, repairID *string, amount database.Money, refund bool) error {
+		err, _ := paymentRepository.Create(ctx, &database.Payment{
+			CustomerID: customerID,
+			PurchaseID: purchaseID,
+			RepairID:   repairID,
+			Method:     database.PaymentCard,
+			Amount:     amount,
+			Refund:     refund,
+			PaidAt:     time.Now(),
+		})
+		return err
+	}
+
+	t.Run("test balance of customer without payments", func(t *testing.T) {
+		err, balance := paymentRepository.GetBalance(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Balance{{Due: pln(105000), Paid: pln(0), Outstanding: pln(105000)}}, balance.Totals)
+		assert.Equal(t, []ItemBalance{{
+			PurchaseID: &purchase.ID,
+			Balance:    Balance{Due: pln(100000), Paid: pln(0), Outstanding: pln(100000)},
+		}}, balance.Purchases)
+		assert.Equal(t, []ItemBalance{{
+			RepairID: &repair.ID,
+			Balance:  Balance{Due: pln(5000), Paid: pln(0), Outstanding: pln(5000)},
+		}}, balance.Repairs)
+	})
+
+	t.Run("test deposit and refund", func(t *testing.T) {
+		assert.NoError(t, pay(customer.ID, &purchase.ID, nil, pln(30000), false))
+		assert.NoError(t, pay(customer.ID, &purchase.ID, nil, pln(10000), true))
+		assert.NoError(t, pay(customer.ID, nil, &repair.ID, pln(5000), false))
+
+		err, balance := paymentRepository.GetBalance(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Balance{{Due: pln(105000), Paid: pln(25000), Outstanding: pln(80000)}}, balance.Totals)
+		assert.Equal(t, Balance{Due: pln(100000), Paid: pln(20000), Outstanding: pln(80000)}, balance.Purchases[0].Balance)
+		assert.Equal(t, Balance{Due: pln(5000), Paid: pln(5000), Outstanding: pln(0)}, balance.Repairs[0].Balance)
+
+		err, payments := paymentRepository.GetAll(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Len(t, payments, 3)
+	})
+
+	t.Run("test payment exceeding outstanding balance", func(t *testing.T) {
+		err := pay(customer.ID, &purchase.ID, nil, pln(80001), false)
+
+		assert.EqualError(
+			t,
+			err,
+			"payment exceeds outstanding balance of 800.00 PLN of purchase with ID '"+purchase.ID+"'",
+		)
+		assert.IsType(t, &BalanceExceededError{}, err)
+	})
+
+	t.Run("test refund exceeding paid amount", func(t *testing.T) {
+		err := pay(customer.ID, nil, &repair.ID, pln(5001), true)
+
+		assert.EqualError(t, err, "refund exceeds 50.00 PLN paid for repair with ID '"+repair.ID+"'")
+	})
+
+	t.Run("test payment in other currency than due", func(t *testing.T) {
+		err := pay(customer.ID, &purchase.ID, nil, database.Money{Amount: 100, Currency: "EUR"}, false)
+
+		assert.EqualError(
+			t,
+			err,
+			"payment exceeds outstanding balance of 0.00 EUR of purchase with ID '"+purchase.ID+"'",
+		)
+	})
+
+	t.Run("test list customers with unpaid balances", func(t *testing.T) {
+		another := getCustomerFixture(t)
+		another.FirstName = "Adam"
+		another.TelephoneNumberE164 = nil
+		err, another := customerRepository.Create(ctx, another)
+		assert.NoError(t, err)
+		paidRepair := getRepairFixture(t)
+		paidRepair.Cost = pln(3000)
+		err, paidRepair = repairRepository.Create(ctx, &database.Customer{ID: another.ID}, paidRepair)
+		assert.NoError(t, err)
+		assert.NoError(t, pay(another.ID, nil, &paidRepair.ID, pln(3000), false))
+
+		err, unpaid := paymentRepository.ListUnpaid(ctx)
+
+		assert.NoError(t, err)
+		assert.Len(t, unpaid, 1)
+		assert.Equal(t, customer.ID, unpaid[0].Customer.ID)
+		assert.Equal(t, []Balance{{Due: pln(105000), Paid: pln(25000), Outstanding: pln(80000)}}, unpaid[0].Totals)
+
+		dueRepair := getRepairFixture(t)
+		dueRepair.Cost = pln(1000)
+		err, _ = repairRepository.Create(ctx, &database.Customer{ID: another.ID}, dueRepair)
+		assert.NoError(t, err)
+
+		err, unpaid = paymentRepository.ListUnpaid(ctx)
+
+		assert.NoError(t, err)
+		assert.Len(t, unpaid, 2)
+		assert.Equal(t, another.ID, unpaid[0].Customer.ID)
+		assert.Equal(t, []Balance{{Due: pln(4000), Paid: pln(3000), Outstanding: pln(1000)}}, unpaid[0].Totals)
+	})
+
+	t.Run("test cancelled repair and deleted purchase are not due", func(t *testing.T) {
+		err, _ := repairRepository.UpdateStatus(ctx, repair.ID, database.RepairCancelled, repair.Version)
+		assert.NoError(t, err)
+
+		err, balance := paymentRepository.GetBalance(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Balance{Due: pln(0), Paid: pln(5000), Outstanding: pln(-5000)}, balance.Repairs[0].Balance)
+		assert.NoError(t, pay(customer.ID, nil, &repair.ID, pln(5000), true))
+
+		assert.NoError(t, purchaseRepository.DeleteByID(ctx, purchase.ID, purchase.Version))
+
+		err, balance = paymentRepository.GetBalance(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Empty(t, balance.Purchases)
+		assert.Equal(t, []Balance{{Due: pln(0), Paid: pln(0), Outstanding: pln(0)}}, balance.Totals)
+	})
+
+	t.Run("test payments of removed purchase are not paid", func(t *testing.T) {
+		removed := getPurchaseFixture(t)
+		removed.Price = pln(30000)
+		err, removed := purchaseRepository.Create(ctx, &database.Customer{ID: customer.ID}, removed)
+		assert.NoError(t, err)
+		assert.NoError(t, pay(customer.ID, &removed.ID, nil, pln(30000), false))
+		assert.NoError(t, db.Unscoped().Delete(&database.Purchase{ID: removed.ID}).Error)
+
+		err, balance := paymentRepository.GetBalance(ctx, customer.ID)
+
+		assert.NoError(t, err)
+		assert.Empty(t, balance.Purchases)
+		assert.Equal(t, []Balance{{Due: pln(0), Paid: pln(0), Outstanding: pln(0)}}, balance.Totals)
+	})
+	clearRecords(t, db)
+}
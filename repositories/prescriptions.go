@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type PrescriptionNotFoundError struct {
+	PrescriptionID string
+}
+
+func (p *PrescriptionNotFoundError) Error() string {
+	return fmt.Sprintf("prescription with ID '%s' does not exist", p.PrescriptionID)
+}
+
+type DBPrescriptionRepository struct {
+	DB *gorm.DB
+}
+
+func (d *DBPrescriptionRepository) Create(
+	ctx context.Context,
+	customer *database.Customer,
+	prescription *database.Prescription,
+) (error, *database.Prescription) {
+	return d.DB.WithContext(ctx).Model(customer).Association("Prescriptions").Append(prescription), prescription
+}
+
+func (d *DBPrescriptionRepository) GetAll(ctx context.Context, customerID string) (error, []database.Prescription) {
+	var prescriptions []database.Prescription
+	result := d.DB.WithContext(ctx).Where("customer_id = ?", customerID).Order("issued_at desc").Find(&prescriptions)
+	return result.Error, prescriptions
+}
+
+func (d *DBPrescriptionRepository) GetByID(ctx context.Context, prescriptionID string) (error, *database.Prescription) {
+	var prescription database.Prescription
+	result := d.DB.WithContext(ctx).Where("id = ?", prescriptionID).First(&prescription)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &PrescriptionNotFoundError{PrescriptionID: prescriptionID}, nil
+	}
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &prescription
+}
+
+func (d *DBPrescriptionRepository) Update(
+	ctx context.Context,
+	prescription *database.Prescription,
+) (error, *database.Prescription) {
+	result := d.DB.WithContext(ctx).Model(prescription).
+		Select(append([]string{"IssuedBy", "IssuedAt", "ExpiresAt"}, prescriptionColumns()...)).
+		Updates(prescription)
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	// rows affected cannot tell a missing prescription apart from an unchanged one, so reloading it
+	// both returns all the stored fields and reports the missing prescription
+	return d.GetByID(ctx, prescription.ID)
+}
+
+// DeleteByID removes the prescription and detaches it from purchases which were made from it,
+// including soft deleted ones.
+func (d *DBPrescriptionRepository) DeleteByID(ctx context.Context, prescriptionID string) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var purchases []database.Purchase
+		if err := tx.Unscoped().Where("prescription_id = ?", prescriptionID).Find(&purchases).Error; err != nil {
+			return err
+		}
+		for _, purchase := range purchases {
+			detached := purchase
+			detached.PrescriptionID = nil
+			err := recordAudit(ctx, tx, database.AuditUpdate, database.AuditPurchase, purchase.ID, &purchase, &detached)
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Unscoped().Model(&database.Purchase{}).
+			Where("prescription_id = ?", prescriptionID).
+			Update("prescription_id", nil).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&database.Prescription{ID: prescriptionID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &PrescriptionNotFoundError{PrescriptionID: prescriptionID}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBPrescriptionRepository(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	prescriptionRepository := DBPrescriptionRepository{db}
+
+	clearRecords(t, db)
+
+	t.Run("test add prescription to a customer", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+
+		err, dbPrescription := prescriptionRepository.Create(ctx, dbCustomer, getPrescriptionFixture(t))
+
+		assert.NoError(t, err)
+		err, prescription := prescriptionRepository.GetByID(ctx, dbPrescription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, dbCustomer.ID, prescription.CustomerID)
+		assert.Equal(t, "Dr. Anna Nowak", prescription.IssuedBy)
+		assert.Equal(t, getLensPowerFixture(t), prescription.LensPower)
+		assert.Equal(t, database.PupillaryDistance{Right: 31, Left: 30.5}, prescription.PD)
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), prescription.ExpiresAt)
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test get prescriptions history latest first", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		olderPrescription := getPrescriptionFixture(t)
+		newerPrescription := getPrescriptionFixture(t)
+		newerPrescription.IssuedAt = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		err, _ = prescriptionRepository.Create(ctx, dbCustomer, olderPrescription)
+		assert.NoError(t, err)
+		err, _ = prescriptionRepository.Create(ctx, &database.Customer{ID: dbCustomer.ID}, newerPrescription)
+		assert.NoError(t, err)
+
+		err, prescriptions := prescriptionRepository.GetAll(ctx, dbCustomer.ID)
+
+		assert.NoError(t, err)
+		assert.Len(t, prescriptions, 2)
+		assert.Equal(t, newerPrescription.ID, prescriptions[0].ID)
+		assert.Equal(t, olderPrescription.ID, prescriptions[1].ID)
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test remove prescription keeps purchases made from it", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbPrescription := prescriptionRepository.Create(ctx, dbCustomer, getPrescriptionFixture(t))
+		assert.NoError(t, err)
+		purchase := getPurchaseFixture(t)
+		purchase.PrescriptionID = &dbPrescription.ID
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, purchase)
+		assert.NoError(t, err)
+
+		err = prescriptionRepository.DeleteByID(ctx, dbPrescription.ID)
+
+		assert.NoError(t, err)
+		err, _ = prescriptionRepository.GetByID(ctx, dbPrescription.ID)
+		assert.Equal(t, &PrescriptionNotFoundError{PrescriptionID: dbPrescription.ID}, err)
+		remainingPurchase := getPurchaseByID(dbPurchase.ID, t, db)
+		assert.NotNil(t, remainingPurchase)
+		assert.Nil(t, remainingPurchase.PrescriptionID)
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test update prescription details", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbPrescription := prescriptionRepository.Create(ctx, dbCustomer, getPrescriptionFixture(t))
+		assert.NoError(t, err)
+		updatedPrescription := getPrescriptionFixture(t)
+		updatedPrescription.ID = dbPrescription.ID
+		updatedPrescription.IssuedBy = "Dr. Jan Kowalski"
+
+		err, returnedPrescription := prescriptionRepository.Update(ctx, updatedPrescription)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Dr. Jan Kowalski", returnedPrescription.IssuedBy)
+		assert.Equal(t, dbCustomer.ID, returnedPrescription.CustomerID)
+		assert.False(t, returnedPrescription.CreatedAt.IsZero())
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test update prescription details but not found", func(t *testing.T) {
+		prescription := getPrescriptionFixture(t)
+		prescription.ID = "4a923682-1234-47c1-b37a-666544d71419"
+
+		err, returnedPrescription := prescriptionRepository.Update(ctx, prescription)
+
+		assert.Equal(t, &PrescriptionNotFoundError{PrescriptionID: "4a923682-1234-47c1-b37a-666544d71419"}, err)
+		assert.Nil(t, returnedPrescription)
+	})
+
+	t.Run("test remove prescription by ID but not found", func(t *testing.T) {
+		err := prescriptionRepository.DeleteByID(ctx, "4a923682-1234-47c1-b37a-666544d71419")
+
+		assert.Equal(t, &PrescriptionNotFoundError{PrescriptionID: "4a923682-1234-47c1-b37a-666544d71419"}, err)
+	})
+}
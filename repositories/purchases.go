@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
 	"errors"
 	"fmt"
@@ -16,40 +17,151 @@ func (p *PurchaseNotFoundError) Error() string {
 	return fmt.Sprintf("purchase with ID '%s' does not exist", p.PurchaseID)
 }
 
+func lensPowerColumns() []string {
+	var columns []string
+	for _, eye := range []string{"right", "left"} {
+		for _, field := range []string{"sphere", "cylinder", "axis", "add", "prism", "base"} {
+			columns = append(columns, fmt.Sprintf("lens_power_%s_%s", eye, field))
+		}
+	}
+	return columns
+}
+
+func pdColumns() []string {
+	return []string{"pd_binocular", "pd_right", "pd_left"}
+}
+
+func prescriptionColumns() []string {
+	return append(pdColumns(), lensPowerColumns()...)
+}
+
+var purchaseFields = updatableFields{
+	"FrameModel":     {"FrameModel"},
+	"LensType":       {"LensType"},
+	"LensPower":      lensPowerColumns(),
+	"PD":             pdColumns(),
+	"PrescriptionID": {"PrescriptionID"},
+	"PurchaseType":   {"PurchaseType"},
+	"PurchasedAt":    {"PurchasedAt"},
+	"Price":          moneyColumns("price"),
+	"Discount":       moneyColumns("discount"),
+	"VATRate":        {"VATRate"},
+}
+
 type DBPurchaseRepository struct {
 	DB *gorm.DB
 }
 
 func (d *DBPurchaseRepository) Create(
+	ctx context.Context,
 	customer *database.Customer,
 	purchase *database.Purchase,
 ) (error, *database.Purchase) {
-	return d.DB.Model(customer).Association("Purchases").Append(purchase), purchase
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(customer).Association("Purchases").Append(purchase); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, database.AuditPurchase, purchase.ID, nil, purchase)
+	})
+	return err, purchase
 }
 
-func (d *DBPurchaseRepository) GetAll(customerID string) (error, []database.Purchase) {
+func (d *DBPurchaseRepository) GetAll(ctx context.Context, customerID string) (error, []database.Purchase) {
 	var purchases []database.Purchase
-	result := d.DB.Where("customer_id = ?", customerID).Order("purchased_at desc").Find(&purchases)
+	result := withContext(ctx, d.DB).Where("customer_id = ?", customerID).Order("purchased_at desc").Find(&purchases)
 	return result.Error, purchases
 }
 
-func (d *DBPurchaseRepository) Update(purchase *database.Purchase) (error, *database.Purchase) {
-	result := d.DB.Model(purchase).
-		Select("FrameModel", "LensType", "LensPower", "PD", "PurchaseType", "PurchasedAt").
-		Updates(purchase)
+// GetPage returns the page of purchases of the customer following the cursor, the latest purchased first.
+func (d *DBPurchaseRepository) GetPage(
+	ctx context.Context,
+	customerID string,
+	cursor *Cursor,
+	limit int,
+) (error, Page[database.Purchase]) {
+	query := withContext(ctx, d.DB).Where("customer_id = ?", customerID)
+	sort := []SortField{{Field: "purchased_at", Descending: true}}
+	return paginate[database.Purchase](ctx, query, sort, cursor, 0, limit)
+}
+
+func (d *DBPurchaseRepository) GetByID(ctx context.Context, purchaseID string) (error, *database.Purchase) {
+	var purchase database.Purchase
+	result := withContext(ctx, d.DB).Where("id = ?", purchaseID).First(&purchase)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return &PurchaseNotFoundError{PurchaseID: purchase.ID}, nil
+		return &PurchaseNotFoundError{PurchaseID: purchaseID}, nil
 	}
-	return result.Error, purchase
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &purchase
 }
 
-func (d *DBPurchaseRepository) DeleteByID(purchaseID string) error {
-	result := d.DB.Delete(&database.Purchase{ID: purchaseID})
-	if result.Error != nil {
-		return result.Error
+// Update changes purchase details if the purchase still has the version set in given purchase.
+// When fields are given, only they are changed, otherwise all purchase details are.
+func (d *DBPurchaseRepository) Update(
+	ctx context.Context,
+	purchase *database.Purchase,
+	fields ...string,
+) (error, *database.Purchase) {
+	err, columns := purchaseFields.columns(fields)
+	if err != nil {
+		return err, nil
 	}
-	if result.RowsAffected == 0 {
-		return &PurchaseNotFoundError{PurchaseID: purchaseID}
+	err, updated := updateWithAudit(ctx, d.DB, database.AuditPurchase, purchase.ID,
+		func(tx *gorm.DB, _ *database.Purchase) error {
+			return updateVersion(tx, purchase.ID, purchase, &purchase.Version, columns...)
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &PurchaseNotFoundError{PurchaseID: purchase.ID}, nil
+	}
+	return err, updated
+}
+
+// DeleteByID soft deletes the purchase if it still has the given version.
+func (d *DBPurchaseRepository) DeleteByID(ctx context.Context, purchaseID string, version int) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var purchase database.Purchase
+		result := tx.Where("id = ?", purchaseID).First(&purchase)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &PurchaseNotFoundError{PurchaseID: purchaseID}
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("version = ?", version).Delete(&purchase)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &VersionConflictError{ID: purchaseID, Version: version}
+		}
+		return recordAudit(ctx, tx, database.AuditDelete, database.AuditPurchase, purchaseID, &purchase, nil)
+	})
+}
+
+// Restore brings back soft deleted purchase incrementing its version, restoring a purchase which is not deleted
+// is a no-op.
+func (d *DBPurchaseRepository) Restore(ctx context.Context, purchaseID string) (error, *database.Purchase) {
+	var purchase database.Purchase
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ?", purchaseID).First(&purchase)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &PurchaseNotFoundError{PurchaseID: purchaseID}
+		}
+		if result.Error != nil || !purchase.DeletedAt.Valid {
+			return result.Error
+		}
+		deleted := purchase
+		purchase.DeletedAt = gorm.DeletedAt{}
+		purchase.Version++
+		if err := tx.Unscoped().Model(&database.Purchase{ID: purchaseID}).Updates(restoredColumns).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditRestore, database.AuditPurchase, purchaseID, &deleted, &purchase)
+	})
+	if err != nil {
+		return err, nil
 	}
-	return nil
+	return nil, &purchase
 }
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 )
 
 func TestDBPurchaseRepository(t *testing.T) {
+	ctx := context.Background()
 	customerRepository := DBCustomerRepository{db}
 	purchaseRepository := DBPurchaseRepository{db}
 
@@ -17,14 +19,17 @@ func TestDBPurchaseRepository(t *testing.T) {
 	t.Run("test get all purchases for a customer", func(t *testing.T) {
 		customer := getCustomerFixture(t)
 		purchase1 := database.Purchase{FrameModel: "Model1", LensType: "LensType1",
-			LensPower: "LensPower1", PD: "CustomPD1", PurchasedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+			LensPower: getLensPowerFixture(t), PD: database.PupillaryDistance{Binocular: 62},
+			PurchasedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
 		purchase2 := database.Purchase{FrameModel: "Model2", LensType: "LensType2",
-			LensPower: "LensPower2", PD: "CustomPD2", PurchasedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+			LensPower:   database.LensPower{Right: database.EyePrescription{Sphere: 2.5}},
+			PD:          database.PupillaryDistance{Right: 31.5, Left: 30.5},
+			PurchasedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
 		customer.Purchases = []database.Purchase{purchase1, purchase2}
-		err, customer := customerRepository.Create(customer)
+		err, customer := customerRepository.Create(ctx, customer)
 		assert.NoError(t, err)
 
-		err, purchases := purchaseRepository.GetAll(customer.ID)
+		err, purchases := purchaseRepository.GetAll(ctx, customer.ID)
 
 		assert.NoError(t, err)
 		assert.Len(t, purchases, 2)
@@ -35,17 +40,17 @@ func TestDBPurchaseRepository(t *testing.T) {
 	})
 
 	t.Run("test add purchase to a customer", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(getCustomerFixture(t))
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
 		assert.NoError(t, err)
 
-		err, dbPurchase := purchaseRepository.Create(dbCustomer, getPurchaseFixture(t))
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, getPurchaseFixture(t))
 
 		assert.NoError(t, err)
 
 		assert.Equal(t, "Model1", dbPurchase.FrameModel)
 		assert.Equal(t, "LensType1", dbPurchase.LensType)
-		assert.Equal(t, "LensPower", dbPurchase.LensPower)
-		assert.Equal(t, "CustomPD", dbPurchase.PD)
+		assert.Equal(t, getLensPowerFixture(t), dbPurchase.LensPower)
+		assert.Equal(t, database.PupillaryDistance{Binocular: 62}, dbPurchase.PD)
 		assert.Equal(t, "CustomPurchaseType", dbPurchase.PurchaseType)
 		assert.Equal(t, dbCustomer.ID, dbPurchase.CustomerID)
 		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), dbPurchase.PurchasedAt)
@@ -54,37 +59,108 @@ func TestDBPurchaseRepository(t *testing.T) {
 	})
 
 	t.Run("test update purchase details", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(getCustomerFixture(t))
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
 		assert.NoError(t, err)
-		err, dbPurchase := purchaseRepository.Create(dbCustomer, getPurchaseFixture(t))
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, getPurchaseFixture(t))
 		assert.NoError(t, err)
 		updatedPurchase := &database.Purchase{
 			ID:           dbPurchase.ID,
 			CustomerID:   dbCustomer.ID,
 			FrameModel:   "UpdatedModel",
 			LensType:     "UpdatedLensType",
-			LensPower:    "UpdatedLensPower",
-			PD:           "UpdatedPD",
+			LensPower:    database.LensPower{Left: database.EyePrescription{Sphere: 0.75}},
+			PD:           database.PupillaryDistance{Right: 32, Left: 31},
 			PurchaseType: "UpdatedPurchaseType",
 			PurchasedAt:  time.Date(2000, 10, 20, 15, 0, 0, 0, time.UTC),
+			Version:      dbPurchase.Version,
 		}
 
-		err, updatedDbPurchase := purchaseRepository.Update(updatedPurchase)
+		err, updatedDbPurchase := purchaseRepository.Update(ctx, updatedPurchase)
 
-		err, dbPurchases := purchaseRepository.GetAll(dbCustomer.ID)
+		assert.NoError(t, err)
+		err, dbPurchases := purchaseRepository.GetAll(ctx, dbCustomer.ID)
 		assert.NoError(t, err)
 		assert.Len(t, dbPurchases, 1)
 		assertPurchase(t, &dbPurchases[0], updatedDbPurchase)
+		assert.False(t, updatedDbPurchase.CreatedAt.IsZero())
+		assert.True(t, dbPurchases[0].CreatedAt.Equal(updatedDbPurchase.CreatedAt))
+		clearRecords(t, db)
+	})
+
+	t.Run("test update only given purchase details", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		lensPower := database.LensPower{Left: database.EyePrescription{Sphere: 0.75}}
+
+		err, returnedPurchase := purchaseRepository.Update(
+			ctx,
+			&database.Purchase{ID: dbPurchase.ID, LensPower: lensPower, Version: dbPurchase.Version},
+			"LensPower",
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, dbCustomer.ID, returnedPurchase.CustomerID)
+		assert.Equal(t, dbPurchase.FrameModel, returnedPurchase.FrameModel)
+		updatedPurchase := getPurchaseByID(dbPurchase.ID, t, db)
+		assert.Equal(t, lensPower, updatedPurchase.LensPower)
+		assert.Equal(t, dbPurchase.PD, updatedPurchase.PD)
+		assert.Equal(t, dbPurchase.FrameModel, updatedPurchase.FrameModel)
+		clearRecords(t, db)
+	})
+
+	t.Run("test update purchase price", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		purchase := getPurchaseFixture(t)
+		purchase.Price = database.Money{Amount: 40000, Currency: "PLN"}
+		purchase.Discount = database.Money{Amount: 10000, Currency: "PLN"}
+		purchase.VATRate = 23
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, purchase)
+		assert.NoError(t, err)
+		assert.Equal(t, database.Money{Amount: 30000, Currency: "PLN"}, dbPurchase.Total)
+
+		err, returnedPurchase := purchaseRepository.Update(
+			ctx,
+			&database.Purchase{
+				ID:       dbPurchase.ID,
+				Price:    database.Money{Amount: 35000, Currency: "PLN"},
+				Discount: dbPurchase.Discount,
+				Version:  dbPurchase.Version,
+			},
+			"Price",
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, database.Money{Amount: 25000, Currency: "PLN"}, returnedPurchase.Total)
+		updatedPurchase := getPurchaseByID(dbPurchase.ID, t, db)
+		assert.Equal(t, database.Money{Amount: 35000, Currency: "PLN"}, updatedPurchase.Price)
+		assert.Equal(t, database.Money{Amount: 10000, Currency: "PLN"}, updatedPurchase.Discount)
+		assert.Equal(t, 23, updatedPurchase.VATRate)
+		assert.Equal(t, database.Money{Amount: 25000, Currency: "PLN"}, updatedPurchase.Total)
+		assert.Equal(t, database.Money{Amount: 4675, Currency: "PLN"}, updatedPurchase.VAT)
 		clearRecords(t, db)
 	})
 
+	t.Run("test update purchase details but not found", func(t *testing.T) {
+		purchase := getPurchaseFixture(t)
+		purchase.ID = "4a923682-1234-47c1-b37a-666544d71419"
+		purchase.Version = 1
+
+		err, returnedPurchase := purchaseRepository.Update(ctx, purchase)
+
+		assert.Equal(t, &PurchaseNotFoundError{PurchaseID: "4a923682-1234-47c1-b37a-666544d71419"}, err)
+		assert.Nil(t, returnedPurchase)
+	})
+
 	t.Run("test remove purchase by ID", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(getCustomerFixture(t))
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
 		assert.NoError(t, err)
-		err, dbPurchase := purchaseRepository.Create(dbCustomer, getPurchaseFixture(t))
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, getPurchaseFixture(t))
 		assert.NoError(t, err)
 
-		err = purchaseRepository.DeleteByID(dbPurchase.ID)
+		err = purchaseRepository.DeleteByID(ctx, dbPurchase.ID, dbPurchase.Version)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, len(getAllCustomers(t, db)))
@@ -92,8 +168,32 @@ func TestDBPurchaseRepository(t *testing.T) {
 		clearRecords(t, db)
 	})
 
+	t.Run("test restore removed purchase", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbPurchase := purchaseRepository.Create(ctx, dbCustomer, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, purchaseRepository.DeleteByID(ctx, dbPurchase.ID, dbPurchase.Version))
+
+		err, restoredPurchase := purchaseRepository.Restore(ctx, dbPurchase.ID)
+
+		assert.NoError(t, err)
+		assert.False(t, restoredPurchase.DeletedAt.Valid)
+		assert.Equal(t, dbPurchase.Version+1, restoredPurchase.Version)
+		assertPurchase(t, dbPurchase, getPurchaseByID(dbPurchase.ID, t, db))
+		assert.Equal(t, dbPurchase.Version+1, getPurchaseByID(dbPurchase.ID, t, db).Version)
+		clearRecords(t, db)
+	})
+
+	t.Run("test restore purchase but not found", func(t *testing.T) {
+		err, restoredPurchase := purchaseRepository.Restore(ctx, "4a923682-1234-47c1-b37a-666544d71419")
+
+		assert.Equal(t, err, &PurchaseNotFoundError{PurchaseID: "4a923682-1234-47c1-b37a-666544d71419"})
+		assert.Nil(t, restoredPurchase)
+	})
+
 	t.Run("test remove purchase by ID but not found", func(t *testing.T) {
-		err := purchaseRepository.DeleteByID("4a923682-1234-47c1-b37a-666544d71419")
+		err := purchaseRepository.DeleteByID(ctx, "4a923682-1234-47c1-b37a-666544d71419", 1)
 
 		assert.Equal(t, err, &PurchaseNotFoundError{PurchaseID: "4a923682-1234-47c1-b37a-666544d71419"})
 	})
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeResult holds numbers of records removed permanently by PurgeDeleted, along with the number of deleted
+// customers, purchases and repairs kept as they are invoiced.
+type PurgeResult struct {
+	Customers     int64
+	Purchases     int64
+	Repairs       int64
+	Prescriptions int64
+	Payments      int64
+	KeptInvoiced  int64
+}
+
+// PurgeDeleted permanently removes records soft deleted before the given time. Purchases, repairs and
+// prescriptions of purged customers are removed with them, regardless of when those were deleted, and so are
+// payments of everything purged. Invoices are never removed, so invoiced purchases and repairs, as well as
+// customers having invoices, are kept deleted instead of being purged.
+func PurgeDeleted(ctx context.Context, db *gorm.DB, before time.Time) (error, PurgeResult) {
+	var purged PurgeResult
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		invoicedCustomers := tx.Model(&database.Invoice{}).Select("customer_id")
+		invoicedPurchases := tx.Model(&database.InvoiceItem{}).Select("purchase_id").Where("purchase_id IS NOT NULL")
+		invoicedRepairs := tx.Model(&database.InvoiceItem{}).Select("repair_id").Where("repair_id IS NOT NULL")
+		purgedCustomers := tx.Model(&database.Customer{}).
+			Select("id").
+			Where("deleted_at < ? AND id NOT IN (?)", before, invoicedCustomers)
+		purgeable := "(deleted_at < ? OR customer_id IN (?)) AND id NOT IN (?)"
+
+		for _, kept := range []*gorm.DB{
+			tx.Model(&database.Customer{}).Where("deleted_at < ? AND id IN (?)", before, invoicedCustomers),
+			tx.Model(&database.Purchase{}).Where("deleted_at < ? AND id IN (?)", before, invoicedPurchases),
+			tx.Model(&database.Repair{}).Where("deleted_at < ? AND id IN (?)", before, invoicedRepairs),
+		} {
+			var count int64
+			if err := kept.Count(&count).Error; err != nil {
+				return err
+			}
+			purged.KeptInvoiced += count
+		}
+
+		// payments go first, as they are found by purchases and repairs about to be purged
+		result := tx.Where(
+			"customer_id IN (?) OR purchase_id IN (?) OR repair_id IN (?)",
+			purgedCustomers,
+			tx.Model(&database.Purchase{}).Select("id").Where(purgeable, before, purgedCustomers, invoicedPurchases),
+			tx.Model(&database.Repair{}).Select("id").Where(purgeable, before, purgedCustomers, invoicedRepairs),
+		).Delete(&database.Payment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged.Payments = result.RowsAffected
+
+		result = tx.Where(purgeable, before, purgedCustomers, invoicedPurchases).Delete(&database.Purchase{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged.Purchases = result.RowsAffected
+
+		result = tx.Where(purgeable, before, purgedCustomers, invoicedRepairs).Delete(&database.Repair{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged.Repairs = result.RowsAffected
+
+		result = tx.Where("customer_id IN (?)", purgedCustomers).Delete(&database.Prescription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged.Prescriptions = result.RowsAffected
+
+		result = tx.Where("deleted_at < ? AND id NOT IN (?)", before, invoicedCustomers).Delete(&database.Customer{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged.Customers = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return err, PurgeResult{}
+	}
+	return nil, purged
+}
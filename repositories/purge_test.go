@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	customerRepository := DBCustomerRepository{db}
+	purchaseRepository := DBPurchaseRepository{db}
+	repairRepository := DBRepairRepository{db}
+	prescriptionRepository := DBPrescriptionRepository{db}
+	paymentRepository := DBPaymentRepository{db}
+	invoiceRepository := DBInvoiceRepository{db}
+
+	clearRecords(t, db)
+
+	t.Run("test purge records deleted before retention window", func(t *testing.T) {
+		err, purgedCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, _ = prescriptionRepository.Create(ctx, purgedCustomer, getPrescriptionFixture(t))
+		assert.NoError(t, err)
+		err, _ = purchaseRepository.Create(ctx, &database.Customer{ID: purgedCustomer.ID}, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, purgedCustomer.ID, purgedCustomer.Version))
+
+		keptCustomer := getCustomerFixture(t)
+		keptTelephoneNumberE164 := "+48987654321"
+		keptCustomer.TelephoneNumber = "987654321"
+		keptCustomer.TelephoneNumberE164 = &keptTelephoneNumberE164
+		err, keptCustomer = customerRepository.Create(ctx, keptCustomer)
+		assert.NoError(t, err)
+		err, keptRepair := repairRepository.Create(ctx, &database.Customer{ID: keptCustomer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+		err, purgedRepair := repairRepository.Create(ctx, &database.Customer{ID: keptCustomer.ID}, getRepairFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, repairRepository.DeleteByID(ctx, purgedRepair.ID, purgedRepair.Version))
+
+		err, purged := PurgeDeleted(ctx, db, time.Now().Add(time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, PurgeResult{Customers: 1, Purchases: 1, Repairs: 1, Prescriptions: 1}, purged)
+		err, page, _ := customerRepository.ListBy(IncludeDeleted(ctx), CustomerFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, keptCustomer.ID, page.Items[0].ID)
+		err, dbRepairs := repairRepository.GetAll(IncludeDeleted(ctx), keptCustomer.ID)
+		assert.NoError(t, err)
+		assert.Len(t, dbRepairs, 1)
+		assert.Equal(t, keptRepair.ID, dbRepairs[0].ID)
+		clearRecords(t, db)
+	})
+
+	t.Run("test purge keeps records deleted within retention window", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, dbCustomer.ID, dbCustomer.Version))
+
+		err, purged := PurgeDeleted(ctx, db, time.Now().Add(-time.Hour))
+
+		assert.NoError(t, err)
+		assert.Equal(t, PurgeResult{}, purged)
+		err, _ = customerRepository.Restore(ctx, dbCustomer.ID)
+		assert.NoError(t, err)
+		clearRecords(t, db)
+	})
+	t.Run("test purge removes payments and keeps invoiced records", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		paid := getPurchaseFixture(t)
+		paid.Price = database.Money{Amount: 10000, Currency: "PLN"}
+		err, paid = purchaseRepository.Create(ctx, &database.Customer{ID: dbCustomer.ID}, paid)
+		assert.NoError(t, err)
+		err, _ = paymentRepository.Create(ctx, &database.Payment{
+			CustomerID: dbCustomer.ID,
+			PurchaseID: &paid.ID,
+			Method:     database.PaymentCash,
+			Amount:     paid.Price,
+			PaidAt:     time.Now(),
+		})
+		assert.NoError(t, err)
+		err, invoiced := purchaseRepository.Create(ctx, &database.Customer{ID: dbCustomer.ID}, getPurchaseFixture(t))
+		assert.NoError(t, err)
+		err, _ = invoiceRepository.Create(ctx, &database.Invoice{
+			CustomerID: dbCustomer.ID,
+			IssuedAt:   time.Now(),
+			Items:      []database.InvoiceItem{database.NewPurchaseInvoiceItem(invoiced)},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, customerRepository.DeleteByID(ctx, dbCustomer.ID, dbCustomer.Version))
+
+		err, purged := PurgeDeleted(ctx, db, time.Now().Add(time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, PurgeResult{Purchases: 1, Payments: 1, KeptInvoiced: 2}, purged)
+		err, dbPayments := paymentRepository.GetAll(ctx, dbCustomer.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, dbPayments)
+		err, dbPurchases := purchaseRepository.GetAll(IncludeDeleted(ctx), dbCustomer.ID)
+		assert.NoError(t, err)
+		assert.Len(t, dbPurchases, 1)
+		assert.Equal(t, invoiced.ID, dbPurchases[0].ID)
+		clearRecords(t, db)
+	})
+}
@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
+	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,23 +18,196 @@ func (r *RepairNotFoundError) Error() string {
 	return fmt.Sprintf("repair with ID '%s' does not exist", r.RepairID)
 }
 
+type InvalidRepairStatusTransitionError struct {
+	RepairID string
+	From     database.RepairStatus
+	To       database.RepairStatus
+}
+
+func (i *InvalidRepairStatusTransitionError) Error() string {
+	return fmt.Sprintf(
+		"repair with ID '%s' cannot change status from '%s' to '%s'",
+		i.RepairID,
+		i.From,
+		i.To,
+	)
+}
+
 type DBRepairRepository struct {
 	DB *gorm.DB
 }
 
-func (d *DBRepairRepository) GetAll(customerID string) (error, []database.Repair) {
+func (d *DBRepairRepository) GetAll(ctx context.Context, customerID string) (error, []database.Repair) {
+	var repairs []database.Repair
+	result := withContext(ctx, d.DB).Where("customer_id = ?", customerID).Order("created_at desc").Find(&repairs)
+	return result.Error, repairs
+}
+
+// GetPage returns the page of repairs of the customer following the cursor, the latest created first.
+func (d *DBRepairRepository) GetPage(
+	ctx context.Context,
+	customerID string,
+	cursor *Cursor,
+	limit int,
+) (error, Page[database.Repair]) {
+	query := withContext(ctx, d.DB).Where("customer_id = ?", customerID)
+	sort := []SortField{{Field: "created_at", Descending: true}}
+	return paginate[database.Repair](ctx, query, sort, cursor, 0, limit)
+}
+
+// ListByStatus returns repairs of all customers in given statuses, the longest waiting first.
+func (d *DBRepairRepository) ListByStatus(
+	ctx context.Context,
+	statuses []database.RepairStatus,
+) (error, []database.Repair) {
 	var repairs []database.Repair
-	result := d.DB.Where("customer_id = ?", customerID).Order("created_at desc").Find(&repairs)
+	result := withContext(ctx, d.DB).
+		Where("status IN ?", statuses).
+		Order("reported_at asc, created_at asc").
+		Find(&repairs)
 	return result.Error, repairs
 }
 
+func (d *DBRepairRepository) GetByID(ctx context.Context, repairID string) (error, *database.Repair) {
+	var repair database.Repair
+	result := withContext(ctx, d.DB).Where("id = ?", repairID).First(&repair)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &RepairNotFoundError{RepairID: repairID}, nil
+	}
+	if result.Error != nil {
+		return result.Error, nil
+	}
+	return nil, &repair
+}
+
 func (d *DBRepairRepository) Create(
+	ctx context.Context,
 	customer *database.Customer,
 	repair *database.Repair,
 ) (error, *database.Repair) {
-	return d.DB.Model(customer).Association("Repairs").Append(repair), repair
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(customer).Association("Repairs").Append(repair); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditCreate, database.AuditRepair, repair.ID, nil, repair)
+	})
+	return err, repair
+}
+
+var repairFields = updatableFields{
+	"Description": {"Description"},
+	"Cost":        moneyColumns("cost"),
+	"ReportedAt":  {"ReportedAt"},
+}
+
+// Update changes repair details if the repair still has the version set in given repair.
+// When fields are given, only they are changed, otherwise all repair details are. Status is changed
+// with UpdateStatus only.
+func (d *DBRepairRepository) Update(
+	ctx context.Context,
+	repair *database.Repair,
+	fields ...string,
+) (error, *database.Repair) {
+	err, columns := repairFields.columns(fields)
+	if err != nil {
+		return err, nil
+	}
+	err, updated := updateWithAudit(ctx, d.DB, database.AuditRepair, repair.ID,
+		func(tx *gorm.DB, _ *database.Repair) error {
+			return updateVersion(tx, repair.ID, repair, &repair.Version, columns...)
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &RepairNotFoundError{RepairID: repair.ID}, nil
+	}
+	return err, updated
+}
+
+// UpdateStatus moves repair to the given status if the transition is allowed. The update is conditional
+// on the given version, so concurrent transitions of the same repair cannot both succeed.
+func (d *DBRepairRepository) UpdateStatus(
+	ctx context.Context,
+	repairID string,
+	status database.RepairStatus,
+	version int,
+) (error, *database.Repair) {
+	err, repair := updateWithAudit(ctx, d.DB, database.AuditRepair, repairID,
+		func(tx *gorm.DB, current *database.Repair) error {
+			if current.Version != version {
+				return &VersionConflictError{ID: repairID, Version: version}
+			}
+			currentStatus := current.Status
+			if !currentStatus.CanTransitionTo(status) {
+				return &InvalidRepairStatusTransitionError{RepairID: repairID, From: currentStatus, To: status}
+			}
+			current.SetStatus(status, time.Now().UTC())
+			return updateVersion(
+				tx,
+				repairID,
+				current,
+				&current.Version,
+				"Status",
+				"InProgressAt",
+				"WaitingForPartsAt",
+				"ReadyForPickupAt",
+				"CollectedAt",
+				"CancelledAt",
+			)
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &RepairNotFoundError{RepairID: repairID}, nil
+	}
+	if err != nil {
+		return err, nil
+	}
+	return nil, repair
+}
+
+// DeleteByID soft deletes the repair if it still has the given version.
+func (d *DBRepairRepository) DeleteByID(ctx context.Context, repairID string, version int) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var repair database.Repair
+		result := tx.Where("id = ?", repairID).First(&repair)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &RepairNotFoundError{RepairID: repairID}
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("version = ?", version).Delete(&repair)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &VersionConflictError{ID: repairID, Version: version}
+		}
+		return recordAudit(ctx, tx, database.AuditDelete, database.AuditRepair, repairID, &repair, nil)
+	})
 }
 
-func (d *DBRepairRepository) DeleteByID(repairID string) error {
-	return d.DB.Delete(&database.Repair{ID: repairID}).Error
+// Restore brings back soft deleted repair incrementing its version, restoring a repair which is not deleted
+// is a no-op.
+func (d *DBRepairRepository) Restore(ctx context.Context, repairID string) (error, *database.Repair) {
+	var repair database.Repair
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ?", repairID).First(&repair)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return &RepairNotFoundError{RepairID: repairID}
+		}
+		if result.Error != nil || !repair.DeletedAt.Valid {
+			return result.Error
+		}
+		deleted := repair
+		repair.DeletedAt = gorm.DeletedAt{}
+		repair.Version++
+		if err := tx.Unscoped().Model(&database.Repair{ID: repairID}).Updates(restoredColumns).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, database.AuditRestore, database.AuditRepair, repairID, &deleted, &repair)
+	})
+	if err != nil {
+		return err, nil
+	}
+	return nil, &repair
 }
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"customer-manager/database"
 	"testing"
 	"time"
@@ -9,38 +10,39 @@ import (
 )
 
 func TestDBRepairRepository(t *testing.T) {
+	ctx := context.Background()
 	customerRepository := DBCustomerRepository{db}
 	repairRepository := DBRepairRepository{db}
 	customer := &database.Customer{FirstName: "John", LastName: "Doe", TelephoneNumber: "123456789"}
 	repair := &database.Repair{
 		Description: "some issue with the thing",
-		Cost:        12.32,
+		Cost:        database.Money{Amount: 1232, Currency: "PLN"},
 		ReportedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	t.Run("test add repair to a customer", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(customer)
+		err, dbCustomer := customerRepository.Create(ctx, customer)
 		assert.NoError(t, err)
 
-		err, dbRepair := repairRepository.Create(dbCustomer, repair)
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, repair)
 
 		assert.NoError(t, err)
 
 		assert.Equal(t, "some issue with the thing", dbRepair.Description)
-		assert.Equal(t, 12.32, dbRepair.Cost)
+		assert.Equal(t, database.Money{Amount: 1232, Currency: "PLN"}, dbRepair.Cost)
 		assert.Equal(t, dbCustomer.ID, dbRepair.CustomerID)
 
 		clearRecords(t, db)
 	})
 
 	t.Run("test remove repair by ID", func(t *testing.T) {
-		err, dbCustomer := customerRepository.Create(customer)
+		err, dbCustomer := customerRepository.Create(ctx, customer)
 		assert.NoError(t, err)
 
-		err, dbRepair := repairRepository.Create(dbCustomer, repair)
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, repair)
 		assert.NoError(t, err)
 
-		err = repairRepository.DeleteByID(dbRepair.ID)
+		err = repairRepository.DeleteByID(ctx, dbRepair.ID, dbRepair.Version)
 		assert.NoError(t, err)
 
 		assert.Equal(t, 1, len(getAllCustomers(t, db)))
@@ -48,4 +50,118 @@ func TestDBRepairRepository(t *testing.T) {
 
 		clearRecords(t, db)
 	})
+
+	t.Run("test restore removed repair", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, getRepairFixture(t))
+		assert.NoError(t, err)
+		assert.NoError(t, repairRepository.DeleteByID(ctx, dbRepair.ID, dbRepair.Version))
+		err, _ = repairRepository.GetByID(ctx, dbRepair.ID)
+		assert.Equal(t, err, &RepairNotFoundError{RepairID: dbRepair.ID})
+
+		err, restoredRepair := repairRepository.Restore(ctx, dbRepair.ID)
+
+		assert.NoError(t, err)
+		assert.False(t, restoredRepair.DeletedAt.Valid)
+		assert.Equal(t, dbRepair.Version+1, restoredRepair.Version)
+		assert.Equal(t, dbRepair.Version+1, getRepairByID(dbRepair.ID, t, db).Version)
+		clearRecords(t, db)
+	})
+
+	t.Run("test move repair through its lifecycle", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, getRepairFixture(t))
+		assert.NoError(t, err)
+		assert.Equal(t, database.RepairReported, dbRepair.Status)
+
+		for _, status := range []database.RepairStatus{
+			database.RepairInProgress,
+			database.RepairWaitingForParts,
+			database.RepairInProgress,
+			database.RepairReadyForPickup,
+			database.RepairCollected,
+		} {
+			err, dbRepair = repairRepository.UpdateStatus(ctx, dbRepair.ID, status, dbRepair.Version)
+			assert.NoError(t, err)
+		}
+
+		err, repair := repairRepository.GetByID(ctx, dbRepair.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, database.RepairCollected, repair.Status)
+		assert.NotNil(t, repair.InProgressAt)
+		assert.NotNil(t, repair.WaitingForPartsAt)
+		assert.NotNil(t, repair.ReadyForPickupAt)
+		assert.NotNil(t, repair.CollectedAt)
+		assert.Nil(t, repair.CancelledAt)
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test cannot change status of a repair changed in the meantime", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, getRepairFixture(t))
+		assert.NoError(t, err)
+		err, _ = repairRepository.UpdateStatus(ctx, dbRepair.ID, database.RepairInProgress, dbRepair.Version)
+		assert.NoError(t, err)
+
+		err, _ = repairRepository.UpdateStatus(ctx, dbRepair.ID, database.RepairCancelled, dbRepair.Version)
+
+		assert.Equal(t, &VersionConflictError{ID: dbRepair.ID, Version: 1}, err)
+		err, repair := repairRepository.GetByID(ctx, dbRepair.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, database.RepairInProgress, repair.Status)
+		assert.Equal(t, 2, repair.Version)
+		clearRecords(t, db)
+	})
+
+	t.Run("test cannot change status of a collected repair", func(t *testing.T) {
+		err, dbCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		repair := getRepairFixture(t)
+		repair.Status = database.RepairCollected
+		err, dbRepair := repairRepository.Create(ctx, dbCustomer, repair)
+		assert.NoError(t, err)
+
+		err, _ = repairRepository.UpdateStatus(ctx, dbRepair.ID, database.RepairInProgress, dbRepair.Version)
+
+		assert.Equal(t, &InvalidRepairStatusTransitionError{
+			RepairID: dbRepair.ID,
+			From:     database.RepairCollected,
+			To:       database.RepairInProgress,
+		}, err)
+
+		clearRecords(t, db)
+	})
+
+	t.Run("test list repairs by status across customers", func(t *testing.T) {
+		err, firstCustomer := customerRepository.Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+		err, secondCustomer := customerRepository.Create(
+			ctx,
+			&database.Customer{FirstName: "Jane", LastName: "Doe", TelephoneNumber: "987654321"},
+		)
+		assert.NoError(t, err)
+		err, firstRepair := repairRepository.Create(ctx, firstCustomer, getRepairFixture(t))
+		assert.NoError(t, err)
+		olderRepair := getRepairFixture(t)
+		olderRepair.ReportedAt = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+		err, secondRepair := repairRepository.Create(ctx, secondCustomer, olderRepair)
+		assert.NoError(t, err)
+		cancelledRepair := getRepairFixture(t)
+		cancelledRepair.Status = database.RepairCancelled
+		err, _ = repairRepository.Create(ctx, &database.Customer{ID: secondCustomer.ID}, cancelledRepair)
+		assert.NoError(t, err)
+
+		err, repairs := repairRepository.ListByStatus(ctx, database.OpenRepairStatuses)
+
+		assert.NoError(t, err)
+		assert.Len(t, repairs, 2)
+		assert.Equal(t, secondRepair.ID, repairs[0].ID)
+		assert.Equal(t, firstRepair.ID, repairs[1].ID)
+
+		clearRecords(t, db)
+	})
 }
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted returns context which makes repository reads return soft deleted records as well.
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+func isIncludingDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
+
+// withContext is a replacement of db.WithContext for read queries which honours IncludeDeleted.
+func withContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if isIncludingDeleted(ctx) {
+		return db.WithContext(ctx).Unscoped()
+	}
+	return db.WithContext(ctx)
+}
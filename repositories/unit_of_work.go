@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+
+	"gorm.io/gorm"
+)
+
+type DBUnitOfWork struct {
+	DB *gorm.DB
+}
+
+func (d *DBUnitOfWork) Do(ctx context.Context, work func(repositories *Repositories) error) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return work(&Repositories{
+			Customers:     &DBCustomerRepository{DB: tx},
+			Purchases:     &DBPurchaseRepository{DB: tx},
+			Repairs:       &DBRepairRepository{DB: tx},
+			Prescriptions: &DBPrescriptionRepository{DB: tx},
+			Invoices:      &DBInvoiceRepository{DB: tx},
+			Payments:      &DBPaymentRepository{DB: tx},
+			Frames:        &DBCatalogRepository[database.Frame]{DB: tx},
+			LensTypes:     &DBCatalogRepository[database.LensType]{DB: tx},
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"customer-manager/database"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	unitOfWork := DBUnitOfWork{db}
+
+	clearRecords(t, db)
+
+	t.Run("test commit customer with first purchase", func(t *testing.T) {
+		err := unitOfWork.Do(ctx, func(repositories *Repositories) error {
+			err, customer := repositories.Customers.Create(ctx, getCustomerFixture(t))
+			if err != nil {
+				return err
+			}
+			err, _ = repositories.Purchases.Create(ctx, customer, getPurchaseFixture(t))
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, getAllCustomers(t, db), 1)
+		assert.Len(t, getAllPurchases(t, db), 1)
+		clearRecords(t, db)
+	})
+
+	t.Run("test rollback all writes when work fails", func(t *testing.T) {
+		workErr := errors.New("something went wrong")
+
+		err := unitOfWork.Do(ctx, func(repositories *Repositories) error {
+			err, customer := repositories.Customers.Create(ctx, getCustomerFixture(t))
+			if err != nil {
+				return err
+			}
+			err, _ = repositories.Repairs.Create(ctx, customer, getRepairFixture(t))
+			if err != nil {
+				return err
+			}
+			return workErr
+		})
+
+		assert.ErrorIs(t, err, workErr)
+		assert.Empty(t, getAllCustomers(t, db))
+		assert.Empty(t, getAllRepairs(t, db))
+	})
+
+	t.Run("test rollback when repository fails", func(t *testing.T) {
+		err, existingCustomer := (&DBCustomerRepository{db}).Create(ctx, getCustomerFixture(t))
+		assert.NoError(t, err)
+
+		err = unitOfWork.Do(ctx, func(repositories *Repositories) error {
+			err, _ := repositories.Purchases.Create(
+				ctx,
+				&database.Customer{ID: existingCustomer.ID},
+				getPurchaseFixture(t),
+			)
+			if err != nil {
+				return err
+			}
+			err, _ = repositories.Customers.Create(ctx, getCustomerFixture(t))
+			return err
+		})
+
+		target := &DuplicatedTelephoneNumberError{}
+		assert.ErrorAs(t, err, &target)
+		assert.Len(t, getAllCustomers(t, db), 1)
+		assert.Empty(t, getAllPurchases(t, db))
+		clearRecords(t, db)
+	})
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// VersionConflictError is returned by conditional writes when the record has been changed since
+// the given version was read.
+type VersionConflictError struct {
+	ID      string
+	Version int
+}
+
+func (v *VersionConflictError) Error() string {
+	return fmt.Sprintf("record with ID '%s' has been changed since version %d was read", v.ID, v.Version)
+}
+
+// updateVersion updates selected columns of the model only if the record still has the version set
+// in the model, which is then incremented. When no row is updated, gorm.ErrRecordNotFound is returned
+// if the record does not exist and VersionConflictError if it has been changed in the meantime.
+func updateVersion(tx *gorm.DB, id string, model any, version *int, columns ...string) error {
+	expected := *version
+	*version = expected + 1
+	result := tx.Model(model).Where("version = ?", expected).Select(append(columns, "Version")).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		var count int64
+		result.Error = tx.Model(model).Where("id = ?", id).Count(&count).Error
+		if result.Error == nil && count == 0 {
+			result.Error = gorm.ErrRecordNotFound
+		} else if result.Error == nil {
+			result.Error = &VersionConflictError{ID: id, Version: expected}
+		}
+	}
+	if result.Error != nil {
+		*version = expected
+	}
+	return result.Error
+}
+
+// restoredColumns clear the deletion time of soft deleted records and increment their version, so ETags
+// read before the records were deleted no longer match them.
+var restoredColumns = map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}
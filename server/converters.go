@@ -1,16 +1,133 @@
 package server
 
 import (
-	"strconv"
+	"customer-manager/database"
+	"strings"
 	"time"
 )
 
-func convertToFloat(num string) float64 {
-	f, _ := strconv.ParseFloat(num, 64)
-	return f
+// convertToMoney returns money of the validated request, in the given currency unless the request has one.
+func convertToMoney(r MoneyRequest, currency string) database.Money {
+	if r.Amount == "" {
+		return database.Money{Currency: moneyCurrency(r, currency)}
+	}
+	money, _ := database.ParseMoney(r.Amount, moneyCurrency(r, currency))
+	return money
+}
+
+func convertToMoneyRequest(money database.Money) MoneyRequest {
+	return MoneyRequest{Amount: money.String(), Currency: money.Currency}
 }
 
 func convertToTime(date string) time.Time {
-	t, _ := time.Parse("2006-01-02", date)
+	t, _ := time.Parse(DateLayout, date)
 	return t
 }
+
+// convertToCustomer returns customer with details of the validated request and the telephone number normalized.
+func convertToCustomer(r *EditCustomerDetailsRequest, region string) *database.Customer {
+	normalized, display, _ := database.NormalizeTelephoneNumber(r.TelephoneNumber, region)
+	var birthDate *time.Time
+	if r.BirthDate != "" {
+		date := convertToTime(r.BirthDate)
+		birthDate = &date
+	}
+	return &database.Customer{
+		FirstName:           r.FirstName,
+		LastName:            r.LastName,
+		TelephoneNumber:     display,
+		TelephoneNumberE164: &normalized,
+		Email:               r.Email,
+		Address: database.Address{
+			Street:     r.Address.Street,
+			City:       r.Address.City,
+			PostalCode: r.Address.PostalCode,
+			Country:    strings.ToUpper(r.Address.Country),
+		},
+		BirthDate:        birthDate,
+		PreferredContact: database.ContactChannel(r.PreferredContact),
+		Notes:            r.Notes,
+	}
+}
+
+func convertToCustomerRequest(customer *database.Customer) *EditCustomerDetailsRequest {
+	var birthDate string
+	if customer.BirthDate != nil {
+		birthDate = customer.BirthDate.Format(DateLayout)
+	}
+	return &EditCustomerDetailsRequest{
+		FirstName:        customer.FirstName,
+		LastName:         customer.LastName,
+		TelephoneNumber:  customer.TelephoneNumber,
+		Email:            customer.Email,
+		Address:          AddressRequest(customer.Address),
+		BirthDate:        birthDate,
+		PreferredContact: string(customer.PreferredContact),
+		Notes:            customer.Notes,
+	}
+}
+
+func convertToEyePrescription(eye EyePrescriptionRequest) database.EyePrescription {
+	return database.EyePrescription{
+		Sphere:   eye.Sphere,
+		Cylinder: eye.Cylinder,
+		Axis:     eye.Axis,
+		Add:      eye.Add,
+		Prism:    eye.Prism,
+		Base:     eye.Base,
+	}
+}
+
+func convertToLensPower(lensPower LensPowerRequest) database.LensPower {
+	return database.LensPower{
+		Right: convertToEyePrescription(lensPower.Right),
+		Left:  convertToEyePrescription(lensPower.Left),
+	}
+}
+
+func convertToPupillaryDistance(pd PupillaryDistanceRequest) database.PupillaryDistance {
+	return database.PupillaryDistance{Binocular: pd.Binocular, Right: pd.Right, Left: pd.Left}
+}
+
+func convertToLensPowerRequest(lensPower database.LensPower) LensPowerRequest {
+	return LensPowerRequest{
+		Right: EyePrescriptionRequest(lensPower.Right),
+		Left:  EyePrescriptionRequest(lensPower.Left),
+	}
+}
+
+// convertToBarcode returns nil for a missing barcode, so products without one do not share it.
+func convertToBarcode(barcode string) *string {
+	if barcode == "" {
+		return nil
+	}
+	return &barcode
+}
+
+func convertToProduct(sku string, barcode string, supplier string, reorderThreshold int) database.Product {
+	return database.Product{
+		SKU:              sku,
+		Barcode:          convertToBarcode(barcode),
+		Supplier:         supplier,
+		ReorderThreshold: reorderThreshold,
+	}
+}
+
+func convertToFrame(r *EditFrameRequest) *database.Frame {
+	return &database.Frame{
+		Product: convertToProduct(r.SKU, r.Barcode, r.Supplier, r.ReorderThreshold),
+		Brand:   r.Brand,
+		Model:   r.Model,
+		Color:   r.Color,
+		Size:    r.Size,
+	}
+}
+
+func convertToLensType(r *EditLensTypeRequest) *database.LensType {
+	return &database.LensType{
+		Product:  convertToProduct(r.SKU, r.Barcode, r.Supplier, r.ReorderThreshold),
+		Name:     r.Name,
+		Material: r.Material,
+		Coating:  r.Coating,
+	}
+}
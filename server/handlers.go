@@ -1,58 +1,254 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"customer-manager/database"
 	"customer-manager/repositories"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
 )
 
-func genericListHandler[V []database.Purchase | []database.Repair](
-	getAll func(customerID string) (error, V),
+func genericListHandler[
+	T database.Purchase | database.Repair | database.Prescription | database.Invoice | database.Payment,
+](
+	getAll func(ctx context.Context, customerID string) (error, []T),
 ) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
-		err, items := getAll(customerID)
+		err, items := getAll(ctx.UserContext(), customerID)
 		if err != nil {
-			return fiber.ErrInternalServerError
+			return err
 		}
 		return ctx.Status(fiber.StatusOK).JSON(items)
 	}
 }
 
+// paginatedListHandler lists records of the customer a page at a time when cursor or limit is given,
+// the page is returned in the envelope with cursors of adjacent pages. Otherwise, all records are listed.
+func paginatedListHandler[T database.Purchase | database.Repair](
+	getAll func(ctx context.Context, customerID string) (error, []T),
+	getPage func(ctx context.Context, customerID string, cursor *repositories.Cursor, limit int) (
+		error,
+		repositories.Page[T],
+	),
+) fiber.Handler {
+	listAll := genericListHandler(getAll)
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Query("cursor") == "" && ctx.Query("limit") == "" {
+			return listAll(ctx)
+		}
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		err, cursor := queryCursor(ctx)
+		if err != nil {
+			return err
+		}
+		err, page := getPage(ctx.UserContext(), customerID, cursor, ctx.QueryInt("limit", 10))
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(pageResponse(page))
+	}
+}
+
+// resolvePurchasePrescription checks that prescription referenced by the purchase belongs to the customer.
+// Lens power and PD which were not given explicitly are taken from the prescription.
+func resolvePurchasePrescription(
+	ctx context.Context,
+	prescriptionsRepository repositories.PrescriptionRepository,
+	customerID string,
+	purchase *EditPurchaseRequest,
+) (error, *string) {
+	if purchase.PrescriptionID == "" {
+		return nil, nil
+	}
+	err, prescription := prescriptionsRepository.GetByID(ctx, purchase.PrescriptionID)
+	if err != nil {
+		return err, nil
+	}
+	if prescription.CustomerID != customerID {
+		return &repositories.PrescriptionNotFoundError{PrescriptionID: purchase.PrescriptionID}, nil
+	}
+	if purchase.LensPower == (LensPowerRequest{}) {
+		purchase.LensPower = convertToLensPowerRequest(prescription.LensPower)
+	}
+	if purchase.PD == (PupillaryDistanceRequest{}) {
+		purchase.PD = PupillaryDistanceRequest(prescription.PD)
+	}
+	return nil, &prescription.ID
+}
+
+// takePurchaseStock takes the frame and the lens type picked from the catalog for the purchase out of stock,
+// their descriptions are taken as the frame model and the lens type unless those are given. Items out of stock
+// are sold on backorder only when the request allows it, warnings about them are returned.
+func takePurchaseStock(
+	ctx context.Context,
+	tx *repositories.Repositories,
+	r *CreatePurchaseRequest,
+	purchase *database.Purchase,
+) (error, []string) {
+	var warnings []string
+	if r.FrameID != "" {
+		err, frame := tx.Frames.AdjustStock(ctx, r.FrameID, -1, r.Backorder)
+		if err != nil {
+			return err, nil
+		}
+		purchase.FrameID = &frame.ID
+		if purchase.FrameModel == "" {
+			purchase.FrameModel = frame.Description()
+		}
+		if frame.Stock < 0 {
+			warnings = append(warnings, fmt.Sprintf("frame with SKU '%s' is out of stock, it is backordered", frame.SKU))
+		}
+	}
+	if r.LensTypeID != "" {
+		err, lensType := tx.LensTypes.AdjustStock(ctx, r.LensTypeID, -1, r.Backorder)
+		if err != nil {
+			return err, nil
+		}
+		purchase.LensTypeID = &lensType.ID
+		if purchase.LensType == "" {
+			purchase.LensType = lensType.Description()
+		}
+		if lensType.Stock < 0 {
+			warnings = append(
+				warnings,
+				fmt.Sprintf("lens type with SKU '%s' is out of stock, it is backordered", lensType.SKU),
+			)
+		}
+	}
+	return nil, warnings
+}
+
+// adjustPurchaseStock adds the quantity to the stock of the frame and the lens type picked from the catalog
+// for the purchase, deleted purchases give their items back while restored ones take them again. Restored
+// purchases were sold already, so their items are taken even when out of stock.
+func adjustPurchaseStock(
+	ctx context.Context,
+	tx *repositories.Repositories,
+	purchase *database.Purchase,
+	quantity int,
+) error {
+	if purchase.FrameID != nil {
+		if err, _ := tx.Frames.AdjustStock(ctx, *purchase.FrameID, quantity, true); err != nil {
+			return err
+		}
+	}
+	if purchase.LensTypeID != nil {
+		if err, _ := tx.LensTypes.AdjustStock(ctx, *purchase.LensTypeID, quantity, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getCustomersHandler godoc
 //
 //	@Summary		Get list of customers
-//	@Description	Returns full list of existing customers
+//	@Description	Returns customers matching all given filters along with the number of all matching customers.
+//	@Description	Each word of the q search has to be found in the name, email or telephone number of the customer.
 //	@Tags			list-customers
-//	@Produce		json
-//	@Success		200			{array}	database.Customer	//		TODO	-	valid	response	body	is	{"data": []database.Customer, "total": int}
-//	@Param			firstName	query	string				false	"first name search"
-//	@Param			lastName	query	string				false	"last name search"
-//	@Param			limit		query	int					false	"list length"	default(10)
-//	@Param			offset		query	int					false	"list offset"	default(0)
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	server.CustomerPageResponse
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			q				query		string			false	"search by words found in name, email or telephone number"
+//	@Param			firstName		query		string			false	"first name search"
+//	@Param			lastName		query		string			false	"last name search"
+//	@Param			telephoneNumber	query		string			false	"telephone number search, in any format"
+//	@Param			email			query		string			false	"email search"
+//	@Param			createdFrom		query		string			false	"earliest creation date, YYYY-MM-DD"
+//	@Param			createdTo		query		string			false	"latest creation date, YYYY-MM-DD"
+//	@Param			hasOpenRepairs	query		bool			false	"customers with or without repairs in the workshop queue"
+//	@Param			purchasedSince	query		string			false	"customers with purchases since the date, YYYY-MM-DD"
+//	@Param			sort			query		string			false	"comma separated fields, prefixed with minus to sort descending"	example(last_name,-created_at)
+//	@Param			cursor			query		string			false	"next_cursor or prev_cursor of another page, used instead of offset"
+//	@Param			limit			query		int				false	"list length"				default(10)
+//	@Param			offset			query		int				false	"list offset"				default(0)
+//	@Param			include_deleted	query		bool			false	"include deleted customers"	default(false)
 //	@Router			/api/customers [get]
 func getCustomersHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		firstName := ctx.Query("firstName")
-		lastName := ctx.Query("lastName")
-		limit := ctx.QueryInt("limit", 10)
-		offset := ctx.QueryInt("offset", 0)
-		err, customers, total := server.customerRepository.ListBy(firstName, lastName, limit, offset)
+		filter := repositories.CustomerFilter{
+			Q:               ctx.Query("q"),
+			FirstName:       ctx.Query("firstName"),
+			LastName:        ctx.Query("lastName"),
+			TelephoneNumber: database.TelephoneNumberQuery(ctx.Query("telephoneNumber"), server.TelephoneRegion),
+			Email:           ctx.Query("email"),
+			Limit:           ctx.QueryInt("limit", 10),
+			Offset:          ctx.QueryInt("offset", 0),
+		}
+		var err error
+		if err, filter.CreatedFrom = queryDate(ctx, "createdFrom"); err != nil {
+			return err
+		}
+		err, createdTo := queryDate(ctx, "createdTo")
+		if err != nil {
+			return err
+		}
+		if !createdTo.IsZero() {
+			filter.CreatedBefore = createdTo.AddDate(0, 0, 1)
+		}
+		if err, filter.PurchasedSince = queryDate(ctx, "purchasedSince"); err != nil {
+			return err
+		}
+		if err, filter.HasOpenRepairs = queryOptionalBool(ctx, "hasOpenRepairs"); err != nil {
+			return err
+		}
+		if err, filter.Sort = parseSort(ctx.Query("sort"), repositories.CustomerSortFields); err != nil {
+			return err
+		}
+		if err, filter.Cursor = queryCursor(ctx); err != nil {
+			return err
+		}
+
+		err, page, total := server.customerRepository.ListBy(ctx.UserContext(), filter)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(CustomerPageResponse{
+			Data:       page.Items,
+			NextCursor: encodeCursor(page.Next),
+			PrevCursor: encodeCursor(page.Prev),
+			Total:      total,
+		})
+	}
+}
+
+// searchCustomersHandler godoc
+//
+//	@Summary		Search customers
+//	@Description	Finds customers by names despite typos and missing diacritics, e.g. "Wisniewsky" finds "Wiśniewski",
+//	@Description	words of digits are found in telephone numbers. Best matches come first, scored from 0 to 1,
+//	@Description	with highlights marking matched characters of first_name, last_name and telephone_number.
+//	@Tags			search-customers
+//	@Produce		json,application/problem+json
+//	@Success		200		{object}	server.CustomerSearchResponse
+//	@Failure		400		{object}	server.Problem	"invalid request"
+//	@Param			q		query		string			true	"searched names or telephone number digits"
+//	@Param			limit	query		int				false	"number of best matches"	default(10)
+//	@Router			/api/customers/search [get]
+func searchCustomersHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		query := strings.TrimSpace(ctx.Query("q"))
+		if query == "" {
+			return badRequest("search query q is required")
+		}
+		err, matches := server.customerRepository.Search(ctx.UserContext(), query, ctx.QueryInt("limit", 10))
 		if err != nil {
-			return fiber.ErrInternalServerError
+			return err
 		}
-		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"data": customers, "total": total})
+		return ctx.Status(fiber.StatusOK).JSON(CustomerSearchResponse{Data: matches})
 	}
 }
 
@@ -62,40 +258,27 @@ func getCustomersHandler(server *CustomerManagerServer) fiber.Handler {
 //	@Description	Create customer object
 //	@Tags			create-customer
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Success		201				{object}	database.Customer
-//	@Failure		400				{string}	string							"IMPLEMENTED BUT DOCS TODO"
+//	@Failure		400				{object}	server.Problem					"invalid request"
 //	@Param			customerDetails	body		server.CreateCustomerRequest	true	"Customer details"
 //	@Router			/api/customers [post]
 func createCustomerHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		newCustomer := new(CreateCustomerRequest)
-		err := ctx.BodyParser(newCustomer)
-		if err == fiber.ErrUnprocessableEntity {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"errors": err.Error(),
-			})
+		if err := parseBody(ctx, newCustomer); err != nil {
+			return err
 		}
 
-		validator := getValidator(newCustomer)
-		if !validator.Validate() {
-			return ctx.Status(fiber.StatusBadRequest).JSON(validator.Errors)
+		validationErrors := validateCustomerRequest(newCustomer, requestLanguage(ctx), server.TelephoneRegion)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
 		}
 
 		err, customer := server.customerRepository.Create(
-			&database.Customer{
-				FirstName:       newCustomer.FirstName,
-				LastName:        newCustomer.LastName,
-				TelephoneNumber: newCustomer.TelephoneNumber,
-			},
+			ctx.UserContext(),
+			convertToCustomer(newCustomer, server.TelephoneRegion),
 		)
-		target := &repositories.DuplicatedTelephoneNumberError{}
-		if errors.As(err, &target) {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": err.Error(),
-			})
-		}
-
 		if err != nil {
 			return err
 		}
@@ -108,27 +291,25 @@ func createCustomerHandler(server *CustomerManagerServer) fiber.Handler {
 //	@Summary		Get customer
 //	@Description	Returns customer details by ID
 //	@Tags			get-customer
-//	@Produce		json
-//	@Success		200			{object}	database.Customer
-//	@Failure		400			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		404			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Param			customerID	path		string	true	"Customer ID"
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Customer
+//	@Header			200				{string}	ETag			"version of the customer"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Param			customerID		path		string			true	"Customer ID"
+//	@Param			include_deleted	query		bool			false	"include deleted customers"	default(false)
 //	@Router			/api/customers/{customerID} [get]
 func getCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
-		_, customer := server.customerRepository.GetByID(customerID)
-		if customer == nil {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
-			})
+		err, customer := server.customerRepository.GetByID(ctx.UserContext(), customerID)
+		if err != nil {
+			return err
 		}
+		setETag(ctx, customer.Version)
 		return ctx.Status(fiber.StatusOK).JSON(customer)
 	}
 }
@@ -138,48 +319,128 @@ func getCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
 //	@Summary		Edit customer
 //	@Description	Edit customer details by ID
 //	@Tags			edit-customer
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Success		200				{object}	database.Customer
-//	@Failure		400				{string}	string								"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		404				{string}	string								"IMPLEMENTED BUT DOCS TODO"
+//	@Header			200				{string}	ETag								"version of the customer"
+//	@Failure		400				{object}	server.Problem						"invalid request"
+//	@Failure		404				{object}	server.Problem						"resource not found"
+//	@Failure		412				{object}	server.Problem						"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem						"If-Match header missing"
 //	@Param			customerID		path		string								true	"Customer ID"
+//	@Param			If-Match		header		string								true	"ETag of the customer"
 //	@Param			customerDetails	body		server.EditCustomerDetailsRequest	true	"New customer details"
 //	@Router			/api/customers/{customerID} [put]
 func editCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
 		newCustomerDetails := new(EditCustomerDetailsRequest)
-		err = ctx.BodyParser(newCustomerDetails)
-		if err == fiber.ErrUnprocessableEntity {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"errors": err.Error(),
-			})
+		if err := parseBody(ctx, newCustomerDetails); err != nil {
+			return err
 		}
-		validator := getValidator(newCustomerDetails)
-		if !validator.Validate() {
-			return ctx.Status(fiber.StatusBadRequest).JSON(validator.Errors)
+		validationErrors := validateCustomerRequest(newCustomerDetails, requestLanguage(ctx), server.TelephoneRegion)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
 		}
 
-		_, customer := server.customerRepository.Update(
-			&database.Customer{
-				ID:              customerID,
-				FirstName:       newCustomerDetails.FirstName,
-				LastName:        newCustomerDetails.LastName,
-				TelephoneNumber: newCustomerDetails.TelephoneNumber,
-			},
+		customer := convertToCustomer(newCustomerDetails, server.TelephoneRegion)
+		customer.ID = customerID
+		customer.Version = *version
+		err, customer = server.customerRepository.Update(ctx.UserContext(), customer)
+		if err != nil {
+			return err
+		}
+		setETag(ctx, customer.Version)
+		return ctx.Status(fiber.StatusOK).JSON(customer)
+	}
+}
+
+// customerPatchFields maps members of customer merge patch to customer fields.
+var customerPatchFields = map[string]string{
+	"first_name":        "FirstName",
+	"last_name":         "LastName",
+	"telephone_number":  "TelephoneNumber",
+	"email":             "Email",
+	"address":           "Address",
+	"birth_date":        "BirthDate",
+	"preferred_contact": "PreferredContact",
+	"notes":             "Notes",
+}
+
+// patchCustomerByIDHandler godoc
+//
+//	@Summary		Patch customer
+//	@Description	Changes only customer details given in the JSON merge patch (RFC 7396)
+//	@Tags			edit-customer
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Customer
+//	@Header			200				{string}	ETag								"version of the customer"
+//	@Failure		400				{object}	server.Problem						"invalid request"
+//	@Failure		404				{object}	server.Problem						"resource not found"
+//	@Failure		412				{object}	server.Problem						"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem						"If-Match header missing"
+//	@Param			customerID		path		string								true	"Customer ID"
+//	@Param			If-Match		header		string								true	"ETag of the customer"
+//	@Param			customerDetails	body		server.EditCustomerDetailsRequest	true	"Customer details to change"
+//	@Router			/api/customers/{customerID} [patch]
+func patchCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		err, customer := server.customerRepository.GetByID(ctx.UserContext(), customerID)
+		if err != nil {
+			return err
+		}
+
+		customerDetails := convertToCustomerRequest(customer)
+		err, fields := applyMergePatch(ctx.Body(), customerDetails)
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		validatedFields := fields
+		if slices.Contains(fields, "preferred_contact") {
+			// contact details required by the preferred contact channel are validated together with it
+			validatedFields = append(slices.Clone(fields), "email", "address")
+		}
+		validationErrors := patchedErrors(
+			validateCustomerRequest(customerDetails, requestLanguage(ctx), server.TelephoneRegion),
+			validatedFields,
 		)
-		if customer == nil {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
-			})
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+		patched := patchedFields(fields, customerPatchFields)
+		if len(patched) == 0 {
+			if customer.Version != *version {
+				err = &repositories.VersionConflictError{ID: customerID, Version: *version}
+			}
+		} else {
+			patchedCustomer := convertToCustomer(customerDetails, server.TelephoneRegion)
+			if !slices.Contains(patched, "TelephoneNumber") {
+				patchedCustomer.TelephoneNumber = customer.TelephoneNumber
+				patchedCustomer.TelephoneNumberE164 = customer.TelephoneNumberE164
+			}
+			patchedCustomer.ID = customerID
+			patchedCustomer.Version = *version
+			err, customer = server.customerRepository.Update(ctx.UserContext(), patchedCustomer, patched...)
+		}
+		if err != nil {
+			return err
 		}
-		// TODO during update the "created_at": "0001-01-01T00:00:00Z" is zeroed
+		setETag(ctx, customer.Version)
 		return ctx.Status(fiber.StatusOK).JSON(customer)
 	}
 }
@@ -189,145 +450,276 @@ func editCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
 //	@Summary		Delete customer
 //	@Description	Delete customer details and it's relations by ID
 //	@Tags			delete-customer
+//	@Produce		application/problem+json
 //	@Success		204
-//	@Failure		400			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		404			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Param			customerID	path		string	true	"Customer ID"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		412			{object}	server.Problem	"resource changed since its ETag was read"
+//	@Failure		428			{object}	server.Problem	"If-Match header missing"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Param			If-Match	header		string			true	"ETag of the customer"
 //	@Router			/api/customers/{customerID} [delete]
 func deleteCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
 		}
-		if err := server.customerRepository.DeleteByID(customerID); err != nil {
-			target := &repositories.CustomerNotFoundError{}
-			if errors.As(err, &target) {
-				return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
-				})
+		err = server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, purchases := tx.Purchases.GetAll(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
 			}
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"detail": err,
-			})
+			if err := tx.Customers.DeleteByID(ctx.UserContext(), customerID, *version); err != nil {
+				return err
+			}
+			for i := range purchases {
+				if err := adjustPurchaseStock(ctx.UserContext(), tx, &purchases[i], 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 		ctx.Status(fiber.StatusNoContent)
 		return nil
 	}
 }
 
+// restoreCustomerByIDHandler godoc
+//
+//	@Summary		Restore customer
+//	@Description	Restores deleted customer together with purchases and repairs deleted with them
+//	@Tags			restore-customer
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Customer
+//	@Header			200			{string}	ETag			"version of the customer"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Router			/api/customers/{customerID}/restore [post]
+func restoreCustomerByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		var customer *database.Customer
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			var err error
+			var customerNotFound *repositories.CustomerNotFoundError
+			err, customer = tx.Customers.GetByID(ctx.UserContext(), customerID)
+			if !errors.As(err, &customerNotFound) {
+				return err
+			}
+			if err, customer = tx.Customers.Restore(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			// purchases of a deleted customer are all deleted, so the listed ones were restored together with them
+			err, purchases := tx.Purchases.GetAll(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
+			}
+			for i := range purchases {
+				if err := adjustPurchaseStock(ctx.UserContext(), tx, &purchases[i], -1); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		setETag(ctx, customer.Version)
+		return ctx.Status(fiber.StatusOK).JSON(customer)
+	}
+}
+
 // getPurchasesHandler godoc
 //
 //	@Summary		Get list of purchases
-//	@Description	Returns full list of purchases for a specific customer by ID
+//	@Description	Returns full list of purchases for a specific customer by ID, the latest purchased first.
+//	@Description	When cursor or limit is given, a page of purchases is returned in {"data", "next_cursor", "prev_cursor"}.
 //	@Tags			get-customer-purchases
-//	@Produce		json
-//	@Success		200			{array}	database.Purchase
-//	@Param			customerID	path	string	true	"Customer ID"
+//	@Produce		json,application/problem+json
+//	@Success		200				{array}	database.Purchase
+//	@Param			customerID		path	string	true	"Customer ID"
+//	@Param			cursor			query	string	false	"next_cursor or prev_cursor of another page"
+//	@Param			limit			query	int		false	"page length"				default(10)
+//	@Param			include_deleted	query	bool	false	"include deleted purchases"	default(false)
 //	@Router			/api/customers/{customerID}/purchases [get]
 func getPurchasesHandler(server *CustomerManagerServer) fiber.Handler {
-	return genericListHandler[[]database.Purchase](server.purchasesRepository.GetAll)
+	return paginatedListHandler(server.purchasesRepository.GetAll, server.purchasesRepository.GetPage)
 }
 
 // createPurchaseHandler godoc
 //
 //	@Summary		Create a purchase for a customer
-//	@Description	Creates a new purchase for a customer by ID
+//	@Description	Creates a new purchase for a customer by ID. The frame and the lens type picked from the catalog
+//	@Description	are taken out of stock, items out of stock are sold only on backorder, with stock_warnings.
 //	@Tags			create-customer-purchase
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Success		200				{object}	database.Purchase
-//	@Failure		404				{string}	string							"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		400				{string}	string							"IMPLEMENTED BUT DOCS TODO"
+//	@Failure		404				{object}	server.Problem					"resource not found"
+//	@Failure		400				{object}	server.Problem					"invalid request"
+//	@Failure		409				{object}	server.Problem					"out of stock"
 //	@Param			customerID		path		string							true	"Customer ID"
 //	@Param			purchaseDetails	body		server.CreatePurchaseRequest	true	"Purchase details"
 //	@Router			/api/customers/{customerID}/purchases [post]
 func createPurchaseHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		newPurchase := new(CreatePurchaseRequest)
-		err := ctx.BodyParser(newPurchase)
-		if err == fiber.ErrUnprocessableEntity {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"errors": err.Error(),
-			})
+		if err := parseBody(ctx, newPurchase); err != nil {
+			return err
 		}
 
-		validator := getValidator(newPurchase)
-		if !validator.Validate() {
-			return ctx.Status(fiber.StatusBadRequest).JSON(validator.Errors)
+		validationErrors := validateNewPurchaseRequest(newPurchase, requestLanguage(ctx), server.Currency)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
 		}
 
 		customerID := ctx.Params("customerID")
-		_, err = uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
-		}
-		err, customer := server.customerRepository.GetByID(customerID)
-		if errors.Is(err, &repositories.CustomerNotFoundError{}) {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
-
-		err, purchase := server.purchasesRepository.Create(customer, &database.Purchase{
-			FrameModel:   newPurchase.FrameModel,
-			LensType:     newPurchase.LensType,
-			LensPower:    newPurchase.LensPower,
-			PD:           newPurchase.PD,
-			PurchaseType: newPurchase.PurchaseType,
-			PurchasedAt:  time.Time(newPurchase.PurchasedAt),
+		var purchase *database.Purchase
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, customer := tx.Customers.GetByID(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
+			}
+			err, prescriptionID := resolvePurchasePrescription(
+				ctx.UserContext(),
+				tx.Prescriptions,
+				customerID,
+				&newPurchase.EditPurchaseRequest,
+			)
+			if err != nil {
+				return err
+			}
+			purchase = &database.Purchase{
+				FrameModel:     newPurchase.FrameModel,
+				LensType:       newPurchase.LensType,
+				LensPower:      convertToLensPower(newPurchase.LensPower),
+				PD:             convertToPupillaryDistance(newPurchase.PD),
+				PrescriptionID: prescriptionID,
+				PurchaseType:   newPurchase.PurchaseType,
+				PurchasedAt:    convertToTime(newPurchase.PurchasedAt),
+				Price:          convertToMoney(newPurchase.Price, server.Currency),
+				Discount:       convertToMoney(newPurchase.Discount, server.Currency),
+				VATRate:        newPurchase.VATRate,
+			}
+			err, warnings := takePurchaseStock(ctx.UserContext(), tx, newPurchase, purchase)
+			if err != nil {
+				return err
+			}
+			err, purchase = tx.Purchases.Create(ctx.UserContext(), customer, purchase)
+			if err == nil {
+				purchase.StockWarnings = warnings
+			}
+			return err
 		})
+		prescriptionNotFound := &repositories.PrescriptionNotFoundError{}
+		productNotFound := &repositories.ProductNotFoundError{}
+		if errors.As(err, &prescriptionNotFound) || errors.As(err, &productNotFound) {
+			return badRequest(err.Error())
+		}
 		if err != nil {
-			// TODO: handle error
 			return err
 		}
 		return ctx.Status(fiber.StatusCreated).JSON(purchase)
 	}
 }
 
+// getPurchaseByIDHandler godoc
+//
+//	@Summary		Get a purchase
+//	@Description	Returns purchase of a customer by ID
+//	@Tags			get-customer-purchase
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Purchase
+//	@Header			200				{string}	ETag			"version of the purchase"
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			customerID		path		string			true	"Customer ID"
+//	@Param			purchaseID		path		string			true	"Purchase ID"
+//	@Param			include_deleted	query		bool			false	"include deleted purchases"	default(false)
+//	@Router			/api/customers/{customerID}/purchases/{purchaseID} [get]
+func getPurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		purchaseID := ctx.Params("purchaseID")
+		if err := validateID("purchase", purchaseID); err != nil {
+			return err
+		}
+		err, purchase := server.purchasesRepository.GetByID(ctx.UserContext(), purchaseID)
+		if err == nil && purchase.CustomerID != customerID {
+			err = &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
+		}
+		if err != nil {
+			return err
+		}
+		setETag(ctx, purchase.Version)
+		return ctx.Status(fiber.StatusOK).JSON(purchase)
+	}
+}
+
 // deletePurchaseByIDHandler godoc
 //
 //	@Summary		Delete a purchase
 //	@Description	Deletes a purchase by ID
 //	@Tags			delete-customer-purchase
+//	@Produce		application/problem+json
 //	@Success		204
-//	@Failure		404			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		400			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Param			customerID	path		string	true	"Customer ID"
-//	@Param			purchaseID	path		string	true	"Purchase ID"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Failure		412			{object}	server.Problem	"resource changed since its ETag was read"
+//	@Failure		428			{object}	server.Problem	"If-Match header missing"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Param			purchaseID	path		string			true	"Purchase ID"
+//	@Param			If-Match	header		string			true	"ETag of the purchase"
 //	@Router			/api/customers/{customerID}/purchases/{purchaseID} [delete]
 func deletePurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
 		purchaseID := ctx.Params("purchaseID")
-		_, err = uuid.Parse(purchaseID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given purchase id '%s' is not a valid UUID", purchaseID),
-			})
+		if err := validateID("purchase", purchaseID); err != nil {
+			return err
 		}
 
-		if err := server.purchasesRepository.DeleteByID(purchaseID); err != nil {
-			target := &repositories.PurchaseNotFoundError{}
-			if errors.As(err, &target) {
-				return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"detail": fmt.Sprintf("purchase with given id '%s' does not exists", purchaseID),
-				})
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+
+		err = server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, purchase := tx.Purchases.GetByID(ctx.UserContext(), purchaseID)
+			if err == nil && purchase.CustomerID != customerID {
+				err = &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
 			}
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"detail": err,
-			})
+			if err != nil {
+				return err
+			}
+			if err := tx.Purchases.DeleteByID(ctx.UserContext(), purchaseID, *version); err != nil {
+				return err
+			}
+			return adjustPurchaseStock(ctx.UserContext(), tx, purchase, 1)
+		})
+		if err != nil {
+			return err
 		}
 
 		ctx.Status(fiber.StatusNoContent)
@@ -335,95 +727,313 @@ func deletePurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	}
 }
 
+// restorePurchaseByIDHandler godoc
+//
+//	@Summary		Restore a purchase
+//	@Description	Restores deleted purchase of an existing customer by ID
+//	@Tags			restore-customer-purchase
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Purchase
+//	@Header			200			{string}	ETag			"version of the purchase"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Param			purchaseID	path		string			true	"Purchase ID"
+//	@Router			/api/customers/{customerID}/purchases/{purchaseID}/restore [post]
+func restorePurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		purchaseID := ctx.Params("purchaseID")
+		if err := validateID("purchase", purchaseID); err != nil {
+			return err
+		}
+
+		var purchase *database.Purchase
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if err, _ := tx.Customers.GetByID(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			var err error
+			var purchaseNotFound *repositories.PurchaseNotFoundError
+			err, purchase = tx.Purchases.GetByID(ctx.UserContext(), purchaseID)
+			deleted := errors.As(err, &purchaseNotFound)
+			if deleted {
+				err, purchase = tx.Purchases.Restore(ctx.UserContext(), purchaseID)
+			}
+			if err == nil && purchase.CustomerID != customerID {
+				return &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
+			}
+			if err != nil || !deleted {
+				return err
+			}
+			return adjustPurchaseStock(ctx.UserContext(), tx, purchase, -1)
+		})
+		if err != nil {
+			return err
+		}
+		setETag(ctx, purchase.Version)
+		return ctx.Status(fiber.StatusOK).JSON(purchase)
+	}
+}
+
 // editPurchaseByIDHandler godoc
 //
 //	@Summary		Update a purchase
 //	@Description	Updates a purchase for a customer by ID
 //	@Tags			update-customer-purchase
+//	@Accept			json
+//	@Produce		json,application/problem+json
 //	@Success		200				{object}	database.Purchase
-//	@Failure		404				{string}	string						"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		400				{string}	string						"IMPLEMENTED BUT DOCS TODO"
+//	@Header			200				{string}	ETag						"version of the purchase"
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		412				{object}	server.Problem				"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem				"If-Match header missing"
 //	@Param			customerID		path		string						true	"Customer ID"
 //	@Param			purchaseID		path		string						true	"Purchase ID"
+//	@Param			If-Match		header		string						true	"ETag of the purchase"
 //	@Param			customerDetails	body		server.EditPurchaseRequest	true	"New purchase details"
 //	@Router			/api/customers/{customerID}/purchases/{purchaseID} [put]
 func editPurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
-		}
-
-		_, customer := server.customerRepository.GetByID(customerID)
-		if customer == nil {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
 
 		purchaseID := ctx.Params("purchaseID")
-		_, err = uuid.Parse(purchaseID)
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given purchase id '%s' is not a valid UUID", purchaseID),
-			})
+		if err := validateID("purchase", purchaseID); err != nil {
+			return err
 		}
 
 		newPurchaseDetails := new(EditPurchaseRequest)
-		err = ctx.BodyParser(newPurchaseDetails)
-		if err == fiber.ErrUnprocessableEntity {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"errors": err.Error(),
-			})
-		}
-		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": err,
-			})
+		if err := parseBody(ctx, newPurchaseDetails); err != nil {
+			return err
 		}
 
-		validator := getValidator(newPurchaseDetails)
-		if !validator.Validate() {
-			fmt.Println(validator.Errors)
-			return ctx.Status(fiber.StatusBadRequest).JSON(validator.Errors)
+		validationErrors := validatePurchaseRequest(newPurchaseDetails, requestLanguage(ctx), server.Currency)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
 		}
 
-		_, purchase := server.purchasesRepository.Update(
-			&database.Purchase{
-				ID:           purchaseID,
-				FrameModel:   newPurchaseDetails.FrameModel,
-				LensType:     newPurchaseDetails.LensType,
-				LensPower:    newPurchaseDetails.LensPower,
-				PD:           newPurchaseDetails.PD,
-				CustomerID:   customerID,
-				PurchaseType: newPurchaseDetails.PurchaseType,
-				PurchasedAt:  time.Time(newPurchaseDetails.PurchasedAt),
-			},
-		)
-		if purchase == nil {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("purchase with given id '%s' does not exists", customerID),
-			})
+		var purchase *database.Purchase
+		err = server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if err, _ := tx.Customers.GetByID(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			err, existingPurchase := tx.Purchases.GetByID(ctx.UserContext(), purchaseID)
+			if err == nil && existingPurchase.CustomerID != customerID {
+				err = &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
+			}
+			if err != nil {
+				return err
+			}
+			err, prescriptionID := resolvePurchasePrescription(
+				ctx.UserContext(),
+				tx.Prescriptions,
+				customerID,
+				newPurchaseDetails,
+			)
+			if err != nil {
+				return err
+			}
+			err, purchase = tx.Purchases.Update(
+				ctx.UserContext(),
+				&database.Purchase{
+					ID:             purchaseID,
+					FrameModel:     newPurchaseDetails.FrameModel,
+					LensType:       newPurchaseDetails.LensType,
+					LensPower:      convertToLensPower(newPurchaseDetails.LensPower),
+					PD:             convertToPupillaryDistance(newPurchaseDetails.PD),
+					PrescriptionID: prescriptionID,
+					CustomerID:     customerID,
+					PurchaseType:   newPurchaseDetails.PurchaseType,
+					PurchasedAt:    convertToTime(newPurchaseDetails.PurchasedAt),
+					Price:          convertToMoney(newPurchaseDetails.Price, server.Currency),
+					Discount:       convertToMoney(newPurchaseDetails.Discount, server.Currency),
+					VATRate:        newPurchaseDetails.VATRate,
+					Version:        *version,
+				},
+			)
+			return err
+		})
+		prescriptionNotFound := &repositories.PrescriptionNotFoundError{}
+		if errors.As(err, &prescriptionNotFound) {
+			return badRequest(err.Error())
 		}
-		// TODO during update the "created_at": "0001-01-01T00:00:00Z" is zeroed
+		if err != nil {
+			return err
+		}
+		setETag(ctx, purchase.Version)
 		return ctx.Status(fiber.StatusOK).JSON(purchase)
 	}
 }
 
-// getRepairsHandler godoc
+// purchasePatchFields maps members of purchase merge patch to purchase fields.
+var purchasePatchFields = map[string]string{
+	"frame_model":     "FrameModel",
+	"lens_type":       "LensType",
+	"lens_power":      "LensPower",
+	"pd":              "PD",
+	"prescription_id": "PrescriptionID",
+	"purchase_type":   "PurchaseType",
+	"purchased_at":    "PurchasedAt",
+	"price":           "Price",
+	"discount":        "Discount",
+	"vat_rate":        "VATRate",
+}
+
+// patchPurchaseByIDHandler godoc
+//
+//	@Summary		Patch a purchase
+//	@Description	Changes only purchase details given in the JSON merge patch (RFC 7396).
+//	@Description	When another prescription is given without lens power or PD, they are taken from the prescription.
+//	@Tags			update-customer-purchase
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Purchase
+//	@Header			200				{string}	ETag						"version of the purchase"
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		412				{object}	server.Problem				"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem				"If-Match header missing"
+//	@Param			customerID		path		string						true	"Customer ID"
+//	@Param			purchaseID		path		string						true	"Purchase ID"
+//	@Param			If-Match		header		string						true	"ETag of the purchase"
+//	@Param			purchaseDetails	body		server.EditPurchaseRequest	true	"Purchase details to change"
+//	@Router			/api/customers/{customerID}/purchases/{purchaseID} [patch]
+func patchPurchaseByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		purchaseID := ctx.Params("purchaseID")
+		if err := validateID("purchase", purchaseID); err != nil {
+			return err
+		}
+		err, purchase := server.purchasesRepository.GetByID(ctx.UserContext(), purchaseID)
+		if err == nil && purchase.CustomerID != customerID {
+			err = &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
+		}
+		if err != nil {
+			return err
+		}
+
+		purchaseDetails := &EditPurchaseRequest{
+			FrameModel:   purchase.FrameModel,
+			LensType:     purchase.LensType,
+			LensPower:    convertToLensPowerRequest(purchase.LensPower),
+			PD:           PupillaryDistanceRequest(purchase.PD),
+			PurchaseType: purchase.PurchaseType,
+			PurchasedAt:  purchase.PurchasedAt.Format(DateLayout),
+			Price:        convertToMoneyRequest(purchase.Price),
+			Discount:     convertToMoneyRequest(purchase.Discount),
+			VATRate:      purchase.VATRate,
+		}
+		if purchase.PrescriptionID != nil {
+			purchaseDetails.PrescriptionID = *purchase.PrescriptionID
+		}
+		err, fields := applyMergePatch(ctx.Body(), purchaseDetails)
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		// lens power and PD not given together with another prescription are taken from it
+		if slices.Contains(fields, "prescription_id") && purchaseDetails.PrescriptionID != "" {
+			if !slices.Contains(fields, "lens_power") {
+				purchaseDetails.LensPower = LensPowerRequest{}
+				fields = append(fields, "lens_power")
+			}
+			if !slices.Contains(fields, "pd") {
+				purchaseDetails.PD = PupillaryDistanceRequest{}
+				fields = append(fields, "pd")
+			}
+		}
+		validatedFields := fields
+		if slices.Contains(fields, "price") {
+			// the discount cannot exceed the price, so it is validated together with it
+			validatedFields = append(slices.Clone(fields), "discount")
+		}
+		validationErrors := patchedErrors(
+			validatePurchaseRequest(purchaseDetails, requestLanguage(ctx), server.Currency),
+			validatedFields,
+		)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+		patched := patchedFields(fields, purchasePatchFields)
+		err = server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if err, _ := tx.Customers.GetByID(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			if len(patched) == 0 {
+				if purchase.Version != *version {
+					return &repositories.VersionConflictError{ID: purchaseID, Version: *version}
+				}
+				return nil
+			}
+			err, prescriptionID := resolvePurchasePrescription(
+				ctx.UserContext(),
+				tx.Prescriptions,
+				customerID,
+				purchaseDetails,
+			)
+			if err != nil {
+				return err
+			}
+			purchase.FrameModel = purchaseDetails.FrameModel
+			purchase.LensType = purchaseDetails.LensType
+			purchase.LensPower = convertToLensPower(purchaseDetails.LensPower)
+			purchase.PD = convertToPupillaryDistance(purchaseDetails.PD)
+			purchase.PrescriptionID = prescriptionID
+			purchase.PurchaseType = purchaseDetails.PurchaseType
+			purchase.PurchasedAt = convertToTime(purchaseDetails.PurchasedAt)
+			purchase.Price = convertToMoney(purchaseDetails.Price, server.Currency)
+			purchase.Discount = convertToMoney(purchaseDetails.Discount, server.Currency)
+			purchase.VATRate = purchaseDetails.VATRate
+			purchase.Version = *version
+			err, purchase = tx.Purchases.Update(ctx.UserContext(), purchase, patched...)
+			return err
+		})
+		prescriptionNotFound := &repositories.PrescriptionNotFoundError{}
+		if errors.As(err, &prescriptionNotFound) {
+			return badRequest(err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		setETag(ctx, purchase.Version)
+		return ctx.Status(fiber.StatusOK).JSON(purchase)
+	}
+}
+
+// getRepairsHandler godoc
 //
 //	@Summary		Get list of repairs
-//	@Description	Returns full list of repairs for a specific customer by ID
+//	@Description	Returns full list of repairs for a specific customer by ID, the latest created first.
+//	@Description	When cursor or limit is given, a page of repairs is returned in {"data", "next_cursor", "prev_cursor"}.
 //	@Tags			get-customer-repairs
-//	@Produce		json
-//	@Success		200			{array}	database.Repair
-//	@Param			customerID	path	string	true	"Customer ID"
+//	@Produce		json,application/problem+json
+//	@Success		200				{array}	database.Repair
+//	@Param			customerID		path	string	true	"Customer ID"
+//	@Param			cursor			query	string	false	"next_cursor or prev_cursor of another page"
+//	@Param			limit			query	int		false	"page length"				default(10)
+//	@Param			include_deleted	query	bool	false	"include deleted repairs"	default(false)
 //	@Router			/api/customers/{customerID}/repairs [get]
 func getRepairsHandler(server *CustomerManagerServer) fiber.Handler {
-	return genericListHandler[[]database.Repair](server.repairsRepository.GetAll)
+	return paginatedListHandler(server.repairsRepository.GetAll, server.repairsRepository.GetPage)
 }
 
 // createRepairHandler godoc
@@ -432,93 +1042,1262 @@ func getRepairsHandler(server *CustomerManagerServer) fiber.Handler {
 //	@Description	Creates a new repair for a customer by ID
 //	@Tags			create-customer-repair
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Success		200				{object}	database.Repair
-//	@Failure		404				{string}	string						"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		400				{string}	string						"IMPLEMENTED BUT DOCS TODO"
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
 //	@Param			customerID		path		string						true	"Customer ID"
 //	@Param			repairDetails	body		server.CreateRepairRequest	true	"Repair details"
 //	@Router			/api/customers/{customerID}/repairs [post]
 func createRepairHandler(server *CustomerManagerServer) fiber.Handler {
-	registerValidators()
 	return func(ctx *fiber.Ctx) error {
 		req := new(CreateRepairRequest)
-		if err := ctx.BodyParser(req); err != nil {
+		if err := parseBody(ctx, req); err != nil {
 			return err
 		}
 
-		if validationErrors := validateRequest(req); validationErrors != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(validationErrors)
+		validationErrors := validateRepairRequest(req, req.Cost, requestLanguage(ctx), server.Currency)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
 		}
 
 		customerID := ctx.Params("customerID")
-		if _, err := uuid.Parse(customerID); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
-		err, customer := server.customerRepository.GetByID(customerID)
-		if _, isErr := err.(*repositories.CustomerNotFoundError); isErr {
-			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"detail": fmt.Sprintf("customer with given id '%s' does not exists", customerID),
+		var repair *database.Repair
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, customer := tx.Customers.GetByID(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
+			}
+			err, repair = tx.Repairs.Create(ctx.UserContext(), customer, &database.Repair{
+				Description: req.Description,
+				Cost:        convertToMoney(req.Cost, server.Currency),
+				ReportedAt:  convertToTime(req.ReportedAt),
+				Status:      database.RepairReported,
 			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(repair)
+	}
+}
+
+// getRepairsQueueHandler godoc
+//
+//	@Summary		Get workshop queue
+//	@Description	Returns repairs of all customers filtered by status, the longest waiting first.
+//	@Description	When no status is given, all repairs which are not collected nor cancelled are returned.
+//	@Tags			list-repairs
+//	@Produce		json,application/problem+json
+//	@Success		200				{array}		database.Repair
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			status			query		string			false	"comma separated list of statuses"
+//	@Param			include_deleted	query		bool			false	"include deleted repairs"	default(false)
+//	@Router			/api/repairs [get]
+func getRepairsQueueHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		statuses := database.OpenRepairStatuses
+		if query := ctx.Query("status"); query != "" {
+			statuses = nil
+			for _, value := range strings.Split(query, ",") {
+				status := database.RepairStatus(strings.TrimSpace(value))
+				if !status.IsValid() {
+					return badRequest(fmt.Sprintf("given repair status '%s' is not valid", status))
+				}
+				statuses = append(statuses, status)
+			}
+		}
+		err, repairs := server.repairsRepository.ListByStatus(ctx.UserContext(), statuses)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(repairs)
+	}
+}
+
+// getRepairByIDHandler godoc
+//
+//	@Summary		Get a repair
+//	@Description	Returns repair of a customer by ID
+//	@Tags			get-customer-repair
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Repair
+//	@Header			200				{string}	ETag			"version of the repair"
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			customerID		path		string			true	"Customer ID"
+//	@Param			repairID		path		string			true	"Repair ID"
+//	@Param			include_deleted	query		bool			false	"include deleted repairs"	default(false)
+//	@Router			/api/customers/{customerID}/repairs/{repairID} [get]
+func getRepairByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, repair := getCustomerRepair(ctx, server)
+		if repair == nil {
+			return err
+		}
+		setETag(ctx, repair.Version)
+		return ctx.Status(fiber.StatusOK).JSON(repair)
+	}
+}
+
+// editRepairByIDHandler godoc
+//
+//	@Summary		Update a repair
+//	@Description	Updates repair details for a customer by ID
+//	@Tags			update-customer-repair
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Repair
+//	@Header			200				{string}	ETag						"version of the repair"
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		412				{object}	server.Problem				"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem				"If-Match header missing"
+//	@Param			customerID		path		string						true	"Customer ID"
+//	@Param			repairID		path		string						true	"Repair ID"
+//	@Param			If-Match		header		string						true	"ETag of the repair"
+//	@Param			repairDetails	body		server.EditRepairRequest	true	"New repair details"
+//	@Router			/api/customers/{customerID}/repairs/{repairID} [put]
+func editRepairByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, repair := getCustomerRepair(ctx, server)
+		if repair == nil {
+			return err
 		}
 
-		err, repair := server.repairsRepository.Create(customer, &database.Repair{
-			Description: req.Description,
-			Cost:        convertToFloat(req.Cost),
-			ReportedAt:  convertToTime(req.ReportedAt),
+		req := new(EditRepairRequest)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+		validationErrors := validateRepairRequest(req, req.Cost, requestLanguage(ctx), server.Currency)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+
+		repair.Description = req.Description
+		repair.Cost = convertToMoney(req.Cost, server.Currency)
+		repair.ReportedAt = convertToTime(req.ReportedAt)
+		repair.Version = *version
+		err, repair = server.repairsRepository.Update(ctx.UserContext(), repair)
+		if err != nil {
+			return err
+		}
+		setETag(ctx, repair.Version)
+		return ctx.Status(fiber.StatusOK).JSON(repair)
+	}
+}
+
+// repairPatchFields maps members of repair merge patch to repair fields, status is changed separately.
+var repairPatchFields = map[string]string{
+	"description": "Description",
+	"cost":        "Cost",
+	"reported_at": "ReportedAt",
+}
+
+// patchRepairByIDHandler godoc
+//
+//	@Summary		Patch a repair
+//	@Description	Changes only repair details given in the JSON merge patch (RFC 7396).
+//	@Description	Allowed status transitions are:
+//	@Description	reported -> in_progress, cancelled;
+//	@Description	in_progress -> waiting_for_parts, ready_for_pickup, cancelled;
+//	@Description	waiting_for_parts -> in_progress, cancelled;
+//	@Description	ready_for_pickup -> collected, in_progress, cancelled.
+//	@Tags			update-customer-repair
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Repair
+//	@Header			200				{string}	ETag						"version of the repair"
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		409				{object}	server.Problem				"status transition not allowed"
+//	@Failure		412				{object}	server.Problem				"resource changed since its ETag was read"
+//	@Failure		428				{object}	server.Problem				"If-Match header missing"
+//	@Param			customerID		path		string						true	"Customer ID"
+//	@Param			repairID		path		string						true	"Repair ID"
+//	@Param			If-Match		header		string						true	"ETag of the repair"
+//	@Param			repairDetails	body		server.PatchRepairRequest	true	"Repair details to change"
+//	@Router			/api/customers/{customerID}/repairs/{repairID} [patch]
+func patchRepairByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, repair := getCustomerRepair(ctx, server)
+		if repair == nil {
+			return err
+		}
+
+		repairDetails := &PatchRepairRequest{
+			EditRepairRequest: EditRepairRequest{
+				Description: repair.Description,
+				Cost:        convertToMoneyRequest(repair.Cost),
+				ReportedAt:  repair.ReportedAt.Format(DateLayout),
+			},
+			Status: string(repair.Status),
+		}
+		err, fields := applyMergePatch(ctx.Body(), repairDetails)
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		validationErrors := patchedErrors(
+			validateRepairRequest(repairDetails, repairDetails.Cost, requestLanguage(ctx), server.Currency),
+			fields,
+		)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+		patched := patchedFields(fields, repairPatchFields)
+		status := database.RepairStatus(repairDetails.Status)
+		err = server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if repair.Version != *version {
+				return &repositories.VersionConflictError{ID: repair.ID, Version: *version}
+			}
+			if len(patched) > 0 {
+				repair.Description = repairDetails.Description
+				repair.Cost = convertToMoney(repairDetails.Cost, server.Currency)
+				repair.ReportedAt = convertToTime(repairDetails.ReportedAt)
+				if err, repair = tx.Repairs.Update(ctx.UserContext(), repair, patched...); err != nil {
+					return err
+				}
+			}
+			if status != repair.Status {
+				err, repair = tx.Repairs.UpdateStatus(ctx.UserContext(), repair.ID, status, repair.Version)
+			}
+			return err
 		})
 		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err,
-			})
+			return err
 		}
-		return ctx.Status(fiber.StatusCreated).JSON(repair)
+		setETag(ctx, repair.Version)
+		return ctx.Status(fiber.StatusOK).JSON(repair)
 	}
 }
 
+// getCustomerRepair returns repair given in the path if it belongs to the customer given in the path.
+// Otherwise, the error to respond with is returned instead.
+func getCustomerRepair(ctx *fiber.Ctx, server *CustomerManagerServer) (error, *database.Repair) {
+	customerID := ctx.Params("customerID")
+	if err := validateID("customer", customerID); err != nil {
+		return err, nil
+	}
+	repairID := ctx.Params("repairID")
+	if err := validateID("repair", repairID); err != nil {
+		return err, nil
+	}
+	err, repair := server.repairsRepository.GetByID(ctx.UserContext(), repairID)
+	if err == nil && repair.CustomerID != customerID {
+		err = &repositories.RepairNotFoundError{RepairID: repairID}
+	}
+	if err != nil {
+		return err, nil
+	}
+	return nil, repair
+}
+
 // deleteRepairByIDHandler godoc
 //
 //	@Summary		Delete a repair
 //	@Description	Deletes a repair by ID
 //	@Tags			delete-customer-repair
+//	@Produce		application/problem+json
 //	@Success		204
-//	@Failure		404			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Failure		400			{string}	string	"IMPLEMENTED BUT DOCS TODO"
-//	@Param			customerID	path		string	true	"Customer ID"
-//	@Param			repairID	path		string	true	"Repair ID"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Failure		412			{object}	server.Problem	"resource changed since its ETag was read"
+//	@Failure		428			{object}	server.Problem	"If-Match header missing"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Param			repairID	path		string			true	"Repair ID"
+//	@Param			If-Match	header		string			true	"ETag of the repair"
 //	@Router			/api/customers/{customerID}/repairs/{repairID} [delete]
 func deleteRepairByIDHandler(server *CustomerManagerServer) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		customerID := ctx.Params("customerID")
-		_, err := uuid.Parse(customerID)
+		err, repair := getCustomerRepair(ctx, server)
 		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", customerID),
-			})
+			return err
+		}
+
+		err, version := ifMatchVersion(ctx)
+		if version == nil {
+			return err
+		}
+
+		if err := server.repairsRepository.DeleteByID(ctx.UserContext(), repair.ID, *version); err != nil {
+			return err
+		}
+
+		ctx.Status(fiber.StatusNoContent)
+		return nil
+	}
+}
+
+// restoreRepairByIDHandler godoc
+//
+//	@Summary		Restore a repair
+//	@Description	Restores deleted repair of an existing customer by ID
+//	@Tags			restore-customer-repair
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Repair
+//	@Header			200			{string}	ETag			"version of the repair"
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Param			repairID	path		string			true	"Repair ID"
+//	@Router			/api/customers/{customerID}/repairs/{repairID}/restore [post]
+func restoreRepairByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
 		}
 		repairID := ctx.Params("repairID")
-		_, err = uuid.Parse(repairID)
+		if err := validateID("repair", repairID); err != nil {
+			return err
+		}
+
+		var repair *database.Repair
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if err, _ := tx.Customers.GetByID(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			var err error
+			err, repair = tx.Repairs.Restore(ctx.UserContext(), repairID)
+			if err == nil && repair.CustomerID != customerID {
+				return &repositories.RepairNotFoundError{RepairID: repairID}
+			}
+			return err
+		})
 		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf("given repair id '%s' is not a valid UUID", repairID),
-			})
+			return err
 		}
+		setETag(ctx, repair.Version)
+		return ctx.Status(fiber.StatusOK).JSON(repair)
+	}
+}
 
-		if err := server.repairsRepository.DeleteByID(repairID); err != nil {
-			target := &repositories.RepairNotFoundError{}
-			if errors.As(err, &target) {
-				return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"detail": fmt.Sprintf("repair with given id '%s' does not exists", repairID),
-				})
+// getPrescriptionsHandler godoc
+//
+//	@Summary		Get list of prescriptions
+//	@Description	Returns prescriptions history for a specific customer by ID, latest first
+//	@Tags			get-customer-prescriptions
+//	@Produce		json,application/problem+json
+//	@Success		200			{array}	database.Prescription
+//	@Param			customerID	path	string	true	"Customer ID"
+//	@Router			/api/customers/{customerID}/prescriptions [get]
+func getPrescriptionsHandler(server *CustomerManagerServer) fiber.Handler {
+	return genericListHandler(server.prescriptionsRepository.GetAll)
+}
+
+// createPrescriptionHandler godoc
+//
+//	@Summary		Create a prescription for a customer
+//	@Description	Creates a new prescription for a customer by ID
+//	@Tags			create-customer-prescription
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		201					{object}	database.Prescription
+//	@Failure		404					{object}	server.Problem						"resource not found"
+//	@Failure		400					{object}	server.Problem						"invalid request"
+//	@Param			customerID			path		string								true	"Customer ID"
+//	@Param			prescriptionDetails	body		server.CreatePrescriptionRequest	true	"Prescription details"
+//	@Router			/api/customers/{customerID}/prescriptions [post]
+func createPrescriptionHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		newPrescription := new(CreatePrescriptionRequest)
+		if err := parseBody(ctx, newPrescription); err != nil {
+			return err
+		}
+
+		if validationErrors := validatePrescriptionRequest(newPrescription, requestLanguage(ctx)); validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		var prescription *database.Prescription
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, customer := tx.Customers.GetByID(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
 			}
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"detail": err,
+			err, prescription = tx.Prescriptions.Create(ctx.UserContext(), customer, &database.Prescription{
+				LensPower: convertToLensPower(newPrescription.LensPower),
+				PD:        convertToPupillaryDistance(newPrescription.PD),
+				IssuedBy:  newPrescription.IssuedBy,
+				IssuedAt:  convertToTime(newPrescription.IssuedAt),
+				ExpiresAt: convertToTime(newPrescription.ExpiresAt),
 			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(prescription)
+	}
+}
+
+// getPrescriptionByIDHandler godoc
+//
+//	@Summary		Get a prescription
+//	@Description	Returns prescription details by ID
+//	@Tags			get-customer-prescription
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Prescription
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			customerID		path		string			true	"Customer ID"
+//	@Param			prescriptionID	path		string			true	"Prescription ID"
+//	@Router			/api/customers/{customerID}/prescriptions/{prescriptionID} [get]
+func getPrescriptionByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		prescriptionID := ctx.Params("prescriptionID")
+		if err := validateID("prescription", prescriptionID); err != nil {
+			return err
+		}
+
+		err, prescription := server.prescriptionsRepository.GetByID(ctx.UserContext(), prescriptionID)
+		if err == nil && prescription.CustomerID != customerID {
+			err = &repositories.PrescriptionNotFoundError{PrescriptionID: prescriptionID}
+		}
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(prescription)
+	}
+}
+
+// editPrescriptionByIDHandler godoc
+//
+//	@Summary		Update a prescription
+//	@Description	Updates a prescription for a customer by ID
+//	@Tags			update-customer-prescription
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200					{object}	database.Prescription
+//	@Failure		404					{object}	server.Problem					"resource not found"
+//	@Failure		400					{object}	server.Problem					"invalid request"
+//	@Param			customerID			path		string							true	"Customer ID"
+//	@Param			prescriptionID		path		string							true	"Prescription ID"
+//	@Param			prescriptionDetails	body		server.EditPrescriptionRequest	true	"New prescription details"
+//	@Router			/api/customers/{customerID}/prescriptions/{prescriptionID} [put]
+func editPrescriptionByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		prescriptionID := ctx.Params("prescriptionID")
+		if err := validateID("prescription", prescriptionID); err != nil {
+			return err
+		}
+
+		newPrescriptionDetails := new(EditPrescriptionRequest)
+		if err := parseBody(ctx, newPrescriptionDetails); err != nil {
+			return err
+		}
+		validationErrors := validatePrescriptionRequest(newPrescriptionDetails, requestLanguage(ctx))
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		err, existingPrescription := server.prescriptionsRepository.GetByID(ctx.UserContext(), prescriptionID)
+		if err == nil && existingPrescription.CustomerID != customerID {
+			err = &repositories.PrescriptionNotFoundError{PrescriptionID: prescriptionID}
+		}
+		if err != nil {
+			return err
+		}
+
+		err, prescription := server.prescriptionsRepository.Update(
+			ctx.UserContext(),
+			&database.Prescription{
+				ID:         prescriptionID,
+				LensPower:  convertToLensPower(newPrescriptionDetails.LensPower),
+				PD:         convertToPupillaryDistance(newPrescriptionDetails.PD),
+				IssuedBy:   newPrescriptionDetails.IssuedBy,
+				IssuedAt:   convertToTime(newPrescriptionDetails.IssuedAt),
+				ExpiresAt:  convertToTime(newPrescriptionDetails.ExpiresAt),
+				CustomerID: customerID,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(prescription)
+	}
+}
+
+// deletePrescriptionByIDHandler godoc
+//
+//	@Summary		Delete a prescription
+//	@Description	Deletes a prescription by ID, purchases made from it are kept
+//	@Tags			delete-customer-prescription
+//	@Produce		application/problem+json
+//	@Success		204
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			customerID		path		string			true	"Customer ID"
+//	@Param			prescriptionID	path		string			true	"Prescription ID"
+//	@Router			/api/customers/{customerID}/prescriptions/{prescriptionID} [delete]
+func deletePrescriptionByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		prescriptionID := ctx.Params("prescriptionID")
+		if err := validateID("prescription", prescriptionID); err != nil {
+			return err
+		}
+
+		err, prescription := server.prescriptionsRepository.GetByID(ctx.UserContext(), prescriptionID)
+		if err == nil && prescription.CustomerID != customerID {
+			err = &repositories.PrescriptionNotFoundError{PrescriptionID: prescriptionID}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = server.prescriptionsRepository.DeleteByID(ctx.UserContext(), prescriptionID); err != nil {
+			return err
 		}
 
 		ctx.Status(fiber.StatusNoContent)
 		return nil
 	}
 }
+
+// getInvoicesHandler godoc
+//
+//	@Summary		Get list of invoices
+//	@Description	Returns invoices issued to a customer by ID, the latest issued first
+//	@Tags			get-customer-invoices
+//	@Produce		json,application/problem+json
+//	@Success		200			{array}		database.Invoice
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Router			/api/customers/{customerID}/invoices [get]
+func getInvoicesHandler(server *CustomerManagerServer) fiber.Handler {
+	return genericListHandler(server.invoicesRepository.GetAll)
+}
+
+// invoiceItems returns items invoicing purchases and repairs of the request, which have to belong
+// to the customer and be in the same currency. Cancelled repairs are not invoiced.
+func invoiceItems(
+	ctx context.Context,
+	tx *repositories.Repositories,
+	customerID string,
+	r *CreateInvoiceRequest,
+	language string,
+) (error, []database.InvoiceItem) {
+	var items []database.InvoiceItem
+	for _, purchaseID := range r.PurchaseIDs {
+		err, purchase := tx.Purchases.GetByID(ctx, purchaseID)
+		if err == nil && purchase.CustomerID != customerID {
+			err = &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
+		}
+		if err != nil {
+			return err, nil
+		}
+		items = append(items, database.NewPurchaseInvoiceItem(purchase))
+	}
+	for i, repairID := range r.RepairIDs {
+		err, repair := tx.Repairs.GetByID(ctx, repairID)
+		if err == nil && repair.CustomerID != customerID {
+			err = &repositories.RepairNotFoundError{RepairID: repairID}
+		}
+		if err != nil {
+			return err, nil
+		}
+		if repair.Status == database.RepairCancelled {
+			field := fmt.Sprintf("repair_ids.%d", i)
+			return validationProblem(map[string][]FieldError{
+				field: {{Code: "cancelledRepair", Message: validationMessage(language, "cancelledRepair", field)}},
+			}), nil
+		}
+		items = append(items, database.NewRepairInvoiceItem(repair, *r.RepairVATRate))
+	}
+	for _, item := range items {
+		if item.Total.Currency != items[0].Total.Currency {
+			return badRequest("invoiced purchases and repairs have to be in the same currency"), nil
+		}
+	}
+	return nil, items
+}
+
+// createInvoiceHandler godoc
+//
+//	@Summary		Issue an invoice to a customer
+//	@Description	Issues an invoice for purchases and repairs of a customer by ID, each of which can be invoiced once.
+//	@Description	Invoices are numbered with a sequence without gaps which starts over every year, e.g. FV/2024/0001.
+//	@Description	An invoice cannot be issued before the latest invoice of the year, cancelled repairs are not invoiced.
+//	@Tags			create-customer-invoice
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		201				{object}	database.Invoice
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		409				{object}	server.Problem				"already invoiced or issued before the latest invoice"
+//	@Param			customerID		path		string						true	"Customer ID"
+//	@Param			invoiceDetails	body		server.CreateInvoiceRequest	true	"Invoiced purchases and repairs"
+//	@Router			/api/customers/{customerID}/invoices [post]
+func createInvoiceHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		req := new(CreateInvoiceRequest)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+
+		if validationErrors := validateInvoiceRequest(req, requestLanguage(ctx)); validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		issuedAt := req.IssuedAt
+		if issuedAt == "" {
+			issuedAt = time.Now().Format(DateLayout)
+		}
+		var invoice *database.Invoice
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			err, customer := tx.Customers.GetByID(ctx.UserContext(), customerID)
+			if err != nil {
+				return err
+			}
+			err, items := invoiceItems(ctx.UserContext(), tx, customerID, req, requestLanguage(ctx))
+			if err != nil {
+				return err
+			}
+			err, invoice = tx.Invoices.Create(ctx.UserContext(), &database.Invoice{
+				CustomerID: customerID,
+				Seller:     server.Seller,
+				Buyer: database.InvoiceParty{
+					Name:    strings.TrimSpace(customer.FirstName + " " + customer.LastName),
+					TaxID:   req.BuyerTaxID,
+					Address: customer.Address,
+				},
+				IssuedAt: convertToTime(issuedAt),
+				Items:    items,
+			})
+			return err
+		})
+		purchaseNotFound := &repositories.PurchaseNotFoundError{}
+		repairNotFound := &repositories.RepairNotFoundError{}
+		if errors.As(err, &purchaseNotFound) || errors.As(err, &repairNotFound) {
+			return badRequest(err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(invoice)
+	}
+}
+
+// getInvoice returns invoice given in the path, or the error to respond with instead.
+func getInvoice(ctx *fiber.Ctx, server *CustomerManagerServer) (error, *database.Invoice) {
+	invoiceID := ctx.Params("invoiceID")
+	if err := validateID("invoice", invoiceID); err != nil {
+		return err, nil
+	}
+	return server.invoicesRepository.GetByID(ctx.UserContext(), invoiceID)
+}
+
+// getInvoiceByIDHandler godoc
+//
+//	@Summary		Get an invoice
+//	@Description	Returns invoice by ID along with its items
+//	@Tags			get-invoice
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Invoice
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			invoiceID	path		string			true	"Invoice ID"
+//	@Router			/api/invoices/{invoiceID} [get]
+func getInvoiceByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, invoice := getInvoice(ctx, server)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(invoice)
+	}
+}
+
+// getInvoicePDFHandler godoc
+//
+//	@Summary		Print an invoice
+//	@Description	Returns invoice by ID as an A4 PDF document, labelled in the language accepted by the client
+//	@Tags			get-invoice
+//	@Produce		application/pdf,application/problem+json
+//	@Success		200				{file}		file
+//	@Failure		404				{object}	server.Problem	"resource not found"
+//	@Failure		400				{object}	server.Problem	"invalid request"
+//	@Param			invoiceID		path		string			true	"Invoice ID"
+//	@Param			Accept-Language	header		string			false	"language of labels, en or pl"
+//	@Router			/api/invoices/{invoiceID}.pdf [get]
+func getInvoicePDFHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, invoice := getInvoice(ctx, server)
+		if err != nil {
+			return err
+		}
+		var document bytes.Buffer
+		if _, err := renderInvoice(invoice, requestLanguage(ctx)).WriteTo(&document); err != nil {
+			return err
+		}
+		ctx.Set(
+			fiber.HeaderContentDisposition,
+			fmt.Sprintf(`inline; filename="%s.pdf"`, strings.ReplaceAll(invoice.Number, "/", "-")),
+		)
+		ctx.Type("pdf")
+		return ctx.Status(fiber.StatusOK).Send(document.Bytes())
+	}
+}
+
+// getPaymentsHandler godoc
+//
+//	@Summary		Get list of payments
+//	@Description	Returns payments and refunds of a customer by ID, the latest paid first
+//	@Tags			get-customer-payments
+//	@Produce		json,application/problem+json
+//	@Success		200			{array}		database.Payment
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Router			/api/customers/{customerID}/payments [get]
+func getPaymentsHandler(server *CustomerManagerServer) fiber.Handler {
+	return genericListHandler(server.paymentsRepository.GetAll)
+}
+
+// paidItemCurrency returns the currency of the purchase or repair paid by the request, which has to belong
+// to the customer.
+func paidItemCurrency(
+	ctx context.Context,
+	tx *repositories.Repositories,
+	customerID string,
+	r *CreatePaymentRequest,
+) (error, string) {
+	if r.PurchaseID != "" {
+		err, purchase := tx.Purchases.GetByID(ctx, r.PurchaseID)
+		if err == nil && purchase.CustomerID != customerID {
+			err = &repositories.PurchaseNotFoundError{PurchaseID: r.PurchaseID}
+		}
+		if err != nil {
+			return err, ""
+		}
+		return nil, purchase.Price.Currency
+	}
+	err, repair := tx.Repairs.GetByID(ctx, r.RepairID)
+	if err == nil && repair.CustomerID != customerID {
+		err = &repositories.RepairNotFoundError{RepairID: r.RepairID}
+	}
+	if err != nil {
+		return err, ""
+	}
+	return nil, repair.Cost.Currency
+}
+
+// createPaymentHandler godoc
+//
+//	@Summary		Record a payment
+//	@Description	Records a payment or a refund of a purchase or a repair of a customer by ID, e.g. a deposit paid
+//	@Description	when glasses are ordered. Payments cannot exceed the outstanding balance of what they pay for,
+//	@Description	nor refunds the amount paid for it. Payments cannot be changed, a mistaken one is refunded instead.
+//	@Tags			create-customer-payment
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		201				{object}	database.Payment
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Failure		409				{object}	server.Problem				"balance exceeded"
+//	@Param			customerID		path		string						true	"Customer ID"
+//	@Param			paymentDetails	body		server.CreatePaymentRequest	true	"Payment details"
+//	@Router			/api/customers/{customerID}/payments [post]
+func createPaymentHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		req := new(CreatePaymentRequest)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+
+		validationErrors := validatePaymentRequest(req, requestLanguage(ctx), server.Currency)
+		if validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		paidAt := time.Now()
+		if req.PaidAt != "" {
+			paidAt, _ = time.Parse(time.RFC3339, req.PaidAt)
+		}
+		var payment *database.Payment
+		err := server.unitOfWork.Do(ctx.UserContext(), func(tx *repositories.Repositories) error {
+			if err, _ := tx.Customers.GetByID(ctx.UserContext(), customerID); err != nil {
+				return err
+			}
+			err, currency := paidItemCurrency(ctx.UserContext(), tx, customerID, req)
+			if err != nil {
+				return err
+			}
+			if moneyCurrency(req.Amount, currency) != currency {
+				return badRequest(fmt.Sprintf("payment has to be in %s, the currency of the paid purchase or repair", currency))
+			}
+			amount, err := database.ParseMoney(req.Amount.Amount, currency)
+			if err != nil {
+				return badRequest(err.Error())
+			}
+			newPayment := &database.Payment{
+				CustomerID: customerID,
+				Method:     database.PaymentMethod(req.Method),
+				Amount:     amount,
+				Refund:     req.Refund,
+				PaidAt:     paidAt,
+			}
+			if req.PurchaseID != "" {
+				newPayment.PurchaseID = &req.PurchaseID
+			} else {
+				newPayment.RepairID = &req.RepairID
+			}
+			err, payment = tx.Payments.Create(ctx.UserContext(), newPayment)
+			return err
+		})
+		purchaseNotFound := &repositories.PurchaseNotFoundError{}
+		repairNotFound := &repositories.RepairNotFoundError{}
+		if errors.As(err, &purchaseNotFound) || errors.As(err, &repairNotFound) {
+			return badRequest(err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(payment)
+	}
+}
+
+// getCustomerBalanceHandler godoc
+//
+//	@Summary		Get balance of a customer
+//	@Description	Returns amounts due, paid and outstanding for each purchase and repair of a customer by ID,
+//	@Description	along with their totals per currency. Deleted purchases and repairs are left out,
+//	@Description	cancelled repairs are not due. Negative outstanding amounts are owed to the customer.
+//	@Tags			get-customer-balance
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	repositories.CustomerBalance
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			customerID	path		string			true	"Customer ID"
+//	@Router			/api/customers/{customerID}/balance [get]
+func getCustomerBalanceHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		customerID := ctx.Params("customerID")
+		if err := validateID("customer", customerID); err != nil {
+			return err
+		}
+		if err, _ := server.customerRepository.GetByID(ctx.UserContext(), customerID); err != nil {
+			return err
+		}
+		err, balance := server.paymentsRepository.GetBalance(ctx.UserContext(), customerID)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(balance)
+	}
+}
+
+// getUnpaidBalancesHandler godoc
+//
+//	@Summary		Get customers with unpaid balances
+//	@Description	Returns customers who owe an outstanding amount for their purchases and repairs, ordered by names,
+//	@Description	with totals in currencies they owe in
+//	@Tags			list-unpaid-balances
+//	@Produce		json,application/problem+json
+//	@Success		200	{array}		repositories.UnpaidBalance
+//	@Failure		400	{object}	server.Problem	"invalid request"
+//	@Router			/api/customers/unpaid [get]
+func getUnpaidBalancesHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err, unpaid := server.paymentsRepository.ListUnpaid(ctx.UserContext())
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(unpaid)
+	}
+}
+
+// listProductsHandler lists products of the catalog having all words of the q query in their SKU or details,
+// or the product of the SKU or barcode given as the code query.
+func listProductsHandler[T repositories.CatalogProduct](catalog repositories.CatalogRepository[T]) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		filter := repositories.ProductFilter{Q: ctx.Query("q"), Code: ctx.Query("code")}
+		err, products := catalog.List(ctx.UserContext(), filter)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(products)
+	}
+}
+
+// createProductHandler validates the request and adds the product it is converted to to the catalog.
+func createProductHandler[T repositories.CatalogProduct, R any](
+	catalog repositories.CatalogRepository[T],
+	convert func(r *R) *T,
+) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		req := new(R)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+		if validationErrors := validateStruct(req, requestLanguage(ctx)); validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, product := catalog.Create(ctx.UserContext(), convert(req))
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(product)
+	}
+}
+
+func getProductByIDHandler[T repositories.CatalogProduct](
+	catalog repositories.CatalogRepository[T],
+	kind string,
+) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		productID := ctx.Params("productID")
+		if err := validateID(kind, productID); err != nil {
+			return err
+		}
+		err, product := catalog.GetByID(ctx.UserContext(), productID)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(product)
+	}
+}
+
+// editProductHandler validates the request and changes details of the product to the ones it is converted to.
+func editProductHandler[T repositories.CatalogProduct, R any](
+	catalog repositories.CatalogRepository[T],
+	kind string,
+	convert func(r *R, productID string) *T,
+) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		productID := ctx.Params("productID")
+		if err := validateID(kind, productID); err != nil {
+			return err
+		}
+		req := new(R)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+		if validationErrors := validateStruct(req, requestLanguage(ctx)); validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, product := catalog.Update(ctx.UserContext(), convert(req, productID))
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(product)
+	}
+}
+
+func adjustStockHandler[T repositories.CatalogProduct](
+	catalog repositories.CatalogRepository[T],
+	kind string,
+) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		productID := ctx.Params("productID")
+		if err := validateID(kind, productID); err != nil {
+			return err
+		}
+		req := new(StockAdjustmentRequest)
+		if err := parseBody(ctx, req); err != nil {
+			return err
+		}
+		if validationErrors := validateStruct(req, requestLanguage(ctx)); validationErrors != nil {
+			return validationProblem(validationErrors)
+		}
+		err, product := catalog.AdjustStock(ctx.UserContext(), productID, req.Quantity, false)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(product)
+	}
+}
+
+// getFramesHandler godoc
+//
+//	@Summary		Get list of frames
+//	@Description	Returns frames of the catalog ordered by SKU, along with quantities in stock
+//	@Tags			list-frames
+//	@Produce		json,application/problem+json
+//	@Success		200		{array}		database.Frame
+//	@Failure		400		{object}	server.Problem	"invalid request"
+//	@Param			q		query		string			false	"search by words found in SKU, brand, model or color"
+//	@Param			code	query		string			false	"SKU or barcode of the frame"
+//	@Router			/api/catalog/frames [get]
+func getFramesHandler(server *CustomerManagerServer) fiber.Handler {
+	return listProductsHandler(server.framesRepository)
+}
+
+// createFrameHandler godoc
+//
+//	@Summary		Add a frame to the catalog
+//	@Description	Adds a frame with the quantity initially in stock, the SKU and the barcode cannot be shared
+//	@Tags			create-frame
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		201				{object}	database.Frame
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Param			frameDetails	body		server.CreateFrameRequest	true	"Frame details"
+//	@Router			/api/catalog/frames [post]
+func createFrameHandler(server *CustomerManagerServer) fiber.Handler {
+	return createProductHandler(server.framesRepository, func(r *CreateFrameRequest) *database.Frame {
+		frame := convertToFrame(&r.EditFrameRequest)
+		frame.Stock = r.Stock
+		return frame
+	})
+}
+
+// getFrameByIDHandler godoc
+//
+//	@Summary		Get a frame
+//	@Description	Returns a frame of the catalog by ID
+//	@Tags			get-frame
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Frame
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			productID	path		string			true	"Frame ID"
+//	@Router			/api/catalog/frames/{productID} [get]
+func getFrameByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return getProductByIDHandler(server.framesRepository, "frame")
+}
+
+// editFrameByIDHandler godoc
+//
+//	@Summary		Edit a frame
+//	@Description	Changes details of a frame of the catalog by ID, the stock is changed by stock adjustments only
+//	@Tags			update-frame
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.Frame
+//	@Failure		404				{object}	server.Problem			"resource not found"
+//	@Failure		400				{object}	server.Problem			"invalid request"
+//	@Param			productID		path		string					true	"Frame ID"
+//	@Param			frameDetails	body		server.EditFrameRequest	true	"New frame details"
+//	@Router			/api/catalog/frames/{productID} [put]
+func editFrameByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return editProductHandler(
+		server.framesRepository,
+		"frame",
+		func(r *EditFrameRequest, productID string) *database.Frame {
+			frame := convertToFrame(r)
+			frame.ID = productID
+			return frame
+		},
+	)
+}
+
+// adjustFrameStockHandler godoc
+//
+//	@Summary		Adjust stock of a frame
+//	@Description	Adds delivered frames to the stock, negative quantities write frames off
+//	@Tags			adjust-frame-stock
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.Frame
+//	@Failure		404			{object}	server.Problem					"resource not found"
+//	@Failure		400			{object}	server.Problem					"invalid request"
+//	@Failure		409			{object}	server.Problem					"out of stock"
+//	@Param			productID	path		string							true	"Frame ID"
+//	@Param			adjustment	body		server.StockAdjustmentRequest	true	"Quantity added to the stock"
+//	@Router			/api/catalog/frames/{productID}/stock [post]
+func adjustFrameStockHandler(server *CustomerManagerServer) fiber.Handler {
+	return adjustStockHandler(server.framesRepository, "frame")
+}
+
+// getLensTypesHandler godoc
+//
+//	@Summary		Get list of lens types
+//	@Description	Returns lens types of the catalog ordered by SKU, along with pairs of lenses in stock
+//	@Tags			list-lens-types
+//	@Produce		json,application/problem+json
+//	@Success		200		{array}		database.LensType
+//	@Failure		400		{object}	server.Problem	"invalid request"
+//	@Param			q		query		string			false	"search by words found in SKU, name, material or coating"
+//	@Param			code	query		string			false	"SKU or barcode of the lens type"
+//	@Router			/api/catalog/lens-types [get]
+func getLensTypesHandler(server *CustomerManagerServer) fiber.Handler {
+	return listProductsHandler(server.lensTypesRepository)
+}
+
+// createLensTypeHandler godoc
+//
+//	@Summary		Add a lens type to the catalog
+//	@Description	Adds a lens type with pairs of lenses initially in stock, the SKU and the barcode cannot be shared
+//	@Tags			create-lens-type
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		201				{object}	database.LensType
+//	@Failure		400				{object}	server.Problem					"invalid request"
+//	@Param			lensTypeDetails	body		server.CreateLensTypeRequest	true	"Lens type details"
+//	@Router			/api/catalog/lens-types [post]
+func createLensTypeHandler(server *CustomerManagerServer) fiber.Handler {
+	return createProductHandler(server.lensTypesRepository, func(r *CreateLensTypeRequest) *database.LensType {
+		lensType := convertToLensType(&r.EditLensTypeRequest)
+		lensType.Stock = r.Stock
+		return lensType
+	})
+}
+
+// getLensTypeByIDHandler godoc
+//
+//	@Summary		Get a lens type
+//	@Description	Returns a lens type of the catalog by ID
+//	@Tags			get-lens-type
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.LensType
+//	@Failure		404			{object}	server.Problem	"resource not found"
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			productID	path		string			true	"Lens type ID"
+//	@Router			/api/catalog/lens-types/{productID} [get]
+func getLensTypeByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return getProductByIDHandler(server.lensTypesRepository, "lens type")
+}
+
+// editLensTypeByIDHandler godoc
+//
+//	@Summary		Edit a lens type
+//	@Description	Changes details of a lens type of the catalog by ID, the stock is changed by stock adjustments only
+//	@Tags			update-lens-type
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200				{object}	database.LensType
+//	@Failure		404				{object}	server.Problem				"resource not found"
+//	@Failure		400				{object}	server.Problem				"invalid request"
+//	@Param			productID		path		string						true	"Lens type ID"
+//	@Param			lensTypeDetails	body		server.EditLensTypeRequest	true	"New lens type details"
+//	@Router			/api/catalog/lens-types/{productID} [put]
+func editLensTypeByIDHandler(server *CustomerManagerServer) fiber.Handler {
+	return editProductHandler(
+		server.lensTypesRepository,
+		"lens type",
+		func(r *EditLensTypeRequest, productID string) *database.LensType {
+			lensType := convertToLensType(r)
+			lensType.ID = productID
+			return lensType
+		},
+	)
+}
+
+// adjustLensTypeStockHandler godoc
+//
+//	@Summary		Adjust stock of a lens type
+//	@Description	Adds delivered pairs of lenses to the stock, negative quantities write them off
+//	@Tags			adjust-lens-type-stock
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	database.LensType
+//	@Failure		404			{object}	server.Problem					"resource not found"
+//	@Failure		400			{object}	server.Problem					"invalid request"
+//	@Failure		409			{object}	server.Problem					"out of stock"
+//	@Param			productID	path		string							true	"Lens type ID"
+//	@Param			adjustment	body		server.StockAdjustmentRequest	true	"Pairs added to the stock"
+//	@Router			/api/catalog/lens-types/{productID}/stock [post]
+func adjustLensTypeStockHandler(server *CustomerManagerServer) fiber.Handler {
+	return adjustStockHandler(server.lensTypesRepository, "lens type")
+}
+
+// getReorderSuggestionsHandler godoc
+//
+//	@Summary		Get reorder suggestions
+//	@Description	Returns frames and lens types expected to fall below their reorder thresholds within the lead time,
+//	@Description	given sales of recent purchases, grouped by suppliers with quantities recommended to reorder.
+//	@Description	Suggestions of the last run of the reorder job are returned unless refresh is set.
+//	@Tags			list-reorder-suggestions
+//	@Produce		json,application/problem+json
+//	@Success		200		{object}	repositories.ReorderSuggestions
+//	@Failure		400		{object}	server.Problem	"invalid request"
+//	@Param			refresh	query		bool			false	"compute suggestions from the current stock"
+//	@Router			/api/inventory/reorder-suggestions [get]
+func getReorderSuggestionsHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		suggestions := server.latestReorderSuggestions()
+		if suggestions == nil || ctx.QueryBool("refresh") {
+			var err error
+			if err, suggestions = server.computeReorderSuggestions(ctx.UserContext()); err != nil {
+				return err
+			}
+		}
+		return ctx.Status(fiber.StatusOK).JSON(suggestions)
+	}
+}
+
+// getAuditLogHandler godoc
+//
+//	@Summary		Get audit log
+//	@Description	Returns changes of customers, purchases and repairs, the latest first
+//	@Tags			list-audit
+//	@Produce		json,application/problem+json
+//	@Success		200			{object}	server.AuditLogResponse
+//	@Failure		400			{object}	server.Problem	"invalid request"
+//	@Param			entityID	query		string			false	"ID of changed customer, purchase or repair"
+//	@Param			actor		query		string			false	"author of changes"
+//	@Param			from		query		string			false	"RFC 3339 time of the earliest change"
+//	@Param			to			query		string			false	"RFC 3339 time of the latest change"
+//	@Param			limit		query		int				false	"list length"	default(50)
+//	@Param			offset		query		int				false	"list offset"	default(0)
+//	@Router			/api/audit [get]
+func getAuditLogHandler(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		filter := repositories.AuditFilter{
+			EntityID: ctx.Query("entityID"),
+			Actor:    ctx.Query("actor"),
+			Limit:    ctx.QueryInt("limit", 50),
+			Offset:   ctx.QueryInt("offset", 0),
+		}
+		if filter.EntityID != "" {
+			if err := validateID("entity", filter.EntityID); err != nil {
+				return err
+			}
+		}
+		for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			value := ctx.Query(param)
+			if value == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return badRequest(fmt.Sprintf("given %s time '%s' is not a valid RFC 3339 time", param, value))
+			}
+			*bound = parsed
+		}
+
+		err, entries, total := server.auditRepository.ListBy(ctx.UserContext(), filter)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(AuditLogResponse{Data: entries, Total: total})
+	}
+}
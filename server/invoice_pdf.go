@@ -0,0 +1,267 @@
+package server
+
+import (
+	"customer-manager/database"
+	"customer-manager/pdf"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// invoiceLabels holds labels printed on invoices per language of messages.
+var invoiceLabels = map[string]map[string]string{
+	"en": {
+		"title":       "Invoice",
+		"issuedAt":    "Issue date",
+		"currency":    "Currency",
+		"seller":      "Seller",
+		"buyer":       "Buyer",
+		"taxID":       "Tax ID",
+		"position":    "No.",
+		"description": "Description",
+		"unitPrice":   "Unit price",
+		"discount":    "Discount",
+		"vatRate":     "Rate",
+		"net":         "Net",
+		"vat":         "VAT",
+		"gross":       "Gross",
+		"summary":     "VAT summary",
+		"total":       "Total",
+		"due":         "Amount due",
+	},
+	"pl": {
+		"title":       "Faktura VAT",
+		"issuedAt":    "Data wystawienia",
+		"currency":    "Waluta",
+		"seller":      "Sprzedawca",
+		"buyer":       "Nabywca",
+		"taxID":       "NIP",
+		"position":    "Lp.",
+		"description": "Nazwa",
+		"unitPrice":   "Cena brutto",
+		"discount":    "Rabat",
+		"vatRate":     "Stawka",
+		"net":         "Netto",
+		"vat":         "VAT",
+		"gross":       "Brutto",
+		"summary":     "Podsumowanie VAT",
+		"total":       "Razem",
+		"due":         "Do zapłaty",
+	},
+}
+
+const (
+	invoiceMargin     = 40.0
+	invoiceFontSize   = 9.0
+	invoiceLineHeight = 12.0
+	// invoiceDescriptionWidth is the width of the description column, longer descriptions are wrapped.
+	invoiceDescriptionWidth = 185.0
+)
+
+// invoiceColumns are right edges of amount columns of the items table.
+var invoiceColumns = struct {
+	position, unitPrice, discount, vatRate, net, vat, gross float64
+}{58, 310, 360, 395, 445, 495, pdf.PageWidth - invoiceMargin}
+
+// vatRateTotal sums amounts of invoice items with the same VAT rate.
+type vatRateTotal struct {
+	rate            int
+	net, vat, gross database.Money
+}
+
+// vatSummary returns totals of the invoice per VAT rate, the highest rate first.
+func vatSummary(invoice *database.Invoice) []vatRateTotal {
+	var totals []vatRateTotal
+	for _, item := range invoice.Items {
+		i := slices.IndexFunc(totals, func(total vatRateTotal) bool { return total.rate == item.VATRate })
+		if i < 0 {
+			zero := database.Money{Currency: item.Total.Currency}
+			totals = append(totals, vatRateTotal{rate: item.VATRate, net: zero, vat: zero, gross: zero})
+			i = len(totals) - 1
+		}
+		totals[i].net = totals[i].net.Add(item.Net)
+		totals[i].vat = totals[i].vat.Add(item.VAT)
+		totals[i].gross = totals[i].gross.Add(item.Total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].rate > totals[j].rate })
+	return totals
+}
+
+// wrapText splits the text into lines which fit the width, words wider than the width are split as well.
+func wrapText(font pdf.Font, size float64, text string, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if pdf.TextWidth(font, size, candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, r := range word {
+			if line != "" && pdf.TextWidth(font, size, line+string(r)) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// invoicePrinter lays out the invoice top to bottom, starting new pages when the current one is full.
+type invoicePrinter struct {
+	document *pdf.Document
+	labels   map[string]string
+	y        float64
+}
+
+func (p *invoicePrinter) text(x float64, font pdf.Font, size float64, text string) {
+	p.document.Text(x, p.y, font, size, text)
+}
+
+func (p *invoicePrinter) textRight(x float64, font pdf.Font, size float64, text string) {
+	p.document.TextRight(x, p.y, font, size, text)
+}
+
+func (p *invoicePrinter) rule(width float64) {
+	p.document.Line(invoiceMargin, p.y, pdf.PageWidth-invoiceMargin, p.y, width)
+}
+
+// ensureSpace starts a new page unless the height fits the current one, and tells whether it did.
+func (p *invoicePrinter) ensureSpace(height float64) bool {
+	if p.y+height <= pdf.PageHeight-invoiceMargin {
+		return false
+	}
+	p.document.AddPage()
+	p.y = invoiceMargin + invoiceLineHeight
+	return true
+}
+
+// party prints the seller or the buyer at x and returns the position below them.
+func (p *invoicePrinter) party(x float64, top float64, label string, party database.InvoiceParty) float64 {
+	lines := []string{party.Name}
+	if party.TaxID != "" {
+		lines = append(lines, fmt.Sprintf("%s: %s", p.labels["taxID"], party.TaxID))
+	}
+	address := party.Address
+	lines = append(lines, address.Street, strings.TrimSpace(address.PostalCode+" "+address.City), address.Country)
+	p.y = top
+	p.text(x, pdf.Bold, 10, label)
+	for _, line := range lines {
+		if line != "" {
+			p.y += invoiceLineHeight
+			p.text(x, pdf.Regular, 10, line)
+		}
+	}
+	return p.y
+}
+
+func (p *invoicePrinter) itemsHeader() {
+	header := []struct {
+		x     float64
+		label string
+	}{
+		{invoiceColumns.unitPrice, p.labels["unitPrice"]},
+		{invoiceColumns.discount, p.labels["discount"]},
+		{invoiceColumns.vatRate, p.labels["vatRate"]},
+		{invoiceColumns.net, p.labels["net"]},
+		{invoiceColumns.vat, p.labels["vat"]},
+		{invoiceColumns.gross, p.labels["gross"]},
+	}
+	p.textRight(invoiceColumns.position, pdf.Bold, invoiceFontSize, p.labels["position"])
+	p.text(invoiceColumns.position+4, pdf.Bold, invoiceFontSize, p.labels["description"])
+	for _, column := range header {
+		p.textRight(column.x, pdf.Bold, invoiceFontSize, column.label)
+	}
+	p.y += 4
+	p.rule(0.5)
+}
+
+// amounts prints the net amount, VAT and the gross amount in their columns.
+func (p *invoicePrinter) amounts(font pdf.Font, net, vat, gross database.Money) {
+	p.textRight(invoiceColumns.net, font, invoiceFontSize, net.String())
+	p.textRight(invoiceColumns.vat, font, invoiceFontSize, vat.String())
+	p.textRight(invoiceColumns.gross, font, invoiceFontSize, gross.String())
+}
+
+func (p *invoicePrinter) item(item database.InvoiceItem) {
+	lines := wrapText(pdf.Regular, invoiceFontSize, item.Description, invoiceDescriptionWidth)
+	if p.ensureSpace(float64(len(lines)) * invoiceLineHeight) {
+		p.itemsHeader()
+	}
+	p.y += invoiceLineHeight
+	p.textRight(invoiceColumns.position, pdf.Regular, invoiceFontSize, fmt.Sprintf("%d.", item.Position))
+	p.textRight(invoiceColumns.unitPrice, pdf.Regular, invoiceFontSize, item.UnitPrice.String())
+	p.textRight(invoiceColumns.discount, pdf.Regular, invoiceFontSize, item.Discount.String())
+	p.textRight(invoiceColumns.vatRate, pdf.Regular, invoiceFontSize, fmt.Sprintf("%d%%", item.VATRate))
+	p.amounts(pdf.Regular, item.Net, item.VAT, item.Total)
+	for i, line := range lines {
+		if i > 0 {
+			p.y += invoiceLineHeight
+		}
+		p.text(invoiceColumns.position+4, pdf.Regular, invoiceFontSize, line)
+	}
+	p.y += 4
+	p.rule(0.25)
+}
+
+// renderInvoice lays the invoice out on A4 pages with labels in the given language.
+func renderInvoice(invoice *database.Invoice, language string) *pdf.Document {
+	labels, ok := invoiceLabels[language]
+	if !ok {
+		labels = invoiceLabels[messageLanguages[0]]
+	}
+	title := fmt.Sprintf("%s %s", labels["title"], invoice.Number)
+	p := &invoicePrinter{document: pdf.NewDocument(title), labels: labels, y: 60}
+
+	p.text(invoiceMargin, pdf.Bold, 18, title)
+	p.y += 20
+	issuedAt := invoice.IssuedAt.Format(DateLayout)
+	p.text(invoiceMargin, pdf.Regular, 10, fmt.Sprintf("%s: %s", labels["issuedAt"], issuedAt))
+	p.y += invoiceLineHeight
+	p.text(invoiceMargin, pdf.Regular, 10, fmt.Sprintf("%s: %s", labels["currency"], invoice.Total.Currency))
+
+	top := p.y + 30
+	sellerBottom := p.party(invoiceMargin, top, labels["seller"], invoice.Seller)
+	buyerBottom := p.party(pdf.PageWidth/2, top, labels["buyer"], invoice.Buyer)
+	p.y = sellerBottom
+	if buyerBottom > p.y {
+		p.y = buyerBottom
+	}
+
+	p.y += 30
+	p.itemsHeader()
+	for _, item := range invoice.Items {
+		p.item(item)
+	}
+
+	summary := vatSummary(invoice)
+	p.ensureSpace(float64(len(summary)+4) * invoiceLineHeight)
+	p.y += 2 * invoiceLineHeight
+	p.text(invoiceMargin, pdf.Bold, invoiceFontSize, labels["summary"])
+	for _, total := range summary {
+		p.textRight(invoiceColumns.vatRate, pdf.Regular, invoiceFontSize, fmt.Sprintf("%d%%", total.rate))
+		p.amounts(pdf.Regular, total.net, total.vat, total.gross)
+		p.y += invoiceLineHeight
+	}
+	p.textRight(invoiceColumns.vatRate, pdf.Bold, invoiceFontSize, labels["total"])
+	p.amounts(pdf.Bold, invoice.Net, invoice.VAT, invoice.Total)
+
+	p.y += 2 * invoiceLineHeight
+	p.textRight(
+		invoiceColumns.gross,
+		pdf.Bold,
+		12,
+		fmt.Sprintf("%s: %s %s", labels["due"], invoice.Total.String(), invoice.Total.Currency),
+	)
+	return p.document
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// messageLanguages are languages validation messages are translated to, the first one is used
+// when the client does not accept any of them.
+var messageLanguages = []string{"en", "pl"}
+
+// validationMessages holds messages of validation error codes per language, '{field}' is replaced
+// with the path of the invalid field.
+var validationMessages = map[string]map[string]string{
+	"en": {
+		"required":             "The '{field}' is required",
+		"telephone":            "The '{field}' is not a valid telephone number",
+		"date":                 "The '{field}' must be a date in YYYY-MM-DD format",
+		"money":                "The '{field}' must be a non-negative decimal amount, e.g. 120.50",
+		"moneyScale":           "The '{field}' has more decimal places than its currency",
+		"currency":             "The '{field}' must be an ISO 4217 currency code, e.g. PLN",
+		"priceCurrency":        "The '{field}' must be the currency of the price",
+		"discount":             "The '{field}' cannot exceed the price",
+		"vatRate":              "The '{field}' must be a percentage between 0 and 100",
+		"uuid":                 "The '{field}' is not a valid UUID",
+		"sphere":               "The '{field}' must be between -30 and 30 in 0.25 diopter steps",
+		"cylinder":             "The '{field}' must be between -10 and 10 in 0.25 diopter steps",
+		"axis":                 "The '{field}' must be between 0 and 180 degrees",
+		"addition":             "The '{field}' must be between 0 and 4 in 0.25 diopter steps",
+		"prism":                "The '{field}' must be between 0 and 20 prism diopters",
+		"prismBase":            "The '{field}' must be one of BI, BO, BU or BD",
+		"binocularPD":          "The '{field}' must be between 40 and 80 millimeters",
+		"monocularPD":          "The '{field}' must be between 20 and 40 millimeters",
+		"repairStatus":         "The '{field}' is not a valid repair status",
+		"requiredWithCylinder": "The '{field}' is required when cylinder is given",
+		"requiredWithPrism":    "The '{field}' is required when prism is given",
+		"pd":                   "The '{field}' requires either binocular or both monocular distances",
+		"afterIssuedAt":        "The '{field}' must be after 'issued_at'",
+		"email":                "The '{field}' is not a valid email address",
+		"maxLen":               "The '{field}' must be at most %d characters long",
+		"min":                  "The '{field}' must be at least %v",
+		"country":              "The '{field}' must be a two-letter country code, e.g. PL",
+		"contactChannel":       "The '{field}' must be one of telephone, sms, email or post",
+		"pastDate":             "The '{field}' cannot be in the future",
+		"requiredForContact":   "The '{field}' is required for the preferred contact channel",
+		"invoiceItems":         "At least one purchase or repair has to be invoiced",
+		"duplicated":           "The '{field}' is given more than once",
+		"cancelledRepair":      "The '{field}' is a cancelled repair, which cannot be invoiced",
+		"paymentMethod":        "The '{field}' must be one of cash, card or transfer",
+		"paidItem":             "Either 'purchase_id' or 'repair_id' has to be given",
+		"positiveAmount":       "The '{field}' must be greater than zero",
+		"dateTime":             "The '{field}' must be a date and time in RFC 3339 format, e.g. 2024-01-31T15:04:05Z",
+	},
+	"pl": {
+		"required":             "Pole '{field}' jest wymagane",
+		"telephone":            "Pole '{field}' nie jest poprawnym numerem telefonu",
+		"date":                 "Pole '{field}' musi być datą w formacie RRRR-MM-DD",
+		"money":                "Pole '{field}' musi być nieujemną kwotą dziesiętną, np. 120.50",
+		"moneyScale":           "Pole '{field}' ma więcej miejsc po przecinku niż jego waluta",
+		"currency":             "Pole '{field}' musi być kodem waluty ISO 4217, np. PLN",
+		"priceCurrency":        "Pole '{field}' musi być walutą ceny",
+		"discount":             "Pole '{field}' nie może przekraczać ceny",
+		"vatRate":              "Pole '{field}' musi być wartością procentową od 0 do 100",
+		"uuid":                 "Pole '{field}' nie jest poprawnym UUID",
+		"sphere":               "Pole '{field}' musi mieścić się w zakresie od -30 do 30 z krokiem 0,25 dioptrii",
+		"cylinder":             "Pole '{field}' musi mieścić się w zakresie od -10 do 10 z krokiem 0,25 dioptrii",
+		"axis":                 "Pole '{field}' musi mieścić się w zakresie od 0 do 180 stopni",
+		"addition":             "Pole '{field}' musi mieścić się w zakresie od 0 do 4 z krokiem 0,25 dioptrii",
+		"prism":                "Pole '{field}' musi mieścić się w zakresie od 0 do 20 dioptrii pryzmatycznych",
+		"prismBase":            "Pole '{field}' musi mieć jedną z wartości BI, BO, BU lub BD",
+		"binocularPD":          "Pole '{field}' musi mieścić się w zakresie od 40 do 80 milimetrów",
+		"monocularPD":          "Pole '{field}' musi mieścić się w zakresie od 20 do 40 milimetrów",
+		"repairStatus":         "Pole '{field}' nie jest poprawnym statusem naprawy",
+		"requiredWithCylinder": "Pole '{field}' jest wymagane, gdy podano cylinder",
+		"requiredWithPrism":    "Pole '{field}' jest wymagane, gdy podano pryzmat",
+		"pd":                   "Pole '{field}' wymaga rozstawu źrenic dla obu oczu lub dla każdego oka osobno",
+		"afterIssuedAt":        "Pole '{field}' musi być późniejsze niż 'issued_at'",
+		"email":                "Pole '{field}' nie jest poprawnym adresem email",
+		"maxLen":               "Pole '{field}' może mieć co najwyżej %d znaków",
+		"min":                  "Pole '{field}' musi wynosić co najmniej %v",
+		"country":              "Pole '{field}' musi być dwuliterowym kodem kraju, np. PL",
+		"contactChannel":       "Pole '{field}' musi mieć jedną z wartości telephone, sms, email lub post",
+		"pastDate":             "Pole '{field}' nie może być datą z przyszłości",
+		"requiredForContact":   "Pole '{field}' jest wymagane dla preferowanego sposobu kontaktu",
+		"invoiceItems":         "Należy zafakturować co najmniej jeden zakup lub naprawę",
+		"duplicated":           "Pole '{field}' podano więcej niż raz",
+		"cancelledRepair":      "Pole '{field}' wskazuje anulowaną naprawę, której nie można zafakturować",
+		"paymentMethod":        "Pole '{field}' musi mieć jedną z wartości cash, card lub transfer",
+		"paidItem":             "Należy podać 'purchase_id' albo 'repair_id'",
+		"positiveAmount":       "Pole '{field}' musi być większe od zera",
+		"dateTime":             "Pole '{field}' musi być datą i godziną w formacie RFC 3339, np. 2024-01-31T15:04:05Z",
+	},
+}
+
+// requestLanguage returns the language of validation messages preferred by the client in the Accept-Language header.
+func requestLanguage(ctx *fiber.Ctx) string {
+	if language := ctx.AcceptsLanguages(messageLanguages...); language != "" {
+		return language
+	}
+	return messageLanguages[0]
+}
+
+// validationMessage returns the message of the validation error code for the field in the given language.
+func validationMessage(language string, code string, field string) string {
+	message, ok := validationMessages[language][code]
+	if !ok {
+		message = validationMessages[messageLanguages[0]][code]
+	}
+	return strings.ReplaceAll(message, "{field}", field)
+}
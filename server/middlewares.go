@@ -1,7 +1,11 @@
 package server
 
 import (
+	"context"
+	"customer-manager/repositories"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/exp/slices"
@@ -13,15 +17,67 @@ func jsonContentValidator() fiber.Handler {
 		if !slices.Contains(methodsToValidate, ctx.Method()) {
 			return ctx.Next()
 		}
-		if contentType := ctx.Get("Content-Type"); contentType != fiber.MIMEApplicationJSON {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"detail": fmt.Sprintf(
-					"invalid content-type header specified: '%s', allowed: '%s'",
-					contentType,
-					fiber.MIMEApplicationJSON,
-				),
-			})
+		// requests without a body, like restoring a record, do not have to declare the content type
+		if len(ctx.Body()) == 0 && ctx.Get("Content-Type") == "" {
+			return ctx.Next()
+		}
+		allowedContentTypes := []string{fiber.MIMEApplicationJSON}
+		if ctx.Method() == fiber.MethodPatch {
+			allowedContentTypes = append(allowedContentTypes, MIMEApplicationMergePatchJSON)
+		}
+		if contentType := ctx.Get("Content-Type"); !slices.Contains(allowedContentTypes, contentType) {
+			return badRequest(fmt.Sprintf(
+				"invalid content-type header specified: '%s', allowed: '%s'",
+				contentType,
+				strings.Join(allowedContentTypes, "', '"),
+			))
+		}
+		return ctx.Next()
+	}
+}
+
+// queryTimeout attaches the server query timeout to the context passed to repositories. When the deadline
+// is exceeded and the handler has not succeeded, its error is replaced with 504.
+func queryTimeout(server *CustomerManagerServer) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if server.QueryTimeout <= 0 {
+			return ctx.Next()
+		}
+		userContext, cancel := context.WithTimeout(ctx.UserContext(), server.QueryTimeout)
+		defer cancel()
+		ctx.SetUserContext(userContext)
+
+		err := ctx.Next()
+		failed := err != nil || ctx.Response().StatusCode() >= fiber.StatusBadRequest
+		if failed && errors.Is(userContext.Err(), context.DeadlineExceeded) {
+			return newProblem(
+				fiber.StatusGatewayTimeout,
+				ProblemTypeBlank,
+				fmt.Sprintf("request did not finish within %s", server.QueryTimeout),
+			)
 		}
+		return err
+	}
+}
+
+// includeDeleted makes GET requests with "include_deleted=true" query return soft deleted records as well.
+func includeDeleted() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() == fiber.MethodGet && ctx.QueryBool("include_deleted") {
+			ctx.SetUserContext(repositories.IncludeDeleted(ctx.UserContext()))
+		}
+		return ctx.Next()
+	}
+}
+
+// AnonymousActor is recorded in the audit log for requests which do not identify their author.
+const AnonymousActor = "anonymous"
+
+// auditActor takes the author of changes made by the request from the "X-Actor" header.
+func auditActor() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		actor := ctx.Get("X-Actor", AnonymousActor)
+		ctx.SetUserContext(repositories.WithActor(ctx.UserContext(), actor))
 		return ctx.Next()
 	}
 }
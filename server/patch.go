@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// MIMEApplicationMergePatchJSON is the content type of RFC 7396 JSON merge patch documents.
+const MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+
+// mergePatch applies RFC 7396 merge patch to the target document, members set to null are removed
+// and objects are merged recursively.
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// applyMergePatch applies the merge patch body to the request struct holding current details of a record.
+// Members removed by the patch are reset to zero values. Top level members given in the patch are returned.
+func applyMergePatch(body []byte, request any) (error, []string) {
+	var patch map[string]any
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return errors.New("given body is not a valid JSON merge patch object"), nil
+	}
+	current, err := json.Marshal(request)
+	if err != nil {
+		return err, nil
+	}
+	var target any
+	if err := json.Unmarshal(current, &target); err != nil {
+		return err, nil
+	}
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err, nil
+	}
+	value := reflect.ValueOf(request).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	if err := json.Unmarshal(merged, request); err != nil {
+		return err, nil
+	}
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	return nil, fields
+}
+
+// patchedFields maps members given in the merge patch to the model fields they change,
+// unknown members are ignored.
+func patchedFields(fields []string, modelFields map[string]string) []string {
+	var patched []string
+	for _, field := range fields {
+		if modelField, ok := modelFields[field]; ok {
+			patched = append(patched, modelField)
+		}
+	}
+	return patched
+}
+
+// patchedErrors keeps validation errors of members given in the merge patch, as the remaining ones
+// hold current values of the record.
+func patchedErrors[V any](errs map[string]V, fields []string) map[string]V {
+	patched := map[string]V{}
+	for field, err := range errs {
+		for _, patchedField := range fields {
+			if field == patchedField || strings.HasPrefix(field, patchedField+".") {
+				patched[field] = err
+			}
+		}
+	}
+	if len(patched) == 0 {
+		return nil
+	}
+	return patched
+}
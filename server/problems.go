@@ -0,0 +1,179 @@
+package server
+
+import (
+	"customer-manager/repositories"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+)
+
+// MIMEApplicationProblemJSON is the content type of RFC 7807 problem details documents.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem types identify the kind of error independently of its detail, which is meant for humans.
+// Errors without a more specific type are reported as "about:blank" titled with the HTTP status.
+const (
+	ProblemTypeBlank                     = "about:blank"
+	ProblemTypeValidation                = "/problems/validation-error"
+	ProblemTypeNotFound                  = "/problems/not-found"
+	ProblemTypeDuplicatedTelephoneNumber = "/problems/duplicated-telephone-number"
+	ProblemTypeVersionConflict           = "/problems/version-conflict"
+	ProblemTypePreconditionRequired      = "/problems/precondition-required"
+	ProblemTypeInvalidStatusTransition   = "/problems/invalid-status-transition"
+	ProblemTypeAlreadyInvoiced           = "/problems/already-invoiced"
+	ProblemTypeBalanceExceeded           = "/problems/balance-exceeded"
+	ProblemTypeDuplicatedProductCode     = "/problems/duplicated-product-code"
+	ProblemTypeOutOfStock                = "/problems/out-of-stock"
+	ProblemTypeInvoiceBackdated          = "/problems/invoice-backdated"
+)
+
+var problemTitles = map[string]string{
+	ProblemTypeValidation:                "Request validation failed",
+	ProblemTypeNotFound:                  "Resource not found",
+	ProblemTypeDuplicatedTelephoneNumber: "Telephone number already taken",
+	ProblemTypeVersionConflict:           "Resource changed in the meantime",
+	ProblemTypePreconditionRequired:      "Resource version required",
+	ProblemTypeInvalidStatusTransition:   "Invalid status transition",
+	ProblemTypeAlreadyInvoiced:           "Already invoiced",
+	ProblemTypeBalanceExceeded:           "Balance exceeded",
+	ProblemTypeDuplicatedProductCode:     "Product code already taken",
+	ProblemTypeOutOfStock:                "Out of stock",
+	ProblemTypeInvoiceBackdated:          "Invoice backdated",
+}
+
+// FieldError describes why a request field is invalid, the code is meant for clients
+// while the message is translated to the language accepted by the client.
+type FieldError struct {
+	Code    string `json:"code"    example:"required"`
+	Message string `json:"message" example:"The 'first_name' is required"`
+}
+
+// Problem is an RFC 7807 problem details object which all error responses of the API consist of.
+// Errors holds errors of invalid request fields keyed by their JSON path.
+type Problem struct {
+	Type     string                  `json:"type"               example:"/problems/not-found"`
+	Title    string                  `json:"title"              example:"Resource not found"`
+	Status   int                     `json:"status"             example:"404"`
+	Detail   string                  `json:"detail,omitempty"`
+	Instance string                  `json:"instance,omitempty"`
+	Errors   map[string][]FieldError `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+func newProblem(status int, problemType string, detail string) *Problem {
+	title, ok := problemTitles[problemType]
+	if !ok {
+		title = utils.StatusMessage(status)
+	}
+	return &Problem{Type: problemType, Title: title, Status: status, Detail: detail}
+}
+
+func badRequest(detail string) *Problem {
+	return newProblem(fiber.StatusBadRequest, ProblemTypeBlank, detail)
+}
+
+func notFound(detail string) *Problem {
+	return newProblem(fiber.StatusNotFound, ProblemTypeNotFound, detail)
+}
+
+func validationProblem(errs map[string][]FieldError) *Problem {
+	problem := newProblem(fiber.StatusBadRequest, ProblemTypeValidation, "given request has invalid fields")
+	problem.Errors = errs
+	return problem
+}
+
+// validateID returns the bad request problem when the ID of the entity given in the path is not a valid UUID.
+func validateID(entity string, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return badRequest(fmt.Sprintf("given %s id '%s' is not a valid UUID", entity, id))
+	}
+	return nil
+}
+
+// parseBody parses the request body into the given request struct.
+func parseBody(ctx *fiber.Ctx, request any) error {
+	if err := ctx.BodyParser(request); err != nil {
+		return badRequest(fmt.Sprintf("given body cannot be parsed: %s", err.Error()))
+	}
+	return nil
+}
+
+// toProblem maps errors returned by handlers to problems. Domain errors of repositories have their own problem
+// types, while unexpected errors are reported without details which could reveal internals of the service.
+func toProblem(err error) *Problem {
+	var (
+		problem                 *Problem
+		fiberError              *fiber.Error
+		customerNotFound        *repositories.CustomerNotFoundError
+		purchaseNotFound        *repositories.PurchaseNotFoundError
+		repairNotFound          *repositories.RepairNotFoundError
+		prescriptionNotFound    *repositories.PrescriptionNotFoundError
+		invoiceNotFound         *repositories.InvoiceNotFoundError
+		productNotFound         *repositories.ProductNotFoundError
+		duplicatedTelephone     *repositories.DuplicatedTelephoneNumberError
+		versionConflict         *repositories.VersionConflictError
+		invalidStatusTransition *repositories.InvalidRepairStatusTransitionError
+		invalidCursor           *repositories.InvalidCursorError
+		alreadyInvoiced         *repositories.AlreadyInvoicedError
+		balanceExceeded         *repositories.BalanceExceededError
+		duplicatedProductCode   *repositories.DuplicatedProductCodeError
+		outOfStock              *repositories.OutOfStockError
+		invoiceBackdated        *repositories.InvoiceBackdatedError
+	)
+	switch {
+	case errors.As(err, &problem):
+		copied := *problem
+		return &copied
+	case errors.As(err, &customerNotFound):
+		return notFound(fmt.Sprintf("customer with given id '%s' does not exists", customerNotFound.CustomerID))
+	case errors.As(err, &purchaseNotFound):
+		return notFound(fmt.Sprintf("purchase with given id '%s' does not exists", purchaseNotFound.PurchaseID))
+	case errors.As(err, &repairNotFound):
+		return notFound(fmt.Sprintf("repair with given id '%s' does not exists", repairNotFound.RepairID))
+	case errors.As(err, &prescriptionNotFound):
+		return notFound(
+			fmt.Sprintf("prescription with given id '%s' does not exists", prescriptionNotFound.PrescriptionID),
+		)
+	case errors.As(err, &invoiceNotFound):
+		return notFound(fmt.Sprintf("invoice with given id '%s' does not exists", invoiceNotFound.InvoiceID))
+	case errors.As(err, &productNotFound):
+		return notFound(fmt.Sprintf("%s with given id '%s' does not exists", productNotFound.Kind, productNotFound.ID))
+	case errors.As(err, &duplicatedTelephone):
+		return newProblem(fiber.StatusBadRequest, ProblemTypeDuplicatedTelephoneNumber, err.Error())
+	case errors.As(err, &versionConflict):
+		return newProblem(fiber.StatusPreconditionFailed, ProblemTypeVersionConflict, err.Error())
+	case errors.As(err, &invalidStatusTransition):
+		return newProblem(fiber.StatusConflict, ProblemTypeInvalidStatusTransition, err.Error())
+	case errors.As(err, &alreadyInvoiced):
+		return newProblem(fiber.StatusConflict, ProblemTypeAlreadyInvoiced, err.Error())
+	case errors.As(err, &balanceExceeded):
+		return newProblem(fiber.StatusConflict, ProblemTypeBalanceExceeded, err.Error())
+	case errors.As(err, &duplicatedProductCode):
+		return newProblem(fiber.StatusBadRequest, ProblemTypeDuplicatedProductCode, err.Error())
+	case errors.As(err, &outOfStock):
+		return newProblem(fiber.StatusConflict, ProblemTypeOutOfStock, err.Error())
+	case errors.As(err, &invoiceBackdated):
+		return newProblem(fiber.StatusConflict, ProblemTypeInvoiceBackdated, err.Error())
+	case errors.As(err, &invalidCursor):
+		return badRequest(err.Error())
+	case errors.As(err, &fiberError):
+		return newProblem(fiberError.Code, ProblemTypeBlank, fiberError.Message)
+	}
+	log.Printf("unexpected error: %v", err)
+	return newProblem(fiber.StatusInternalServerError, ProblemTypeBlank, "unexpected error occurred")
+}
+
+// ErrorHandler responds to errors returned by handlers and middlewares with problem details,
+// it has to be set in the config of the fiber app the server is mounted on.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	problem := toProblem(err)
+	problem.Instance = ctx.Path()
+	return ctx.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+}
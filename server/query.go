@@ -0,0 +1,116 @@
+package server
+
+import (
+	"customer-manager/database"
+	"customer-manager/repositories"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/exp/slices"
+)
+
+// parseSort parses comma separated fields to sort by, such as "last_name,-created_at", where fields
+// prefixed with minus are sorted descending. Only the given fields are accepted.
+func parseSort(sort string, fields []string) (error, []repositories.SortField) {
+	if sort == "" {
+		return nil, nil
+	}
+	var sortFields []repositories.SortField
+	for _, value := range strings.Split(sort, ",") {
+		field := strings.TrimSpace(value)
+		descending := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if !slices.Contains(fields, field) {
+			return badRequest(
+				fmt.Sprintf("given sort field '%s' is not one of %s", field, strings.Join(fields, ", ")),
+			), nil
+		}
+		sortFields = append(sortFields, repositories.SortField{Field: field, Descending: descending})
+	}
+	return nil, sortFields
+}
+
+// queryDate returns the date given in the query parameter, or zero time when the parameter is missing.
+func queryDate(ctx *fiber.Ctx, param string) (error, time.Time) {
+	value := ctx.Query(param)
+	if value == "" {
+		return nil, time.Time{}
+	}
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return badRequest(fmt.Sprintf("given %s date '%s' is not a valid date in YYYY-MM-DD format", param, value)),
+			time.Time{}
+	}
+	return nil, date
+}
+
+// queryOptionalBool returns the boolean given in the query parameter, or nil when the parameter is missing.
+func queryOptionalBool(ctx *fiber.Ctx, param string) (error, *bool) {
+	value := ctx.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return badRequest(fmt.Sprintf("given %s value '%s' is not a valid boolean", param, value)), nil
+	}
+	return nil, &parsed
+}
+
+// encodeCursor returns the opaque form of the cursor given to clients, nil cursor is encoded as nil.
+func encodeCursor(cursor *repositories.Cursor) *string {
+	if cursor == nil {
+		return nil
+	}
+	value, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(value)
+	return &encoded
+}
+
+// queryCursor returns the cursor given in the cursor query parameter, or nil when the parameter is missing.
+func queryCursor(ctx *fiber.Ctx) (error, *repositories.Cursor) {
+	value := ctx.Query("cursor")
+	if value == "" {
+		return nil, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	var cursor repositories.Cursor
+	if err != nil || json.Unmarshal(decoded, &cursor) != nil {
+		return badRequest(fmt.Sprintf("given cursor '%s' is not valid", value)), nil
+	}
+	return nil, &cursor
+}
+
+// CustomerPageResponse is a page of customers along with the number of all customers matching the filters.
+type CustomerPageResponse struct {
+	Data       []database.Customer `json:"data"`
+	NextCursor *string             `json:"next_cursor"`
+	PrevCursor *string             `json:"prev_cursor"`
+	Total      int                 `json:"total"`
+}
+
+// CustomerSearchResponse lists customers found by the search, the best matches first.
+type CustomerSearchResponse struct {
+	Data []repositories.CustomerMatch `json:"data"`
+}
+
+// AuditLogResponse is a page of audit log entries along with the number of all entries matching the filters.
+type AuditLogResponse struct {
+	Data  []database.AuditEntry `json:"data"`
+	Total int                   `json:"total"`
+}
+
+// pageResponse returns the response envelope of the page of records, cursors of adjacent pages are null
+// when there are no such pages.
+func pageResponse[T any](page repositories.Page[T]) fiber.Map {
+	return fiber.Map{
+		"data":        page.Items,
+		"next_cursor": encodeCursor(page.Next),
+		"prev_cursor": encodeCursor(page.Prev),
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"customer-manager/repositories"
+	"log"
+	"time"
+)
+
+// DefaultReorderInterval is how often the reorder job computes reorder suggestions.
+const DefaultReorderInterval = time.Hour
+
+// StartReorderJob computes reorder suggestions right away and then every interval until the context is done.
+// Products to reorder are logged as low-stock alerts, while the latest suggestions are served by the API.
+func (s *CustomerManagerServer) StartReorderJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.runReorderJob(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *CustomerManagerServer) runReorderJob(ctx context.Context) {
+	if s.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.QueryTimeout)
+		defer cancel()
+	}
+	err, suggestions := s.computeReorderSuggestions(ctx)
+	if err != nil {
+		log.Printf("computing reorder suggestions failed: %v", err)
+		return
+	}
+	for _, supplier := range suggestions.Suppliers {
+		for _, item := range supplier.Items {
+			log.Printf(
+				"low stock: %s with SKU '%s' has %d in stock below threshold of %d, reorder %d from supplier '%s'",
+				item.Kind, item.SKU, item.Stock, item.ReorderThreshold, item.Quantity, supplier.Supplier,
+			)
+		}
+	}
+}
+
+// computeReorderSuggestions computes reorder suggestions and keeps them as the latest ones.
+func (s *CustomerManagerServer) computeReorderSuggestions(
+	ctx context.Context,
+) (error, *repositories.ReorderSuggestions) {
+	err, suggestions := s.inventoryRepository.GetReorderSuggestions(ctx, s.ReorderPolicy, time.Now())
+	if err != nil {
+		return err, nil
+	}
+	s.reorderMutex.Lock()
+	defer s.reorderMutex.Unlock()
+	s.reorderSuggestions = suggestions
+	return nil, suggestions
+}
+
+// latestReorderSuggestions returns suggestions of the last run of the reorder job, nil before the first one.
+func (s *CustomerManagerServer) latestReorderSuggestions() *repositories.ReorderSuggestions {
+	s.reorderMutex.Lock()
+	defer s.reorderMutex.Unlock()
+	return s.reorderSuggestions
+}
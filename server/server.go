@@ -1,8 +1,11 @@
 package server
 
 import (
+	"customer-manager/database"
 	_ "customer-manager/docs"
 	"customer-manager/repositories"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -10,19 +13,47 @@ import (
 	"github.com/gofiber/swagger"
 )
 
+// DefaultQueryTimeout limits time of database queries made while handling a single request.
+const DefaultQueryTimeout = 10 * time.Second
+
 type CustomerManagerServer struct {
-	App                 *fiber.App
-	customerRepository  repositories.CustomerRepository
-	purchasesRepository repositories.PurchaseRepository
-	repairsRepository   repositories.RepairRepository
+	App *fiber.App
+	// QueryTimeout limits time of database queries made while handling a single request,
+	// requests exceeding it are responded with 504. Zero disables the limit.
+	QueryTimeout time.Duration
+	// TelephoneRegion is the region of telephone numbers given without the country calling code.
+	TelephoneRegion string
+	// Currency is the ISO 4217 code of the currency of amounts given without one.
+	Currency string
+	// Seller is named on invoices as the one issuing them.
+	Seller database.InvoiceParty
+	// ReorderPolicy tells when and how much of products of the catalog are suggested for reordering.
+	ReorderPolicy           repositories.ReorderPolicy
+	customerRepository      repositories.CustomerRepository
+	purchasesRepository     repositories.PurchaseRepository
+	repairsRepository       repositories.RepairRepository
+	prescriptionsRepository repositories.PrescriptionRepository
+	invoicesRepository      repositories.InvoiceRepository
+	paymentsRepository      repositories.PaymentRepository
+	framesRepository        repositories.CatalogRepository[database.Frame]
+	lensTypesRepository     repositories.CatalogRepository[database.LensType]
+	inventoryRepository     repositories.InventoryRepository
+	auditRepository         repositories.AuditRepository
+	unitOfWork              repositories.UnitOfWork
+	reorderMutex            sync.Mutex
+	reorderSuggestions      *repositories.ReorderSuggestions
 }
 
 func mountMiddlewares(server *CustomerManagerServer) {
 	server.App.Use(jsonContentValidator())
 	server.App.Use(cors.New(cors.Config{
-		AllowOrigins: "http://localhost:3000",
+		AllowOrigins:  "http://localhost:3000",
+		ExposeHeaders: fiber.HeaderETag,
 	}))
 	server.App.Use(logger.New()) // TODO - do not log requests during tests
+	server.App.Use(queryTimeout(server))
+	server.App.Use(includeDeleted())
+	server.App.Use(auditActor())
 }
 
 func mountSwaggerDocs(server *CustomerManagerServer) {
@@ -34,12 +65,32 @@ func NewCustomerManagerServer(
 	customerRepository repositories.CustomerRepository,
 	purchasesRepository repositories.PurchaseRepository,
 	repairsRepository repositories.RepairRepository,
+	prescriptionsRepository repositories.PrescriptionRepository,
+	invoicesRepository repositories.InvoiceRepository,
+	paymentsRepository repositories.PaymentRepository,
+	framesRepository repositories.CatalogRepository[database.Frame],
+	lensTypesRepository repositories.CatalogRepository[database.LensType],
+	inventoryRepository repositories.InventoryRepository,
+	auditRepository repositories.AuditRepository,
+	unitOfWork repositories.UnitOfWork,
 ) *CustomerManagerServer {
 	server := &CustomerManagerServer{
-		App:                 app,
-		customerRepository:  customerRepository,
-		purchasesRepository: purchasesRepository,
-		repairsRepository:   repairsRepository,
+		App:                     app,
+		QueryTimeout:            DefaultQueryTimeout,
+		TelephoneRegion:         database.DefaultTelephoneRegion,
+		Currency:                database.DefaultCurrency,
+		ReorderPolicy:           repositories.DefaultReorderPolicy,
+		customerRepository:      customerRepository,
+		purchasesRepository:     purchasesRepository,
+		repairsRepository:       repairsRepository,
+		prescriptionsRepository: prescriptionsRepository,
+		invoicesRepository:      invoicesRepository,
+		paymentsRepository:      paymentsRepository,
+		framesRepository:        framesRepository,
+		lensTypesRepository:     lensTypesRepository,
+		inventoryRepository:     inventoryRepository,
+		auditRepository:         auditRepository,
+		unitOfWork:              unitOfWork,
 	}
 
 	mountMiddlewares(server)
@@ -49,20 +100,67 @@ func NewCustomerManagerServer(
 
 	server.App.Get(customersPath, getCustomersHandler(server))
 	server.App.Post(customersPath, createCustomerHandler(server))
+	server.App.Get(customersPath+"/search", searchCustomersHandler(server))
+	server.App.Get(customersPath+"/unpaid", getUnpaidBalancesHandler(server))
 	server.App.Get(customersPath+"/:customerID", getCustomerByIDHandler(server))
 	server.App.Put(customersPath+"/:customerID", editCustomerByIDHandler(server))
+	server.App.Patch(customersPath+"/:customerID", patchCustomerByIDHandler(server))
 	server.App.Delete(customersPath+"/:customerID", deleteCustomerByIDHandler(server))
+	server.App.Post(customersPath+"/:customerID/restore", restoreCustomerByIDHandler(server))
 
 	purchasesPath := customersPath + "/:customerID" + "/purchases"
 	server.App.Get(purchasesPath, getPurchasesHandler(server))
 	server.App.Post(purchasesPath, createPurchaseHandler(server))
+	server.App.Get(purchasesPath+"/:purchaseID", getPurchaseByIDHandler(server))
 	server.App.Delete(purchasesPath+"/:purchaseID", deletePurchaseByIDHandler(server))
 	server.App.Put(purchasesPath+"/:purchaseID", editPurchaseByIDHandler(server))
+	server.App.Patch(purchasesPath+"/:purchaseID", patchPurchaseByIDHandler(server))
+	server.App.Post(purchasesPath+"/:purchaseID/restore", restorePurchaseByIDHandler(server))
 
 	repairsPath := customersPath + "/:customerID" + "/repairs"
 	server.App.Get(repairsPath, getRepairsHandler(server))
 	server.App.Post(repairsPath, createRepairHandler(server))
+	server.App.Get(repairsPath+"/:repairID", getRepairByIDHandler(server))
+	server.App.Put(repairsPath+"/:repairID", editRepairByIDHandler(server))
+	server.App.Patch(repairsPath+"/:repairID", patchRepairByIDHandler(server))
 	server.App.Delete(repairsPath+"/:repairID", deleteRepairByIDHandler(server))
+	server.App.Post(repairsPath+"/:repairID/restore", restoreRepairByIDHandler(server))
+	server.App.Get("/api/repairs", getRepairsQueueHandler(server))
+
+	prescriptionsPath := customersPath + "/:customerID" + "/prescriptions"
+	server.App.Get(prescriptionsPath, getPrescriptionsHandler(server))
+	server.App.Post(prescriptionsPath, createPrescriptionHandler(server))
+	server.App.Get(prescriptionsPath+"/:prescriptionID", getPrescriptionByIDHandler(server))
+	server.App.Put(prescriptionsPath+"/:prescriptionID", editPrescriptionByIDHandler(server))
+	server.App.Delete(prescriptionsPath+"/:prescriptionID", deletePrescriptionByIDHandler(server))
+
+	server.App.Get(customersPath+"/:customerID/invoices", getInvoicesHandler(server))
+	server.App.Post(customersPath+"/:customerID/invoices", createInvoiceHandler(server))
+	// the PDF route goes first, as the invoice ID parameter would match the ".pdf" extension as well
+	server.App.Get("/api/invoices/:invoiceID.pdf", getInvoicePDFHandler(server))
+	server.App.Get("/api/invoices/:invoiceID", getInvoiceByIDHandler(server))
+
+	server.App.Get(customersPath+"/:customerID/payments", getPaymentsHandler(server))
+	server.App.Post(customersPath+"/:customerID/payments", createPaymentHandler(server))
+	server.App.Get(customersPath+"/:customerID/balance", getCustomerBalanceHandler(server))
+
+	framesPath := "/api/catalog/frames"
+	server.App.Get(framesPath, getFramesHandler(server))
+	server.App.Post(framesPath, createFrameHandler(server))
+	server.App.Get(framesPath+"/:productID", getFrameByIDHandler(server))
+	server.App.Put(framesPath+"/:productID", editFrameByIDHandler(server))
+	server.App.Post(framesPath+"/:productID/stock", adjustFrameStockHandler(server))
+
+	lensTypesPath := "/api/catalog/lens-types"
+	server.App.Get(lensTypesPath, getLensTypesHandler(server))
+	server.App.Post(lensTypesPath, createLensTypeHandler(server))
+	server.App.Get(lensTypesPath+"/:productID", getLensTypeByIDHandler(server))
+	server.App.Put(lensTypesPath+"/:productID", editLensTypeByIDHandler(server))
+	server.App.Post(lensTypesPath+"/:productID/stock", adjustLensTypeStockHandler(server))
+
+	server.App.Get("/api/inventory/reorder-suggestions", getReorderSuggestionsHandler(server))
+
+	server.App.Get("/api/audit", getAuditLogHandler(server))
 
 	return server
 }
@@ -2,27 +2,37 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"customer-manager/database"
+	"customer-manager/pdf"
 	"customer-manager/repositories"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
 )
 
 type StubCustomerRepository struct {
 	customerIDToCreate string
 	customers          []database.Customer
+	deletedCustomers   []database.Customer
+	lastFilter         repositories.CustomerFilter
+	matches            []repositories.CustomerMatch
+	lastSearch         string
 }
 
-func (s *StubCustomerRepository) Create(customer *database.Customer) (error, *database.Customer) {
+func (s *StubCustomerRepository) Create(ctx context.Context, customer *database.Customer) (error, *database.Customer) {
 	for _, c := range s.customers {
-		if c.TelephoneNumber == customer.TelephoneNumber {
+		if c.TelephoneNumberE164 != nil && customer.TelephoneNumberE164 != nil &&
+			*c.TelephoneNumberE164 == *customer.TelephoneNumberE164 {
 			return &repositories.DuplicatedTelephoneNumberError{Customer: customer}, nil
 		}
 	}
@@ -31,14 +41,18 @@ func (s *StubCustomerRepository) Create(customer *database.Customer) (error, *da
 	return nil, customer
 }
 
-func (s *StubCustomerRepository) DeleteByID(customerID string) error {
-	err, customerToDelete := s.GetByID(customerID)
+func (s *StubCustomerRepository) DeleteByID(ctx context.Context, customerID string, version int) error {
+	err, customerToDelete := s.GetByID(ctx, customerID)
 	if err != nil {
 		return err
 	}
+	if customerToDelete.Version != version {
+		return &repositories.VersionConflictError{ID: customerID, Version: version}
+	}
 	for index, customer := range s.customers {
 		if customer.ID == customerToDelete.ID {
 			s.customers = append(s.customers[:index], s.customers[index+1:]...)
+			s.deletedCustomers = append(s.deletedCustomers, customer)
 			return nil
 		}
 	}
@@ -46,20 +60,71 @@ func (s *StubCustomerRepository) DeleteByID(customerID string) error {
 }
 
 func (s *StubCustomerRepository) ListBy(
-	firstName string,
-	lastName string,
-	limit int,
-	offset int,
-) (error, []database.Customer, int) {
+	ctx context.Context,
+	filter repositories.CustomerFilter,
+) (error, repositories.Page[database.Customer], int) {
+	s.lastFilter = filter
 	total := len(s.customers)
-	if limit > len(s.customers) {
-		return nil, s.customers, total
+	if filter.TelephoneNumber != "" || filter.Email != "" {
+		var customers []database.Customer
+		for _, customer := range s.customers {
+			if filter.TelephoneNumber != "" &&
+				(customer.TelephoneNumberE164 == nil || *customer.TelephoneNumberE164 != filter.TelephoneNumber) {
+				continue
+			}
+			if strings.Contains(customer.Email, filter.Email) {
+				customers = append(customers, customer)
+			}
+		}
+		return nil, repositories.Page[database.Customer]{Items: customers}, len(customers)
+	}
+	if filter.Cursor != nil {
+		return nil, stubPage(s.customers, filter.Cursor, filter.Limit, func(c database.Customer) string { return c.ID }), total
+	}
+	if filter.Limit > len(s.customers) {
+		return nil, repositories.Page[database.Customer]{Items: s.customers}, total
+	}
+	customers := s.customers[filter.Offset : filter.Offset+filter.Limit]
+	return nil, repositories.Page[database.Customer]{Items: customers}, total
+}
+
+func (s *StubCustomerRepository) Search(
+	ctx context.Context,
+	query string,
+	limit int,
+) (error, []repositories.CustomerMatch) {
+	s.lastSearch = query
+	if limit < len(s.matches) {
+		return nil, s.matches[:limit]
+	}
+	return nil, s.matches
+}
+
+// stubPage returns the page of items following the cursor, which holds the ID of the preceding item.
+func stubPage[T any](items []T, cursor *repositories.Cursor, limit int, id func(T) string) repositories.Page[T] {
+	start := 0
+	if cursor != nil {
+		for i, item := range items {
+			if id(item) == cursor.Values[0] {
+				start = i + 1
+			}
+		}
+	}
+	var page repositories.Page[T]
+	end := start + limit
+	if end >= len(items) {
+		end = len(items)
+	} else {
+		page.Next = &repositories.Cursor{Values: []string{id(items[end-1])}}
 	}
-	customers := s.customers[offset : offset+limit]
-	return nil, customers, total
+	if start > 0 {
+		page.Prev = &repositories.Cursor{Values: []string{id(items[start])}, Backward: true}
+	}
+	page.Items = items[start:end]
+	return page
 }
 
-func (s *StubCustomerRepository) GetByID(customerID string) (error, *database.Customer) {
+func (s *StubCustomerRepository) GetByID(ctx context.Context, customerID string) (error, *database.Customer) {
 	for _, customer := range s.customers {
 		if customer.ID == customerID {
 			return nil, &customer
@@ -69,24 +134,50 @@ func (s *StubCustomerRepository) GetByID(customerID string) (error, *database.Cu
 }
 
 func (s *StubCustomerRepository) Update(
+	ctx context.Context,
 	customerDetails *database.Customer,
+	fields ...string,
 ) (error, *database.Customer) {
-	err, customer := s.GetByID(customerDetails.ID)
+	err, customer := s.GetByID(ctx, customerDetails.ID)
 	if err != nil {
 		return err, nil
 	}
+	if customer.Version != customerDetails.Version {
+		return &repositories.VersionConflictError{ID: customer.ID, Version: customerDetails.Version}, nil
+	}
 	customer.FirstName = customerDetails.FirstName
 	customer.LastName = customerDetails.LastName
 	customer.TelephoneNumber = customerDetails.TelephoneNumber
+	customer.TelephoneNumberE164 = customerDetails.TelephoneNumberE164
+	customer.Email = customerDetails.Email
+	customer.Address = customerDetails.Address
+	customer.BirthDate = customerDetails.BirthDate
+	customer.PreferredContact = customerDetails.PreferredContact
+	customer.Notes = customerDetails.Notes
+	customer.Version++
 	return nil, customer
 }
 
+func (s *StubCustomerRepository) Restore(ctx context.Context, customerID string) (error, *database.Customer) {
+	for idx, customer := range s.deletedCustomers {
+		if customer.ID == customerID {
+			s.deletedCustomers = append(s.deletedCustomers[:idx], s.deletedCustomers[idx+1:]...)
+			customer.Version++
+			s.customers = append(s.customers, customer)
+			return nil, &customer
+		}
+	}
+	return s.GetByID(ctx, customerID)
+}
+
 type StubPurchaseRepository struct {
 	purchaseIDToCreate string
 	purchases          []database.Purchase
+	deletedPurchases   []database.Purchase
 }
 
 func (s *StubPurchaseRepository) Create(
+	ctx context.Context,
 	customer *database.Customer,
 	purchase *database.Purchase,
 ) (error, *database.Purchase) {
@@ -94,12 +185,13 @@ func (s *StubPurchaseRepository) Create(
 	if purchase.ID == "" {
 		purchase.ID = s.purchaseIDToCreate
 	}
+	purchase.CalculateTotals()
 	customer.Purchases = append(customer.Purchases, *purchase)
 	s.purchases = append(s.purchases, *purchase)
 	return nil, purchase
 }
 
-func (s *StubPurchaseRepository) GetAll(customerID string) (error, []database.Purchase) {
+func (s *StubPurchaseRepository) GetAll(ctx context.Context, customerID string) (error, []database.Purchase) {
 	var customerPurchases []database.Purchase
 	for _, purchase := range s.purchases {
 		if purchase.CustomerID == customerID {
@@ -109,36 +201,84 @@ func (s *StubPurchaseRepository) GetAll(customerID string) (error, []database.Pu
 	return nil, customerPurchases
 }
 
-func (s *StubPurchaseRepository) DeleteByID(purchaseID string) error {
+func (s *StubPurchaseRepository) GetPage(
+	ctx context.Context,
+	customerID string,
+	cursor *repositories.Cursor,
+	limit int,
+) (error, repositories.Page[database.Purchase]) {
+	_, purchases := s.GetAll(ctx, customerID)
+	return nil, stubPage(purchases, cursor, limit, func(p database.Purchase) string { return p.ID })
+}
+
+func (s *StubPurchaseRepository) GetByID(ctx context.Context, purchaseID string) (error, *database.Purchase) {
+	for _, purchase := range s.purchases {
+		if purchase.ID == purchaseID {
+			return nil, &purchase
+		}
+	}
+	return &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}, nil
+}
+
+func (s *StubPurchaseRepository) DeleteByID(ctx context.Context, purchaseID string, version int) error {
 	for idx, purchase := range s.purchases {
 		if purchase.ID == purchaseID {
+			if purchase.Version != version {
+				return &repositories.VersionConflictError{ID: purchaseID, Version: version}
+			}
 			s.purchases = append(s.purchases[:idx], s.purchases[idx+1:]...)
+			s.deletedPurchases = append(s.deletedPurchases, purchase)
 			return nil
 		}
 	}
 	return &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}
 }
 
-func (s *StubPurchaseRepository) Update(purchase *database.Purchase) (error, *database.Purchase) {
-	err, purchases := s.GetAll(purchase.CustomerID)
-	if err != nil {
-		return err, nil
-	}
-	for idx, currentPurchase := range purchases {
+func (s *StubPurchaseRepository) Update(
+	ctx context.Context,
+	purchase *database.Purchase,
+	fields ...string,
+) (error, *database.Purchase) {
+	for idx, currentPurchase := range s.purchases {
 		if currentPurchase.ID == purchase.ID {
-			purchases[idx] = *purchase
+			if currentPurchase.Version != purchase.Version {
+				return &repositories.VersionConflictError{ID: purchase.ID, Version: purchase.Version}, nil
+			}
+			purchase.Version++
+			purchase.CalculateTotals()
+			s.purchases[idx] = *purchase
 			return nil, purchase
 		}
 	}
 	return &repositories.PurchaseNotFoundError{PurchaseID: purchase.ID}, nil
 }
 
+func (s *StubPurchaseRepository) Restore(ctx context.Context, purchaseID string) (error, *database.Purchase) {
+	for idx, purchase := range s.deletedPurchases {
+		if purchase.ID == purchaseID {
+			s.deletedPurchases = append(s.deletedPurchases[:idx], s.deletedPurchases[idx+1:]...)
+			purchase.Version++
+			s.purchases = append(s.purchases, purchase)
+			return nil, &purchase
+		}
+	}
+	for _, purchase := range s.purchases {
+		if purchase.ID == purchaseID {
+			return nil, &purchase
+		}
+	}
+	return &repositories.PurchaseNotFoundError{PurchaseID: purchaseID}, nil
+}
+
 type StubRepairRepository struct {
 	repairIDToCreate string
 	repairs          []database.Repair
+	deletedRepairs   []database.Repair
+	statusChangedAt  time.Time
 }
 
 func (s *StubRepairRepository) Create(
+	ctx context.Context,
 	customer *database.Customer,
 	repair *database.Repair,
 ) (error, *database.Repair) {
@@ -151,7 +291,7 @@ func (s *StubRepairRepository) Create(
 	return nil, repair
 }
 
-func (s *StubRepairRepository) GetAll(customerID string) (error, []database.Repair) {
+func (s *StubRepairRepository) GetAll(ctx context.Context, customerID string) (error, []database.Repair) {
 	var customerRepairs []database.Repair
 	for _, repair := range s.repairs {
 		if repair.CustomerID == customerID {
@@ -161,16 +301,431 @@ func (s *StubRepairRepository) GetAll(customerID string) (error, []database.Repa
 	return nil, customerRepairs
 }
 
-func (s *StubRepairRepository) DeleteByID(repairID string) error {
+func (s *StubRepairRepository) GetPage(
+	ctx context.Context,
+	customerID string,
+	cursor *repositories.Cursor,
+	limit int,
+) (error, repositories.Page[database.Repair]) {
+	_, repairs := s.GetAll(ctx, customerID)
+	return nil, stubPage(repairs, cursor, limit, func(r database.Repair) string { return r.ID })
+}
+
+func (s *StubRepairRepository) ListByStatus(ctx context.Context, statuses []database.RepairStatus) (error, []database.Repair) {
+	var repairs []database.Repair
+	for _, repair := range s.repairs {
+		if slices.Contains(statuses, repair.Status) {
+			repairs = append(repairs, repair)
+		}
+	}
+	return nil, repairs
+}
+
+func (s *StubRepairRepository) GetByID(ctx context.Context, repairID string) (error, *database.Repair) {
+	for _, repair := range s.repairs {
+		if repair.ID == repairID {
+			return nil, &repair
+		}
+	}
+	return &repositories.RepairNotFoundError{RepairID: repairID}, nil
+}
+
+func (s *StubRepairRepository) Update(
+	ctx context.Context,
+	repair *database.Repair,
+	fields ...string,
+) (error, *database.Repair) {
+	for idx, currentRepair := range s.repairs {
+		if currentRepair.ID == repair.ID {
+			if currentRepair.Version != repair.Version {
+				return &repositories.VersionConflictError{ID: repair.ID, Version: repair.Version}, nil
+			}
+			repair.Version++
+			s.repairs[idx] = *repair
+			return nil, repair
+		}
+	}
+	return &repositories.RepairNotFoundError{RepairID: repair.ID}, nil
+}
+
+func (s *StubRepairRepository) UpdateStatus(
+	ctx context.Context,
+	repairID string,
+	status database.RepairStatus,
+	version int,
+) (error, *database.Repair) {
+	err, repair := s.GetByID(ctx, repairID)
+	if err != nil {
+		return err, nil
+	}
+	if repair.Version != version {
+		return &repositories.VersionConflictError{ID: repairID, Version: version}, nil
+	}
+	if !repair.Status.CanTransitionTo(status) {
+		return &repositories.InvalidRepairStatusTransitionError{RepairID: repairID, From: repair.Status, To: status}, nil
+	}
+	repair.SetStatus(status, s.statusChangedAt)
+	return s.Update(ctx, repair)
+}
+
+func (s *StubRepairRepository) DeleteByID(ctx context.Context, repairID string, version int) error {
 	for idx, repair := range s.repairs {
 		if repair.ID == repairID {
+			if repair.Version != version {
+				return &repositories.VersionConflictError{ID: repairID, Version: version}
+			}
 			s.repairs = append(s.repairs[:idx], s.repairs[idx+1:]...)
+			s.deletedRepairs = append(s.deletedRepairs, repair)
 			return nil
 		}
 	}
 	return &repositories.RepairNotFoundError{RepairID: repairID}
 }
 
+func (s *StubRepairRepository) Restore(ctx context.Context, repairID string) (error, *database.Repair) {
+	for idx, repair := range s.deletedRepairs {
+		if repair.ID == repairID {
+			s.deletedRepairs = append(s.deletedRepairs[:idx], s.deletedRepairs[idx+1:]...)
+			repair.Version++
+			s.repairs = append(s.repairs, repair)
+			return nil, &repair
+		}
+	}
+	return s.GetByID(ctx, repairID)
+}
+
+type StubPrescriptionRepository struct {
+	prescriptionIDToCreate string
+	prescriptions          []database.Prescription
+}
+
+func (s *StubPrescriptionRepository) Create(
+	ctx context.Context,
+	customer *database.Customer,
+	prescription *database.Prescription,
+) (error, *database.Prescription) {
+	prescription.CustomerID = customer.ID
+	if prescription.ID == "" {
+		prescription.ID = s.prescriptionIDToCreate
+	}
+	customer.Prescriptions = append(customer.Prescriptions, *prescription)
+	s.prescriptions = append(s.prescriptions, *prescription)
+	return nil, prescription
+}
+
+func (s *StubPrescriptionRepository) GetAll(ctx context.Context, customerID string) (error, []database.Prescription) {
+	var customerPrescriptions []database.Prescription
+	for _, prescription := range s.prescriptions {
+		if prescription.CustomerID == customerID {
+			customerPrescriptions = append(customerPrescriptions, prescription)
+		}
+	}
+	return nil, customerPrescriptions
+}
+
+func (s *StubPrescriptionRepository) GetByID(ctx context.Context, prescriptionID string) (error, *database.Prescription) {
+	for _, prescription := range s.prescriptions {
+		if prescription.ID == prescriptionID {
+			return nil, &prescription
+		}
+	}
+	return &repositories.PrescriptionNotFoundError{PrescriptionID: prescriptionID}, nil
+}
+
+func (s *StubPrescriptionRepository) Update(ctx context.Context, prescription *database.Prescription) (error, *database.Prescription) {
+	for idx, currentPrescription := range s.prescriptions {
+		if currentPrescription.ID == prescription.ID {
+			s.prescriptions[idx] = *prescription
+			return nil, prescription
+		}
+	}
+	return &repositories.PrescriptionNotFoundError{PrescriptionID: prescription.ID}, nil
+}
+
+func (s *StubPrescriptionRepository) DeleteByID(ctx context.Context, prescriptionID string) error {
+	for idx, prescription := range s.prescriptions {
+		if prescription.ID == prescriptionID {
+			s.prescriptions = append(s.prescriptions[:idx], s.prescriptions[idx+1:]...)
+			return nil
+		}
+	}
+	return &repositories.PrescriptionNotFoundError{PrescriptionID: prescriptionID}
+}
+
+type StubInvoiceRepository struct {
+	invoiceIDToCreate string
+	invoices          []database.Invoice
+}
+
+func (s *StubInvoiceRepository) Create(ctx context.Context, invoice *database.Invoice) (error, *database.Invoice) {
+	for _, item := range invoice.Items {
+		for _, existing := range s.invoices {
+			for _, invoiced := range existing.Items {
+				if item.PurchaseID != nil && invoiced.PurchaseID != nil && *item.PurchaseID == *invoiced.PurchaseID {
+					return &repositories.AlreadyInvoicedError{
+						Entity:        "purchase",
+						ID:            *item.PurchaseID,
+						InvoiceNumber: existing.Number,
+					}, nil
+				}
+			}
+		}
+	}
+	invoice.ID = s.invoiceIDToCreate
+	invoice.SetNumber(len(s.invoices) + 1)
+	for i := range invoice.Items {
+		invoice.Items[i].Position = i + 1
+	}
+	invoice.CalculateTotals()
+	s.invoices = append(s.invoices, *invoice)
+	return nil, invoice
+}
+
+func (s *StubInvoiceRepository) GetAll(ctx context.Context, customerID string) (error, []database.Invoice) {
+	var customerInvoices []database.Invoice
+	for _, invoice := range s.invoices {
+		if invoice.CustomerID == customerID {
+			customerInvoices = append(customerInvoices, invoice)
+		}
+	}
+	return nil, customerInvoices
+}
+
+func (s *StubInvoiceRepository) GetByID(ctx context.Context, invoiceID string) (error, *database.Invoice) {
+	for _, invoice := range s.invoices {
+		if invoice.ID == invoiceID {
+			return nil, &invoice
+		}
+	}
+	return &repositories.InvoiceNotFoundError{InvoiceID: invoiceID}, nil
+}
+
+// StubPaymentRepository records payments, unless createError is set, and returns the given balances.
+type StubPaymentRepository struct {
+	paymentIDToCreate string
+	payments          []database.Payment
+	createError       error
+	balance           *repositories.CustomerBalance
+	unpaid            []repositories.UnpaidBalance
+}
+
+func (s *StubPaymentRepository) Create(ctx context.Context, payment *database.Payment) (error, *database.Payment) {
+	if s.createError != nil {
+		return s.createError, nil
+	}
+	payment.ID = s.paymentIDToCreate
+	s.payments = append(s.payments, *payment)
+	return nil, payment
+}
+
+func (s *StubPaymentRepository) GetAll(ctx context.Context, customerID string) (error, []database.Payment) {
+	var customerPayments []database.Payment
+	for _, payment := range s.payments {
+		if payment.CustomerID == customerID {
+			customerPayments = append(customerPayments, payment)
+		}
+	}
+	return nil, customerPayments
+}
+
+func (s *StubPaymentRepository) GetBalance(
+	ctx context.Context,
+	customerID string,
+) (error, *repositories.CustomerBalance) {
+	return nil, s.balance
+}
+
+func (s *StubPaymentRepository) ListUnpaid(ctx context.Context) (error, []repositories.UnpaidBalance) {
+	return nil, s.unpaid
+}
+
+// StubCatalogRepository keeps products of the catalog, products created get IDs of productIDToCreate.
+type StubCatalogRepository[T repositories.CatalogProduct] struct {
+	productIDToCreate string
+	products          []T
+	filter            repositories.ProductFilter
+}
+
+func stubProduct[T repositories.CatalogProduct](product *T) *database.Product {
+	return any(product).(interface{ GetProduct() *database.Product }).GetProduct()
+}
+
+func (s *StubCatalogRepository[T]) Create(ctx context.Context, product *T) (error, *T) {
+	for i := range s.products {
+		if stubProduct(&s.products[i]).SKU == stubProduct(product).SKU {
+			return &repositories.DuplicatedProductCodeError{Kind: "product", SKU: stubProduct(product).SKU}, nil
+		}
+	}
+	stubProduct(product).ID = s.productIDToCreate
+	s.products = append(s.products, *product)
+	return nil, product
+}
+
+func (s *StubCatalogRepository[T]) List(ctx context.Context, filter repositories.ProductFilter) (error, []T) {
+	s.filter = filter
+	return nil, s.products
+}
+
+func (s *StubCatalogRepository[T]) GetByID(ctx context.Context, productID string) (error, *T) {
+	for i := range s.products {
+		if stubProduct(&s.products[i]).ID == productID {
+			product := s.products[i]
+			return nil, &product
+		}
+	}
+	return &repositories.ProductNotFoundError{Kind: "product", ID: productID}, nil
+}
+
+func (s *StubCatalogRepository[T]) Update(ctx context.Context, product *T) (error, *T) {
+	err, current := s.GetByID(ctx, stubProduct(product).ID)
+	if err != nil {
+		return err, nil
+	}
+	stubProduct(product).Stock = stubProduct(current).Stock
+	for i := range s.products {
+		if stubProduct(&s.products[i]).ID == stubProduct(product).ID {
+			s.products[i] = *product
+		}
+	}
+	return nil, product
+}
+
+func (s *StubCatalogRepository[T]) AdjustStock(
+	ctx context.Context,
+	productID string,
+	quantity int,
+	allowNegative bool,
+) (error, *T) {
+	for i := range s.products {
+		product := stubProduct(&s.products[i])
+		if product.ID != productID {
+			continue
+		}
+		if !allowNegative && product.Stock+quantity < 0 {
+			return &repositories.OutOfStockError{Kind: "product", ID: productID, SKU: product.SKU, Stock: product.Stock}, nil
+		}
+		product.Stock += quantity
+		adjusted := s.products[i]
+		return nil, &adjusted
+	}
+	return &repositories.ProductNotFoundError{Kind: "product", ID: productID}, nil
+}
+
+// StubInventoryRepository returns the given suggestions along with the time they are computed at,
+// counting how many times they are computed.
+type StubInventoryRepository struct {
+	suggestions []repositories.SupplierReorder
+	computed    int
+	policy      repositories.ReorderPolicy
+}
+
+func (s *StubInventoryRepository) GetReorderSuggestions(
+	ctx context.Context,
+	policy repositories.ReorderPolicy,
+	now time.Time,
+) (error, *repositories.ReorderSuggestions) {
+	s.computed++
+	s.policy = policy
+	return nil, &repositories.ReorderSuggestions{ComputedAt: now, Suppliers: s.suggestions}
+}
+
+type StubAuditRepository struct {
+	entries []database.AuditEntry
+	filter  repositories.AuditFilter
+}
+
+func (s *StubAuditRepository) ListBy(
+	ctx context.Context,
+	filter repositories.AuditFilter,
+) (error, []database.AuditEntry, int) {
+	s.filter = filter
+	var entries []database.AuditEntry
+	for _, entry := range s.entries {
+		if filter.EntityID == "" || entry.EntityID == filter.EntityID {
+			entries = append(entries, entry)
+		}
+	}
+	return nil, entries, len(entries)
+}
+
+// StubUnitOfWork runs the work on repositories of the server, without a transaction.
+type StubUnitOfWork struct {
+	server *CustomerManagerServer
+}
+
+func (s *StubUnitOfWork) Do(ctx context.Context, work func(repositories *repositories.Repositories) error) error {
+	return work(&repositories.Repositories{
+		Customers:     s.server.customerRepository,
+		Purchases:     s.server.purchasesRepository,
+		Repairs:       s.server.repairsRepository,
+		Prescriptions: s.server.prescriptionsRepository,
+		Invoices:      s.server.invoicesRepository,
+		Payments:      s.server.paymentsRepository,
+		Frames:        s.server.framesRepository,
+		LensTypes:     s.server.lensTypesRepository,
+	})
+}
+
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+}
+
+func newTestServer(
+	app *fiber.App,
+	customerRepository repositories.CustomerRepository,
+	purchasesRepository repositories.PurchaseRepository,
+	repairsRepository repositories.RepairRepository,
+	prescriptionsRepository repositories.PrescriptionRepository,
+) *CustomerManagerServer {
+	unitOfWork := &StubUnitOfWork{}
+	server := NewCustomerManagerServer(
+		app,
+		customerRepository,
+		purchasesRepository,
+		repairsRepository,
+		prescriptionsRepository,
+		&StubInvoiceRepository{},
+		&StubPaymentRepository{},
+		&StubCatalogRepository[database.Frame]{},
+		&StubCatalogRepository[database.LensType]{},
+		&StubInventoryRepository{},
+		&StubAuditRepository{},
+		unitOfWork,
+	)
+	unitOfWork.server = server
+	return server
+}
+
+// SlowCustomerRepository waits until the request context is done before reading customers.
+type SlowCustomerRepository struct {
+	StubCustomerRepository
+}
+
+func (s *SlowCustomerRepository) ListBy(
+	ctx context.Context,
+	filter repositories.CustomerFilter,
+) (error, repositories.Page[database.Customer], int) {
+	<-ctx.Done()
+	return ctx.Err(), repositories.Page[database.Customer]{}, 0
+}
+
+func (s *SlowCustomerRepository) GetByID(ctx context.Context, customerID string) (error, *database.Customer) {
+	<-ctx.Done()
+	return ctx.Err(), nil
+}
+
+// FailingCustomerRepository fails to list customers with an unexpected error.
+type FailingCustomerRepository struct {
+	StubCustomerRepository
+}
+
+func (f *FailingCustomerRepository) ListBy(
+	ctx context.Context,
+	filter repositories.CustomerFilter,
+) (error, repositories.Page[database.Customer], int) {
+	return errors.New("dial tcp 127.0.0.1:3306: connection refused"), repositories.Page[database.Customer]{}, 0
+}
+
 func getCustomer() database.Customer {
 	return database.Customer{
 		ID:              "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
@@ -180,6 +735,28 @@ func getCustomer() database.Customer {
 	}
 }
 
+func emptyAddressResponse() map[string]any {
+	return map[string]any{"street": "", "city": "", "postal_code": "", "country": ""}
+}
+
+func moneyResponse(amount string, currency string) map[string]any {
+	return map[string]any{"amount": amount, "currency": currency}
+}
+
+func getLensPower() database.LensPower {
+	return database.LensPower{
+		Right: database.EyePrescription{Sphere: -1.25, Cylinder: -0.5, Axis: 90},
+		Left:  database.EyePrescription{Sphere: -1, Add: 2},
+	}
+}
+
+func getLensPowerResponse() map[string]any {
+	return map[string]any{
+		"right": map[string]any{"sphere": -1.25, "cylinder": -0.5, "axis": 90.0, "add": 0.0, "prism": 0.0, "base": ""},
+		"left":  map[string]any{"sphere": -1.0, "cylinder": 0.0, "axis": 0.0, "add": 2.0, "prism": 0.0, "base": ""},
+	}
+}
+
 func makeRequest(t *testing.T, method string, path string, body io.Reader) *http.Request {
 	t.Helper()
 	req, err := http.NewRequest(method, path, body)
@@ -217,23 +794,42 @@ func decodeCustomers(t *testing.T, body io.Reader) []database.Customer {
 func assertCustomerDetailsResponse(
 	t *testing.T,
 	resp *http.Response,
-	expectedCustomerDetails map[string]string,
+	expectedCustomerDetails map[string]any,
 ) {
 	t.Helper()
 	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
-	actualCustomerDetails := make(map[string]string)
+	actualCustomerDetails := make(map[string]any)
 	err := json.NewDecoder(resp.Body).Decode(&actualCustomerDetails)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCustomerDetails, actualCustomerDetails)
 }
 
+// assertResponse checks that the response holds problem details with the expected detail.
 func assertResponse(t *testing.T, resp *http.Response, expectedDetails map[string]string) {
 	t.Helper()
-	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
-	actualDetails := make(map[string]string)
-	err := json.NewDecoder(resp.Body).Decode(&actualDetails)
-	assert.NoError(t, err)
-	assert.Equal(t, expectedDetails, actualDetails)
+	problem := decodeProblem(t, resp)
+	assert.Equal(t, expectedDetails["detail"], problem.Detail)
+	assert.Nil(t, problem.Errors)
+}
+
+func decodeProblem(t *testing.T, resp *http.Response) Problem {
+	t.Helper()
+	assert.Equal(t, MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, resp.StatusCode, problem.Status)
+	assert.NotEmpty(t, problem.Type)
+	assert.NotEmpty(t, problem.Title)
+	assert.Equal(t, resp.Request.URL.Path, problem.Instance)
+	return problem
+}
+
+func assertValidationProblemResponse(t *testing.T, resp *http.Response, expectedErrors map[string][]FieldError) {
+	t.Helper()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	problem := decodeProblem(t, resp)
+	assert.Equal(t, ProblemTypeValidation, problem.Type)
+	assert.Equal(t, expectedErrors, problem.Errors)
 }
 
 func assertBadRequestResponse(
@@ -258,11 +854,13 @@ func TestCustomerHandlers(t *testing.T) {
 		LastName:        "Doe",
 		TelephoneNumber: "123-456-789",
 	}
-	server := NewCustomerManagerServer(
-		fiber.New(),
+	telephoneNumberE164 := "+48123456789"
+	server := newTestServer(
+		newTestApp(),
 		&StubCustomerRepository{},
 		&StubPurchaseRepository{},
 		&StubRepairRepository{},
+		&StubPrescriptionRepository{},
 	)
 
 	t.Run("test get all customers", func(t *testing.T) {
@@ -293,23 +891,41 @@ func TestCustomerHandlers(t *testing.T) {
 			fiber.Map{
 				"data": []interface{}{
 					map[string]interface{}{
-						"created_at":       "0001-01-01T00:00:00Z",
-						"first_name":       "Jane",
-						"id":               "7dd4ace2-d792-4532-bda2-c986a9a04363",
-						"last_name":        "Doe",
-						"telephone_number": "123567848",
-						"updated_at":       "0001-01-01T00:00:00Z",
+						"created_at":            "0001-01-01T00:00:00Z",
+						"first_name":            "Jane",
+						"id":                    "7dd4ace2-d792-4532-bda2-c986a9a04363",
+						"last_name":             "Doe",
+						"telephone_number":      "123567848",
+						"telephone_number_e164": nil,
+						"email":                 "",
+						"address":               emptyAddressResponse(),
+						"birth_date":            nil,
+						"preferred_contact":     "",
+						"notes":                 "",
+						"updated_at":            "0001-01-01T00:00:00Z",
+						"deleted_at":            nil,
+						"version":               0.0,
 					},
 					map[string]interface{}{
-						"created_at":       "0001-01-01T00:00:00Z",
-						"first_name":       "Bob",
-						"id":               "8a5cae65-222c-4164-a08b-9983af7e366c",
-						"last_name":        "Toe",
-						"telephone_number": "367654567",
-						"updated_at":       "0001-01-01T00:00:00Z",
+						"created_at":            "0001-01-01T00:00:00Z",
+						"first_name":            "Bob",
+						"id":                    "8a5cae65-222c-4164-a08b-9983af7e366c",
+						"last_name":             "Toe",
+						"telephone_number":      "367654567",
+						"telephone_number_e164": nil,
+						"email":                 "",
+						"address":               emptyAddressResponse(),
+						"birth_date":            nil,
+						"preferred_contact":     "",
+						"notes":                 "",
+						"updated_at":            "0001-01-01T00:00:00Z",
+						"deleted_at":            nil,
+						"version":               0.0,
 					},
 				},
-				"total": 2.0,
+				"total":       2.0,
+				"next_cursor": nil,
+				"prev_cursor": nil,
 			},
 			actualCustomers,
 		)
@@ -347,23 +963,41 @@ func TestCustomerHandlers(t *testing.T) {
 		assert.Equal(t, fiber.Map{
 			"data": []interface{}{
 				map[string]interface{}{
-					"created_at":       "0001-01-01T00:00:00Z",
-					"first_name":       "Bob",
-					"id":               "8a5cae65-222c-4164-a08b-9983af7e366c",
-					"last_name":        "Toe",
-					"telephone_number": "367654567",
-					"updated_at":       "0001-01-01T00:00:00Z",
+					"created_at":            "0001-01-01T00:00:00Z",
+					"first_name":            "Bob",
+					"id":                    "8a5cae65-222c-4164-a08b-9983af7e366c",
+					"last_name":             "Toe",
+					"telephone_number":      "367654567",
+					"telephone_number_e164": nil,
+					"email":                 "",
+					"address":               emptyAddressResponse(),
+					"birth_date":            nil,
+					"preferred_contact":     "",
+					"notes":                 "",
+					"updated_at":            "0001-01-01T00:00:00Z",
+					"deleted_at":            nil,
+					"version":               0.0,
 				},
 				map[string]interface{}{
-					"created_at":       "0001-01-01T00:00:00Z",
-					"first_name":       "Joe",
-					"id":               "325cae65-222c-4164-a08b-9983af7e366c",
-					"last_name":        "Doe",
-					"telephone_number": "567231123",
-					"updated_at":       "0001-01-01T00:00:00Z",
+					"created_at":            "0001-01-01T00:00:00Z",
+					"first_name":            "Joe",
+					"id":                    "325cae65-222c-4164-a08b-9983af7e366c",
+					"last_name":             "Doe",
+					"telephone_number":      "567231123",
+					"telephone_number_e164": nil,
+					"email":                 "",
+					"address":               emptyAddressResponse(),
+					"birth_date":            nil,
+					"preferred_contact":     "",
+					"notes":                 "",
+					"updated_at":            "0001-01-01T00:00:00Z",
+					"deleted_at":            nil,
+					"version":               0.0,
 				},
 			},
-			"total": 3.0,
+			"total":       3.0,
+			"next_cursor": nil,
+			"prev_cursor": nil,
 		}, actualCustomers)
 	})
 
@@ -379,81 +1013,450 @@ func TestCustomerHandlers(t *testing.T) {
 		err := json.NewDecoder(resp.Body).Decode(&actualCustomers)
 		assert.NoError(t, err)
 		assert.Equal(t, fiber.Map{
-			"data":  nil,
-			"total": 0.0,
+			"data":        nil,
+			"total":       0.0,
+			"next_cursor": nil,
+			"prev_cursor": nil,
 		}, actualCustomers)
 	})
 
-	t.Run("test create new customer", func(t *testing.T) {
-		server.customerRepository = &StubCustomerRepository{
-			customerIDToCreate: "67a85348-2afe-4677-99ce-ed7cdc17e525",
+	t.Run("test get customers by telephone number in another format", func(t *testing.T) {
+		otherTelephoneNumberE164 := "+48367654567"
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{
+			{
+				ID:                  "7dd4ace2-d792-4532-bda2-c986a9a04363",
+				FirstName:           "Jane",
+				LastName:            "Doe",
+				TelephoneNumber:     "12 345 67 89",
+				TelephoneNumberE164: &telephoneNumberE164,
+			},
+			{
+				ID:                  "8a5cae65-222c-4164-a08b-9983af7e366c",
+				FirstName:           "Bob",
+				LastName:            "Toe",
+				TelephoneNumber:     "36 765 45 67",
+				TelephoneNumberE164: &otherTelephoneNumberE164,
+			},
+		}}
+		req := makeRequest(t, http.MethodGet, "/api/customers?telephoneNumber=0048%20123-456-789", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualCustomers struct {
+			Data []database.Customer `json:"data"`
 		}
-		body, _ := json.Marshal(&customer)
-		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualCustomers))
+		assert.Len(t, actualCustomers.Data, 1)
+		assert.Equal(t, "7dd4ace2-d792-4532-bda2-c986a9a04363", actualCustomers.Data[0].ID)
+	})
+
+	t.Run("test get customers with filters and sorting", func(t *testing.T) {
+		customerRepository := &StubCustomerRepository{}
+		server.customerRepository = customerRepository
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			"/api/customers?q=john%20doe&createdFrom=2023-01-01&createdTo=2023-01-31&hasOpenRepairs=true"+
+				"&purchasedSince=2023-06-01&sort=last_name,-created_at&limit=20&offset=40",
+			nil,
+		)
 
 		resp := getResponse(t, server, req)
 
-		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
-		assertCustomerDetailsResponse(t, resp, map[string]string{
-			"id":               "67a85348-2afe-4677-99ce-ed7cdc17e525",
-			"first_name":       "John",
-			"last_name":        "Doe",
-			"telephone_number": "123-456-789",
-			"created_at":       "0001-01-01T00:00:00Z",
-			"updated_at":       "0001-01-01T00:00:00Z",
-		})
-		_, currentCustomers, total := server.customerRepository.ListBy("", "", 10, 0)
-		customer.ID = "67a85348-2afe-4677-99ce-ed7cdc17e525"
-		assert.ElementsMatch(t, []database.Customer{customer}, currentCustomers)
-		assert.Equal(t, 1, total)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		hasOpenRepairs := true
+		assert.Equal(t, repositories.CustomerFilter{
+			Q:              "john doe",
+			CreatedFrom:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedBefore:  time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			HasOpenRepairs: &hasOpenRepairs,
+			PurchasedSince: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			Sort: []repositories.SortField{
+				{Field: "last_name"},
+				{Field: "created_at", Descending: true},
+			},
+			Limit:  20,
+			Offset: 40,
+		}, customerRepository.lastFilter)
 	})
 
-	t.Run("test create new customer duplicated telephone number", func(t *testing.T) {
-		server.customerRepository = &StubCustomerRepository{
-			customers: []database.Customer{{FirstName: "Bob", LastName: "Smith", TelephoneNumber: "123-456-789"}},
+	t.Run("test get customers with invalid filters", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		for query, detail := range map[string]string{
+			"sort=last_name,password":  "given sort field 'password' is not one of first_name, last_name, email, created_at, updated_at",
+			"createdFrom=2023-13-01":   "given createdFrom date '2023-13-01' is not a valid date in YYYY-MM-DD format",
+			"purchasedSince=yesterday": "given purchasedSince date 'yesterday' is not a valid date in YYYY-MM-DD format",
+			"hasOpenRepairs=maybe":     "given hasOpenRepairs value 'maybe' is not a valid boolean",
+		} {
+			req := makeRequest(t, http.MethodGet, "/api/customers?"+query, nil)
+
+			resp := getResponse(t, server, req)
+
+			assertBadRequestResponse(t, resp, map[string]string{"detail": detail})
 		}
-		body, _ := json.Marshal(&customer)
-		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+	})
+
+	t.Run("test get customers following cursor", func(t *testing.T) {
+		customerRepository := &StubCustomerRepository{customers: []database.Customer{
+			{ID: "7dd4ace2-d792-4532-bda2-c986a9a04363", FirstName: "Jane"},
+			{ID: "8a5cae65-222c-4164-a08b-9983af7e366c", FirstName: "Bob"},
+			{ID: "325cae65-222c-4164-a08b-9983af7e366c", FirstName: "Joe"},
+		}}
+		server.customerRepository = customerRepository
+		cursor := encodeCursor(&repositories.Cursor{Values: []string{"7dd4ace2-d792-4532-bda2-c986a9a04363"}})
+		req := makeRequest(t, http.MethodGet, "/api/customers?limit=1&cursor="+*cursor, nil)
 
 		resp := getResponse(t, server, req)
 
-		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
-		assertCustomerDetailsResponse(t, resp, map[string]string{
-			"detail": "customer 'John Doe' cannot have telephone number '123-456-789' as already taken.",
-		})
-		_, _, total := server.customerRepository.ListBy("", "", 10, 0)
-		assert.Equal(t, 1, total)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, &repositories.Cursor{Values: []string{"7dd4ace2-d792-4532-bda2-c986a9a04363"}},
+			customerRepository.lastFilter.Cursor)
+		var page struct {
+			Data       []database.Customer `json:"data"`
+			Total      int                 `json:"total"`
+			NextCursor *string             `json:"next_cursor"`
+			PrevCursor *string             `json:"prev_cursor"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		assert.Len(t, page.Data, 1)
+		assert.Equal(t, "8a5cae65-222c-4164-a08b-9983af7e366c", page.Data[0].ID)
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, encodeCursor(&repositories.Cursor{Values: []string{"8a5cae65-222c-4164-a08b-9983af7e366c"}}),
+			page.NextCursor)
+		assert.Equal(t, encodeCursor(
+			&repositories.Cursor{Values: []string{"8a5cae65-222c-4164-a08b-9983af7e366c"}, Backward: true},
+		), page.PrevCursor)
 	})
 
-	t.Run("test create new customer invalid payload", func(t *testing.T) {
+	t.Run("test get customers with invalid cursor", func(t *testing.T) {
 		server.customerRepository = &StubCustomerRepository{}
-		body, _ := json.Marshal(
-			map[string]string{"invalid": "invalid"},
-		) // TODO test unique tel. number
-		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+		req := makeRequest(t, http.MethodGet, "/api/customers?cursor=not-a-cursor", nil)
 
 		resp := getResponse(t, server, req)
 
-		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
-		assert.Equal(t, resp.Header.Get("Content-Type"), "application/json")
+		assertBadRequestResponse(t, resp, map[string]string{"detail": "given cursor 'not-a-cursor' is not valid"})
+	})
 
-		actualErrorMessage := make(map[string]map[string]string)
-		err := json.NewDecoder(resp.Body).Decode(&actualErrorMessage)
-		assert.NoError(t, err)
-		assert.Equal(
-			t,
-			map[string]map[string]string{
-				"first_name":       {"required": "The 'first_name' is required"},
-				"last_name":        {"required": "The 'last_name' is required"},
-				"telephone_number": {"required": "The 'telephone_number' is required"},
+	t.Run("test search customers", func(t *testing.T) {
+		customerRepository := &StubCustomerRepository{matches: []repositories.CustomerMatch{
+			{
+				Customer: database.Customer{ID: "7dd4ace2-d792-4532-bda2-c986a9a04363", LastName: "Wiśniewski"},
+				Score:    0.9,
+				Spans:    []repositories.MatchSpan{{Field: "last_name", Start: 0, End: 10}},
+			},
+			{Customer: database.Customer{ID: "8a5cae65-222c-4164-a08b-9983af7e366c", LastName: "Wiśniewska"}, Score: 0.8},
+		}}
+		server.customerRepository = customerRepository
+		req := makeRequest(t, http.MethodGet, "/api/customers/search?q=wisniewsky&limit=1", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "wisniewsky", customerRepository.lastSearch)
+		var body struct {
+			Data []repositories.CustomerMatch `json:"data"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, customerRepository.matches[:1], body.Data)
+	})
+
+	t.Run("test search customers without query", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		req := makeRequest(t, http.MethodGet, "/api/customers/search?q=%20", nil)
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{"detail": "search query q is required"})
+	})
+
+	t.Run("test get customers by email", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{
+			{
+				ID:              "7dd4ace2-d792-4532-bda2-c986a9a04363",
+				FirstName:       "Jane",
+				LastName:        "Doe",
+				TelephoneNumber: "12 345 67 89",
+				Email:           "jane.doe@example.com",
+			},
+			{
+				ID:              "8a5cae65-222c-4164-a08b-9983af7e366c",
+				FirstName:       "Bob",
+				LastName:        "Toe",
+				TelephoneNumber: "36 765 45 67",
+				Email:           "bob@example.org",
 			},
-			actualErrorMessage,
+		}}
+		req := makeRequest(t, http.MethodGet, "/api/customers?email=example.com", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualCustomers struct {
+			Data []database.Customer `json:"data"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualCustomers))
+		assert.Len(t, actualCustomers.Data, 1)
+		assert.Equal(t, "7dd4ace2-d792-4532-bda2-c986a9a04363", actualCustomers.Data[0].ID)
+	})
+
+	t.Run("test create new customer with telephone number of configured region", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{
+			customerIDToCreate: "67a85348-2afe-4677-99ce-ed7cdc17e525",
+		}
+		server.TelephoneRegion = "DE"
+		defer func() { server.TelephoneRegion = database.DefaultTelephoneRegion }()
+		body, _ := json.Marshal(map[string]string{
+			"first_name":       "Hans",
+			"last_name":        "Schmidt",
+			"telephone_number": "030 1234567",
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var createdCustomer database.Customer
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&createdCustomer))
+		assert.Equal(t, "030 1234567", createdCustomer.TelephoneNumber)
+		assert.Equal(t, "+49301234567", *createdCustomer.TelephoneNumberE164)
+	})
+
+	t.Run("test create new customer", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{
+			customerIDToCreate: "67a85348-2afe-4677-99ce-ed7cdc17e525",
+		}
+		body, _ := json.Marshal(&customer)
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    "67a85348-2afe-4677-99ce-ed7cdc17e525",
+			"first_name":            "John",
+			"last_name":             "Doe",
+			"telephone_number":      "12 345 67 89",
+			"telephone_number_e164": "+48123456789",
+			"email":                 "",
+			"address":               emptyAddressResponse(),
+			"birth_date":            nil,
+			"preferred_contact":     "",
+			"notes":                 "",
+			"created_at":            "0001-01-01T00:00:00Z",
+			"updated_at":            "0001-01-01T00:00:00Z",
+			"deleted_at":            nil,
+			"version":               0.0,
+		})
+		_, currentCustomers, total := server.customerRepository.ListBy(
+			context.Background(),
+			repositories.CustomerFilter{Limit: 10},
+		)
+		customer.ID = "67a85348-2afe-4677-99ce-ed7cdc17e525"
+		createdCustomer := customer
+		createdCustomer.TelephoneNumber = "12 345 67 89"
+		createdCustomer.TelephoneNumberE164 = &telephoneNumberE164
+		assert.ElementsMatch(t, []database.Customer{createdCustomer}, currentCustomers.Items)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("test create new customer duplicated telephone number", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{
+			customers: []database.Customer{{
+				FirstName:           "Bob",
+				LastName:            "Smith",
+				TelephoneNumber:     "12 345 67 89",
+				TelephoneNumberE164: &telephoneNumberE164,
+			}},
+		}
+		body, _ := json.Marshal(map[string]string{
+			"first_name":       "John",
+			"last_name":        "Doe",
+			"telephone_number": "+48 (12) 345-67-89",
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "customer 'John Doe' cannot have telephone number '12 345 67 89' as already taken.",
+		})
+		_, _, total := server.customerRepository.ListBy(
+			context.Background(),
+			repositories.CustomerFilter{Limit: 10},
+		)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("test create new customer invalid payload", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(
+			map[string]string{"invalid": "invalid"},
+		)
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"first_name":       {{Code: "required", Message: "The 'first_name' is required"}},
+			"last_name":        {{Code: "required", Message: "The 'last_name' is required"}},
+			"telephone_number": {{Code: "required", Message: "The 'telephone_number' is required"}},
+		})
+		_, currentCustomers, total := server.customerRepository.ListBy(
+			context.Background(),
+			repositories.CustomerFilter{Limit: 10},
 		)
-		_, currentCustomers, total := server.customerRepository.ListBy("", "", 10, 0)
-		assert.ElementsMatch(t, []database.Customer{}, currentCustomers)
+		assert.ElementsMatch(t, []database.Customer{}, currentCustomers.Items)
 		assert.Equal(t, 0, total)
 	})
 
+	t.Run("test create new customer invalid telephone number", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(map[string]string{
+			"first_name":       "John",
+			"last_name":        "Doe",
+			"telephone_number": "call me",
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"telephone_number": {{
+				Code:    "telephone",
+				Message: "The 'telephone_number' is not a valid telephone number",
+			}},
+		})
+	})
+
+	t.Run("test create new customer with contact profile", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{
+			customerIDToCreate: "67a85348-2afe-4677-99ce-ed7cdc17e525",
+		}
+		body, _ := json.Marshal(map[string]any{
+			"first_name":        "John",
+			"last_name":         "Doe",
+			"telephone_number":  "123456789",
+			"email":             "john.doe@example.com",
+			"address":           map[string]string{"street": "Długa 1", "city": "Kraków", "postal_code": "30-001", "country": "pl"},
+			"birth_date":        "1980-05-17",
+			"preferred_contact": "email",
+			"notes":             "Prefers thin frames",
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    "67a85348-2afe-4677-99ce-ed7cdc17e525",
+			"first_name":            "John",
+			"last_name":             "Doe",
+			"telephone_number":      "12 345 67 89",
+			"telephone_number_e164": "+48123456789",
+			"email":                 "john.doe@example.com",
+			"address": map[string]any{
+				"street":      "Długa 1",
+				"city":        "Kraków",
+				"postal_code": "30-001",
+				"country":     "PL",
+			},
+			"birth_date":        "1980-05-17T00:00:00Z",
+			"preferred_contact": "email",
+			"notes":             "Prefers thin frames",
+			"created_at":        "0001-01-01T00:00:00Z",
+			"updated_at":        "0001-01-01T00:00:00Z",
+			"deleted_at":        nil,
+			"version":           0.0,
+		})
+	})
+
+	t.Run("test create new customer invalid contact profile", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(map[string]any{
+			"first_name":        "John",
+			"last_name":         "Doe",
+			"telephone_number":  "123456789",
+			"email":             "john.doe",
+			"address":           map[string]string{"country": "Poland"},
+			"birth_date":        time.Now().AddDate(0, 0, 1).Format(DateLayout),
+			"preferred_contact": "pigeon",
+			"notes":             strings.Repeat("a", 2001),
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"email": {{Code: "email", Message: "The 'email' is not a valid email address"}},
+			"address.country": {{
+				Code:    "country",
+				Message: "The 'address.country' must be a two-letter country code, e.g. PL",
+			}},
+			"birth_date": {{Code: "pastDate", Message: "The 'birth_date' cannot be in the future"}},
+			"preferred_contact": {{
+				Code:    "contactChannel",
+				Message: "The 'preferred_contact' must be one of telephone, sms, email or post",
+			}},
+			"notes": {{Code: "maxLen", Message: "The 'notes' must be at most 2000 characters long"}},
+		})
+	})
+
+	t.Run("test create new customer preferring post without address", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(map[string]any{
+			"first_name":        "John",
+			"last_name":         "Doe",
+			"telephone_number":  "123456789",
+			"address":           map[string]string{"street": "Długa 1"},
+			"preferred_contact": "post",
+		})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"address.city": {{
+				Code:    "requiredForContact",
+				Message: "The 'address.city' is required for the preferred contact channel",
+			}},
+			"address.postal_code": {{
+				Code:    "requiredForContact",
+				Message: "The 'address.postal_code' is required for the preferred contact channel",
+			}},
+		})
+	})
+
+	t.Run("test create new customer invalid payload in polish", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(map[string]string{"first_name": "Jan", "telephone_number": "12"})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+		req.Header.Set("Accept-Language", "pl-PL,pl;q=0.9,en;q=0.8")
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"last_name": {{Code: "required", Message: "Pole 'last_name' jest wymagane"}},
+			"telephone_number": {{
+				Code:    "telephone",
+				Message: "Pole 'telephone_number' nie jest poprawnym numerem telefonu",
+			}},
+		})
+	})
+
+	t.Run("test create new customer invalid payload in unsupported language", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{}
+		body, _ := json.Marshal(map[string]string{"first_name": "Hans", "telephone_number": "123-456-789"})
+		req := makeRequest(t, http.MethodPost, "/api/customers", bytes.NewBuffer(body))
+		req.Header.Set("Accept-Language", "de")
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"last_name": {{Code: "required", Message: "The 'last_name' is required"}},
+		})
+	})
+
 	t.Run("test create new customer invalid content-type header", func(t *testing.T) {
 		invalidContentType := "text/html"
 		server.customerRepository = &StubCustomerRepository{}
@@ -484,6 +1487,7 @@ func TestCustomerHandlers(t *testing.T) {
 					FirstName:       "Bob",
 					LastName:        "Toe",
 					TelephoneNumber: "367654567",
+					Version:         4,
 				},
 			},
 		}
@@ -497,13 +1501,22 @@ func TestCustomerHandlers(t *testing.T) {
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
-		assertCustomerDetailsResponse(t, resp, map[string]string{
-			"id":               "8a5cae65-222c-4164-a08b-9983af7e366c",
-			"first_name":       "Bob",
-			"last_name":        "Toe",
-			"telephone_number": "367654567",
-			"created_at":       "0001-01-01T00:00:00Z",
-			"updated_at":       "0001-01-01T00:00:00Z",
+		assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    "8a5cae65-222c-4164-a08b-9983af7e366c",
+			"first_name":            "Bob",
+			"last_name":             "Toe",
+			"telephone_number":      "367654567",
+			"telephone_number_e164": nil,
+			"email":                 "",
+			"address":               emptyAddressResponse(),
+			"birth_date":            nil,
+			"preferred_contact":     "",
+			"notes":                 "",
+			"created_at":            "0001-01-01T00:00:00Z",
+			"updated_at":            "0001-01-01T00:00:00Z",
+			"deleted_at":            nil,
+			"version":               4.0,
 		})
 	})
 
@@ -557,6 +1570,7 @@ func TestCustomerHandlers(t *testing.T) {
 				FirstName:       "Bob",
 				LastName:        "Toe",
 				TelephoneNumber: "367654567",
+				Version:         1,
 			},
 		}}
 
@@ -573,17 +1587,84 @@ func TestCustomerHandlers(t *testing.T) {
 			fmt.Sprintf("/api/customers/%s", "8a5cae65-222c-4164-a08b-9983af7e366c"),
 			bytes.NewBuffer(body),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
-		assertCustomerDetailsResponse(t, resp, map[string]string{
-			"id":               "8a5cae65-222c-4164-a08b-9983af7e366c",
-			"first_name":       "John",
-			"last_name":        "Doe",
-			"telephone_number": "123456891",
-			"created_at":       "0001-01-01T00:00:00Z",
-			"updated_at":       "0001-01-01T00:00:00Z",
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    "8a5cae65-222c-4164-a08b-9983af7e366c",
+			"first_name":            "John",
+			"last_name":             "Doe",
+			"telephone_number":      "12 345 68 91",
+			"telephone_number_e164": "+48123456891",
+			"email":                 "",
+			"address":               emptyAddressResponse(),
+			"birth_date":            nil,
+			"preferred_contact":     "",
+			"notes":                 "",
+			"created_at":            "0001-01-01T00:00:00Z",
+			"updated_at":            "0001-01-01T00:00:00Z",
+			"deleted_at":            nil,
+			"version":               2.0,
+		})
+	})
+
+	t.Run("test edit customer details changed in the meantime", func(t *testing.T) {
+		customer := getCustomer()
+		customer.Version = 2
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{customer}}
+		body, err := json.Marshal(
+			map[string]string{"first_name": "John", "last_name": "Doe", "telephone_number": "123456891"},
+		)
+		assert.NoError(t, err)
+		req := makeRequest(t, http.MethodPut, "/api/customers/"+customer.ID, bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("record with ID '%s' has been changed since version 1 was read", customer.ID),
+		})
+		_, currentCustomer := server.customerRepository.GetByID(context.Background(), customer.ID)
+		assert.Equal(t, "123-456-789", currentCustomer.TelephoneNumber)
+	})
+
+	t.Run("test edit customer details without If-Match header", func(t *testing.T) {
+		customer := getCustomer()
+		customer.Version = 1
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{customer}}
+		body, err := json.Marshal(
+			map[string]string{"first_name": "John", "last_name": "Doe", "telephone_number": "123456891"},
+		)
+		assert.NoError(t, err)
+		req := makeRequest(t, http.MethodPut, "/api/customers/"+customer.ID, bytes.NewBuffer(body))
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusPreconditionRequired, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{
+			"detail": "If-Match header with the ETag of the record is required",
+		})
+	})
+
+	t.Run("test edit customer details with invalid If-Match header", func(t *testing.T) {
+		customer := getCustomer()
+		customer.Version = 1
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{customer}}
+		body, err := json.Marshal(
+			map[string]string{"first_name": "John", "last_name": "Doe", "telephone_number": "123456891"},
+		)
+		assert.NoError(t, err)
+		req := makeRequest(t, http.MethodPut, "/api/customers/"+customer.ID, bytes.NewBuffer(body))
+		req.Header.Set("If-Match", "*")
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "given If-Match header '*' is not a valid ETag",
 		})
 	})
 
@@ -630,6 +1711,7 @@ func TestCustomerHandlers(t *testing.T) {
 			fmt.Sprintf("/api/customers/%s", invalidCustomerID),
 			bytes.NewBuffer(body),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
@@ -683,6 +1765,7 @@ func TestCustomerHandlers(t *testing.T) {
 					FirstName:       "Bob",
 					LastName:        "Toe",
 					TelephoneNumber: "367654567",
+					Version:         1,
 					Purchases: []database.Purchase{
 						{
 							ID:         "d11aeae2-d18a-4b6f-8ed5-2223c015adfd",
@@ -694,7 +1777,7 @@ func TestCustomerHandlers(t *testing.T) {
 						{
 							ID:          "b483c02c-d4d0-4da9-8601-50a72c1eac14",
 							Description: "Repair 1",
-							Cost:        123.34,
+							Cost:        database.Money{Amount: 12334, Currency: "PLN"},
 							CustomerID:  customerOneID,
 						},
 					},
@@ -708,17 +1791,38 @@ func TestCustomerHandlers(t *testing.T) {
 			fmt.Sprintf("/api/customers/%s", customerOneID),
 			bytes.NewBuffer([]byte{}),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
-		err, customers, total := server.customerRepository.ListBy("", "", 10, 0)
+		err, customers, total := server.customerRepository.ListBy(
+			context.Background(),
+			repositories.CustomerFilter{Limit: 10},
+		)
 		assert.NoError(t, err)
-		assert.Equal(t, []database.Customer{customerTwo}, customers)
+		assert.Equal(t, []database.Customer{customerTwo}, customers.Items)
 		assert.Equal(t, "", resp.Header.Get("Content-Type"))
 		assert.Equal(t, 1, total)
 	})
 
+	t.Run("test delete customer changed in the meantime", func(t *testing.T) {
+		customer := getCustomer()
+		customer.Version = 3
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{customer}}
+		req := makeRequest(t, http.MethodDelete, "/api/customers/"+customer.ID, nil)
+		req.Header.Set("If-Match", `"2"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("record with ID '%s' has been changed since version 2 was read", customer.ID),
+		})
+		err, _ := server.customerRepository.GetByID(context.Background(), customer.ID)
+		assert.NoError(t, err)
+	})
+
 	t.Run("test delete customer but not found", func(t *testing.T) {
 		invalidID := "37567fea-71ab-4677-9b19-708370034a66"
 		server.customerRepository = &StubCustomerRepository{}
@@ -728,6 +1832,7 @@ func TestCustomerHandlers(t *testing.T) {
 			fmt.Sprintf("/api/customers/%s", invalidID),
 			bytes.NewBuffer([]byte{}),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
@@ -752,38 +1857,89 @@ func TestCustomerHandlers(t *testing.T) {
 			"detail": fmt.Sprintf("given customer id '%s' is not a valid UUID", invalidID),
 		})
 	})
+
+	t.Run("test restore deleted customer", func(t *testing.T) {
+		deletedCustomer := getCustomer()
+		deletedCustomer.Version = 1
+		server.customerRepository = &StubCustomerRepository{deletedCustomers: []database.Customer{deletedCustomer}}
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/restore", deletedCustomer.ID),
+			nil,
+		)
+		req.Header.Del("Content-Type")
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    deletedCustomer.ID,
+			"first_name":            "John",
+			"last_name":             "Doe",
+			"telephone_number":      "123-456-789",
+			"telephone_number_e164": nil,
+			"email":                 "",
+			"address":               emptyAddressResponse(),
+			"birth_date":            nil,
+			"preferred_contact":     "",
+			"notes":                 "",
+			"created_at":            "0001-01-01T00:00:00Z",
+			"updated_at":            "0001-01-01T00:00:00Z",
+			"deleted_at":            nil,
+			"version":               2.0,
+		})
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		err, _ := server.customerRepository.GetByID(context.Background(), deletedCustomer.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("test restore customer but not found", func(t *testing.T) {
+		invalidID := "37567fea-71ab-4677-9b19-708370034a66"
+		server.customerRepository = &StubCustomerRepository{}
+		req := makeRequest(t, http.MethodPost, fmt.Sprintf("/api/customers/%s/restore", invalidID), nil)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("customer with given id '%s' does not exists", invalidID),
+		})
+	})
 }
 
 func TestPurchaseHandlers(t *testing.T) {
 	customer := getCustomer()
 	t.Run("test get all purchases", func(t *testing.T) {
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		err, _ := server.purchasesRepository.Create(
+			context.Background(),
 			&customer,
 			&database.Purchase{
 				ID:           "ca1224cb-c993-4d45-8053-73c56aaf2c77",
 				FrameModel:   "Model1",
 				LensType:     "Lens1",
-				LensPower:    "Power1",
-				PD:           "PD1",
+				LensPower:    getLensPower(),
+				PD:           database.PupillaryDistance{Binocular: 61},
 				PurchaseType: "PurchaseType1",
 				PurchasedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 		)
 		assert.NoError(t, err)
 		err, _ = server.purchasesRepository.Create(
+			context.Background(),
 			&customer,
 			&database.Purchase{
 				ID:           "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
 				FrameModel:   "Model2",
 				LensType:     "Lens2",
-				LensPower:    "Power2",
-				PD:           "PD2",
+				LensPower:    getLensPower(),
+				PD:           database.PupillaryDistance{Binocular: 62},
 				PurchaseType: "PurchaseType2",
 				PurchasedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
@@ -799,45 +1955,103 @@ func TestPurchaseHandlers(t *testing.T) {
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
-		var actualPurchases []map[string]string
+		var actualPurchases []map[string]any
 		err = json.NewDecoder(resp.Body).Decode(&actualPurchases)
 		assert.NoError(t, err)
 		assert.Equal(
 			t,
-			[]map[string]string{
+			[]map[string]any{
 				{
-					"created_at":    "0001-01-01T00:00:00Z",
-					"customer_id":   "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-					"frame_model":   "Model1",
-					"id":            "ca1224cb-c993-4d45-8053-73c56aaf2c77",
-					"lens_power":    "Power1",
-					"lens_type":     "Lens1",
-					"pd":            "PD1",
-					"purchase_type": "PurchaseType1",
-					"purchased_at":  "2022-01-01T00:00:00Z",
-					"updated_at":    "0001-01-01T00:00:00Z",
+					"created_at":      "0001-01-01T00:00:00Z",
+					"customer_id":     "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+					"frame_model":     "Model1",
+					"id":              "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+					"lens_power":      getLensPowerResponse(),
+					"lens_type":       "Lens1",
+					"prescription_id": nil,
+					"frame_id":        nil,
+					"lens_type_id":    nil,
+					"pd":              map[string]any{"binocular": 61.0, "right": 0.0, "left": 0.0},
+					"purchase_type":   "PurchaseType1",
+					"purchased_at":    "2022-01-01T00:00:00Z",
+					"price":           moneyResponse("0.00", ""),
+					"discount":        moneyResponse("0.00", ""),
+					"vat_rate":        0.0,
+					"total":           moneyResponse("0.00", ""),
+					"vat":             moneyResponse("0.00", ""),
+					"updated_at":      "0001-01-01T00:00:00Z",
+					"deleted_at":      nil,
+					"version":         0.0,
 				},
 				{
-					"created_at":    "0001-01-01T00:00:00Z",
-					"customer_id":   "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-					"frame_model":   "Model2",
-					"id":            "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
-					"lens_power":    "Power2",
-					"lens_type":     "Lens2",
-					"pd":            "PD2",
-					"purchase_type": "PurchaseType2",
-					"purchased_at":  "2021-01-01T00:00:00Z",
-					"updated_at":    "0001-01-01T00:00:00Z",
+					"created_at":      "0001-01-01T00:00:00Z",
+					"customer_id":     "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+					"frame_model":     "Model2",
+					"id":              "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+					"lens_power":      getLensPowerResponse(),
+					"lens_type":       "Lens2",
+					"prescription_id": nil,
+					"frame_id":        nil,
+					"lens_type_id":    nil,
+					"pd":              map[string]any{"binocular": 62.0, "right": 0.0, "left": 0.0},
+					"purchase_type":   "PurchaseType2",
+					"purchased_at":    "2021-01-01T00:00:00Z",
+					"price":           moneyResponse("0.00", ""),
+					"discount":        moneyResponse("0.00", ""),
+					"vat_rate":        0.0,
+					"total":           moneyResponse("0.00", ""),
+					"vat":             moneyResponse("0.00", ""),
+					"updated_at":      "0001-01-01T00:00:00Z",
+					"deleted_at":      nil,
+					"version":         0.0,
 				},
 			},
 			actualPurchases,
 		)
 	})
 
+	t.Run("test get purchases a page at a time", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{},
+			&StubPurchaseRepository{purchases: []database.Purchase{
+				{ID: "ca1224cb-c993-4d45-8053-73c56aaf2c77", CustomerID: customer.ID},
+				{ID: "5b521e40-e0f1-47fd-a832-fe6ea3fba22c", CustomerID: customer.ID},
+			}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		getPage := func(t *testing.T, query string) (ids []string, nextCursor *string, prevCursor *string) {
+			req := makeRequest(t, http.MethodGet, "/api/customers/"+customer.ID+"/purchases?"+query, nil)
+			resp := getResponse(t, server, req)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			var page struct {
+				Data       []database.Purchase `json:"data"`
+				NextCursor *string             `json:"next_cursor"`
+				PrevCursor *string             `json:"prev_cursor"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+			for _, purchase := range page.Data {
+				ids = append(ids, purchase.ID)
+			}
+			return ids, page.NextCursor, page.PrevCursor
+		}
+
+		ids, nextCursor, prevCursor := getPage(t, "limit=1")
+		assert.Equal(t, []string{"ca1224cb-c993-4d45-8053-73c56aaf2c77"}, ids)
+		assert.NotNil(t, nextCursor)
+		assert.Nil(t, prevCursor)
+
+		ids, nextCursor, prevCursor = getPage(t, "limit=1&cursor="+*nextCursor)
+		assert.Equal(t, []string{"5b521e40-e0f1-47fd-a832-fe6ea3fba22c"}, ids)
+		assert.Nil(t, nextCursor)
+		assert.NotNil(t, prevCursor)
+	})
+
 	t.Run("test create purchase for a customer", func(t *testing.T) {
-		server := NewCustomerManagerServer(fiber.New(), &StubCustomerRepository{
+		server := newTestServer(newTestApp(), &StubCustomerRepository{
 			customers: []database.Customer{customer},
-		}, &StubPurchaseRepository{purchaseIDToCreate: "80dfb090-deea-4672-873d-a9cf8d4103e0"}, &StubRepairRepository{})
+		}, &StubPurchaseRepository{purchaseIDToCreate: "80dfb090-deea-4672-873d-a9cf8d4103e0"}, &StubRepairRepository{}, &StubPrescriptionRepository{})
 		req := makeRequest(
 			t,
 			http.MethodPost,
@@ -845,32 +2059,48 @@ func TestPurchaseHandlers(t *testing.T) {
 			bytes.NewBuffer([]byte(`{
 				"frame_model": "Model1",
 				"lens_type": "Lens1",
-				"lens_power": "Power1",
-				"pd": "PD1",
+				"lens_power": {
+					"right": {"sphere": -1.25, "cylinder": -0.5, "axis": 90},
+					"left": {"sphere": -1, "add": 2}
+				},
+				"pd": {"binocular": 61},
 				"purchase_type": "PurchaseType1",
-				"purchased_at": "2021-01-01"
+				"purchased_at": "2021-01-01",
+				"price": {"amount": "350.00"},
+				"discount": {"amount": "50", "currency": "PLN"},
+				"vat_rate": 23
 			}`)),
 		)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
-		var actualPurchase map[string]string
+		var actualPurchase map[string]any
 		err := json.NewDecoder(resp.Body).Decode(&actualPurchase)
 		assert.NoError(t, err)
 		assert.Equal(
 			t,
-			map[string]string{
-				"created_at":    "0001-01-01T00:00:00Z",
-				"customer_id":   "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-				"frame_model":   "Model1",
-				"id":            "80dfb090-deea-4672-873d-a9cf8d4103e0",
-				"lens_power":    "Power1",
-				"lens_type":     "Lens1",
-				"pd":            "PD1",
-				"purchase_type": "PurchaseType1",
-				"purchased_at":  "2021-01-01T00:00:00Z",
-				"updated_at":    "0001-01-01T00:00:00Z",
+			map[string]any{
+				"created_at":      "0001-01-01T00:00:00Z",
+				"customer_id":     "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+				"frame_model":     "Model1",
+				"id":              "80dfb090-deea-4672-873d-a9cf8d4103e0",
+				"lens_power":      getLensPowerResponse(),
+				"lens_type":       "Lens1",
+				"prescription_id": nil,
+				"frame_id":        nil,
+				"lens_type_id":    nil,
+				"pd":              map[string]any{"binocular": 61.0, "right": 0.0, "left": 0.0},
+				"purchase_type":   "PurchaseType1",
+				"purchased_at":    "2021-01-01T00:00:00Z",
+				"price":           moneyResponse("350.00", "PLN"),
+				"discount":        moneyResponse("50.00", "PLN"),
+				"vat_rate":        23.0,
+				"total":           moneyResponse("300.00", "PLN"),
+				"vat":             moneyResponse("56.10", "PLN"),
+				"updated_at":      "0001-01-01T00:00:00Z",
+				"deleted_at":      nil,
+				"version":         0.0,
 			},
 			actualPurchase,
 		)
@@ -881,31 +2111,32 @@ func TestPurchaseHandlers(t *testing.T) {
 			ID:           "ca1224cb-c993-4d45-8053-73c56aaf2c77",
 			FrameModel:   "Model1",
 			LensType:     "Lens1",
-			LensPower:    "Power1",
-			PD:           "PD1",
+			LensPower:    getLensPower(),
+			PD:           database.PupillaryDistance{Binocular: 61},
 			PurchaseType: "PurchaseType1",
 			PurchasedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 			CustomerID:   customer.ID,
+			Version:      1,
 		}, {
 			ID:           "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
 			FrameModel:   "Model2",
 			LensType:     "Lens2",
-			LensPower:    "Power2",
-			PD:           "PD2",
+			LensPower:    getLensPower(),
+			PD:           database.PupillaryDistance{Binocular: 62},
 			PurchaseType: "PurchaseType2",
 			PurchasedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			CustomerID:   customer.ID,
 		}}
-		server := NewCustomerManagerServer(fiber.New(), &StubCustomerRepository{
+		server := newTestServer(newTestApp(), &StubCustomerRepository{
 			customers: []database.Customer{customer},
-		}, &StubPurchaseRepository{purchases: purchases}, &StubRepairRepository{})
+		}, &StubPurchaseRepository{purchases: purchases}, &StubRepairRepository{}, &StubPrescriptionRepository{})
 
 		body, _ := json.Marshal(
-			map[string]string{
+			map[string]any{
 				"frame_model":   "UpdatedModel1",
 				"lens_type":     "UpdatedLens1",
-				"lens_power":    "UpdatedPower1",
-				"pd":            "UpdatedPD1",
+				"lens_power":    getLensPower(),
+				"pd":            map[string]float64{"right": 32, "left": 31.5},
 				"purchase_type": "UpdatedPurchaseType1",
 				"purchased_at":  "2025-01-01",
 			},
@@ -920,49 +2151,273 @@ func TestPurchaseHandlers(t *testing.T) {
 			),
 			bytes.NewBuffer(body),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
-		assertResponse(t, resp, map[string]string{
-			"created_at":    "0001-01-01T00:00:00Z",
-			"customer_id":   customer.ID,
-			"frame_model":   "UpdatedModel1",
-			"id":            "ca1224cb-c993-4d45-8053-73c56aaf2c77",
-			"lens_power":    "UpdatedPower1",
-			"lens_type":     "UpdatedLens1",
-			"pd":            "UpdatedPD1",
-			"purchase_type": "UpdatedPurchaseType1",
-			"purchased_at":  "2025-01-01T00:00:00Z",
-			"updated_at":    "0001-01-01T00:00:00Z",
-		})
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		var actualPurchase map[string]any
+		err := json.NewDecoder(resp.Body).Decode(&actualPurchase)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"created_at":      "0001-01-01T00:00:00Z",
+			"customer_id":     customer.ID,
+			"frame_model":     "UpdatedModel1",
+			"id":              "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			"lens_power":      getLensPowerResponse(),
+			"lens_type":       "UpdatedLens1",
+			"prescription_id": nil,
+			"frame_id":        nil,
+			"lens_type_id":    nil,
+			"pd":              map[string]any{"binocular": 0.0, "right": 32.0, "left": 31.5},
+			"purchase_type":   "UpdatedPurchaseType1",
+			"purchased_at":    "2025-01-01T00:00:00Z",
+			"price":           moneyResponse("0.00", "PLN"),
+			"discount":        moneyResponse("0.00", "PLN"),
+			"vat_rate":        0.0,
+			"total":           moneyResponse("0.00", "PLN"),
+			"vat":             moneyResponse("0.00", "PLN"),
+			"updated_at":      "0001-01-01T00:00:00Z",
+			"deleted_at":      nil,
+			"version":         2.0,
+		}, actualPurchase)
 	})
 
-	t.Run("test delete purchase for a customer", func(t *testing.T) {
-		customerID := "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f"
-		purchases := []database.Purchase{{
-			ID:           "ca1224cb-c993-4d45-8053-73c56aaf2c77",
-			FrameModel:   "Model1",
-			LensType:     "Lens1",
-			LensPower:    "Power1",
-			PD:           "PD1",
-			PurchaseType: "PurchaseType1",
-			PurchasedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
-			CustomerID:   customerID,
-		}, {
-			ID:           "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
-			FrameModel:   "Model2",
-			LensType:     "Lens2",
-			LensPower:    "Power2",
-			PD:           "PD2",
-			PurchaseType: "PurchaseType2",
-			PurchasedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
-			CustomerID:   customerID,
-		}}
-		server := NewCustomerManagerServer(fiber.New(), &StubCustomerRepository{
-			customers: []database.Customer{customer},
-		}, &StubPurchaseRepository{purchases: purchases}, &StubRepairRepository{})
-		req := makeRequest(
+	t.Run("test get purchase of a customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: customer.ID,
+			Version:    3,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+		var actualPurchase database.Purchase
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualPurchase))
+		assert.Equal(t, purchase, actualPurchase)
+	})
+
+	t.Run("test get purchase of another customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: "37567fea-71ab-4677-9b19-708370034a66",
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("purchase with given id '%s' does not exists", purchase.ID),
+		})
+	})
+
+	t.Run("test update purchase changed in the meantime", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: customer.ID,
+			Version:    2,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		body, err := json.Marshal(map[string]any{
+			"frame_model":   "UpdatedModel1",
+			"lens_type":     "UpdatedLens1",
+			"pd":            map[string]float64{"binocular": 62},
+			"purchase_type": "UpdatedPurchaseType1",
+			"purchased_at":  "2025-01-01",
+		})
+		assert.NoError(t, err)
+		req := makeRequest(
+			t,
+			http.MethodPut,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			bytes.NewBuffer(body),
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("record with ID '%s' has been changed since version 1 was read", purchase.ID),
+		})
+		_, currentPurchase := server.purchasesRepository.GetByID(context.Background(), purchase.ID)
+		assert.Equal(t, purchase, *currentPurchase)
+	})
+
+	t.Run("test create purchase with invalid prescription", func(t *testing.T) {
+		server := newTestServer(newTestApp(), &StubCustomerRepository{
+			customers: []database.Customer{customer},
+		}, &StubPurchaseRepository{}, &StubRepairRepository{}, &StubPrescriptionRepository{})
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases", customer.ID),
+			bytes.NewBuffer([]byte(`{
+				"frame_model": "Model1",
+				"lens_type": "Lens1",
+				"lens_power": {
+					"right": {"sphere": -1.3, "cylinder": -0.5},
+					"left": {"sphere": -1, "prism": 2, "base": "XX"}
+				},
+				"pd": {"right": 31},
+				"purchase_type": "PurchaseType1",
+				"purchased_at": "2021-01-01"
+			}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"lens_power.right.sphere": {{
+				Code:    "sphere",
+				Message: "The 'lens_power.right.sphere' must be between -30 and 30 in 0.25 diopter steps",
+			}},
+			"lens_power.right.axis": {{
+				Code:    "requiredWithCylinder",
+				Message: "The 'lens_power.right.axis' is required when cylinder is given",
+			}},
+			"lens_power.left.base": {{
+				Code:    "prismBase",
+				Message: "The 'lens_power.left.base' must be one of BI, BO, BU or BD",
+			}},
+			"pd": {{Code: "pd", Message: "The 'pd' requires either binocular or both monocular distances"}},
+		})
+	})
+
+	t.Run("test create purchase with invalid price", func(t *testing.T) {
+		server := newTestServer(newTestApp(), &StubCustomerRepository{
+			customers: []database.Customer{customer},
+		}, &StubPurchaseRepository{}, &StubRepairRepository{}, &StubPrescriptionRepository{})
+		for body, errs := range map[string]map[string][]FieldError{
+			`"price": {"amount": "100"}, "discount": {"amount": "10", "currency": "EUR"}, "vat_rate": 123`: {
+				"discount.currency": {{
+					Code:    "priceCurrency",
+					Message: "The 'discount.currency' must be the currency of the price",
+				}},
+				"vat_rate": {{Code: "vatRate", Message: "The 'vat_rate' must be a percentage between 0 and 100"}},
+			},
+			`"price": {"amount": "100", "currency": "eur"}, "discount": {"amount": "100.01", "currency": "EUR"}`: {
+				"discount.amount": {{Code: "discount", Message: "The 'discount.amount' cannot exceed the price"}},
+			},
+			`"price": {"amount": "1,000"}`: {
+				"price.amount": {{
+					Code:    "money",
+					Message: "The 'price.amount' must be a non-negative decimal amount, e.g. 120.50",
+				}},
+			},
+		} {
+			req := makeRequest(
+				t,
+				http.MethodPost,
+				fmt.Sprintf("/api/customers/%s/purchases", customer.ID),
+				bytes.NewBuffer([]byte(`{
+					"frame_model": "Model1",
+					"lens_type": "Lens1",
+					"pd": {"binocular": 61},
+					"purchase_type": "PurchaseType1",
+					"purchased_at": "2021-01-01",
+					`+body+`
+				}`)),
+			)
+
+			resp := getResponse(t, server, req)
+
+			assertValidationProblemResponse(t, resp, errs)
+		}
+	})
+
+	t.Run("test create purchase for a customer but not found", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases", customer.ID),
+			bytes.NewBuffer([]byte(`{
+				"frame_model": "Model1",
+				"lens_type": "Lens1",
+				"lens_power": {"right": {"sphere": -1.25}, "left": {"sphere": -1}},
+				"pd": {"binocular": 62},
+				"purchase_type": "PurchaseType1",
+				"purchased_at": "2021-01-01"
+			}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("customer with given id '%s' does not exists", customer.ID),
+		})
+	})
+
+	t.Run("test delete purchase for a customer", func(t *testing.T) {
+		customerID := "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f"
+		purchases := []database.Purchase{{
+			ID:           "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel:   "Model1",
+			LensType:     "Lens1",
+			LensPower:    getLensPower(),
+			PD:           database.PupillaryDistance{Binocular: 61},
+			PurchaseType: "PurchaseType1",
+			PurchasedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			CustomerID:   customerID,
+			Version:      1,
+		}, {
+			ID:           "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+			FrameModel:   "Model2",
+			LensType:     "Lens2",
+			LensPower:    getLensPower(),
+			PD:           database.PupillaryDistance{Binocular: 62},
+			PurchaseType: "PurchaseType2",
+			PurchasedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			CustomerID:   customerID,
+		}}
+		server := newTestServer(newTestApp(), &StubCustomerRepository{
+			customers: []database.Customer{customer},
+		}, &StubPurchaseRepository{purchases: purchases}, &StubRepairRepository{}, &StubPrescriptionRepository{})
+		req := makeRequest(
 			t,
 			http.MethodDelete,
 			fmt.Sprintf(
@@ -972,22 +2427,173 @@ func TestPurchaseHandlers(t *testing.T) {
 			),
 			nil,
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
-		err, currentPurchases := server.purchasesRepository.GetAll(customerID)
+		err, currentPurchases := server.purchasesRepository.GetAll(context.Background(), customerID)
 		assert.NoError(t, err)
 		assert.Equal(t, []database.Purchase{purchases[1]}, currentPurchases)
 	})
 
+	t.Run("test delete purchase of another customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: "37567fea-71ab-4677-9b19-708370034a66",
+			Version:    1,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodDelete,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			nil,
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("purchase with given id '%s' does not exists", purchase.ID),
+		})
+		err, currentPurchases := server.purchasesRepository.GetAll(context.Background(), purchase.CustomerID)
+		assert.NoError(t, err)
+		assert.Equal(t, []database.Purchase{purchase}, currentPurchases)
+	})
+
+	t.Run("test update purchase of another customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: "37567fea-71ab-4677-9b19-708370034a66",
+			Version:    1,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPut,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			bytes.NewBufferString(`{
+				"frame_model": "Model2",
+				"lens_type": "Lens2",
+				"pd": {"binocular": 62},
+				"purchase_type": "PurchaseType2",
+				"purchased_at": "2021-01-01"
+			}`),
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("purchase with given id '%s' does not exists", purchase.ID),
+		})
+		err, currentPurchases := server.purchasesRepository.GetAll(context.Background(), purchase.CustomerID)
+		assert.NoError(t, err)
+		assert.Equal(t, []database.Purchase{purchase}, currentPurchases)
+	})
+
+	t.Run("test restore purchase for a customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			FrameModel: "Model1",
+			CustomerID: customer.ID,
+			Version:    1,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{deletedPurchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases/%s/restore", customer.ID, purchase.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		err, currentPurchases := server.purchasesRepository.GetAll(context.Background(), customer.ID)
+		assert.NoError(t, err)
+		purchase.Version = 2
+		assert.Equal(t, []database.Purchase{purchase}, currentPurchases)
+	})
+
+	t.Run("test restore purchase of another customer", func(t *testing.T) {
+		purchase := database.Purchase{
+			ID:         "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			CustomerID: "33c2cb49-6156-4efe-b282-b0ba553d883f",
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{deletedPurchases: []database.Purchase{purchase}},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases/%s/restore", customer.ID, purchase.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("purchase with given id '%s' does not exists", purchase.ID),
+		})
+	})
+
+	t.Run("test restore purchase of deleted customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{deletedCustomers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases/ca1224cb-c993-4d45-8053-73c56aaf2c77/restore", customer.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("customer with given id '%s' does not exists", customer.ID),
+		})
+	})
+
 	t.Run("test delete purchase but not found", func(t *testing.T) {
 		invalidID := "37567fea-71ab-4677-9b19-708370034a66"
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		req := makeRequest(
 			t,
@@ -998,6 +2604,7 @@ func TestPurchaseHandlers(t *testing.T) {
 			),
 			bytes.NewBuffer([]byte{}),
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
@@ -1008,11 +2615,12 @@ func TestPurchaseHandlers(t *testing.T) {
 
 	t.Run("test delete purchase for a customer with invalid purchase id", func(t *testing.T) {
 		invalidID := "invalid-id"
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		req := makeRequest(
 			t,
@@ -1036,18 +2644,19 @@ func TestRepairHandler(t *testing.T) {
 	customer := getCustomer()
 
 	t.Run("test create repair for custoemr", func(t *testing.T) {
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{customers: []database.Customer{customer}},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		req := makeRequest(t,
 			http.MethodPost,
 			fmt.Sprintf("/api/customers/%s/repairs", customer.ID),
 			bytes.NewBuffer([]byte(`{
 				"description": "repair I",
-        "cost": "1.50",
+				"cost": {"amount": "1.50"},
         "reported_at": "2021-01-01"
 			}`)),
 		)
@@ -1061,43 +2670,57 @@ func TestRepairHandler(t *testing.T) {
 		assert.Equal(
 			t,
 			map[string]any{
-				"cost":        1.5,
-				"created_at":  "0001-01-01T00:00:00Z",
-				"customer_id": "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-				"description": "repair I",
-				"id":          "",
-				"reported_at": "2021-01-01T00:00:00Z",
+				"cost":                 map[string]any{"amount": "1.50", "currency": "PLN"},
+				"created_at":           "0001-01-01T00:00:00Z",
+				"customer_id":          "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+				"description":          "repair I",
+				"id":                   "",
+				"reported_at":          "2021-01-01T00:00:00Z",
+				"status":               "reported",
+				"updated_at":           "0001-01-01T00:00:00Z",
+				"deleted_at":           nil,
+				"version":              0.0,
+				"in_progress_at":       nil,
+				"waiting_for_parts_at": nil,
+				"ready_for_pickup_at":  nil,
+				"collected_at":         nil,
+				"cancelled_at":         nil,
 			},
 			createdRepair,
 		)
 	})
 
 	t.Run("test list repairs for a customer", func(t *testing.T) {
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		err, _ := server.repairsRepository.Create(
+			context.Background(),
 			&customer,
 			&database.Repair{
 				ID:          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
 				Description: "To be repaired",
-				Cost:        12.65,
+				Cost:        database.Money{Amount: 1265, Currency: "PLN"},
 				CreatedAt:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 				ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status:      database.RepairReported,
 			},
 		)
 		assert.NoError(t, err)
 		err, _ = server.repairsRepository.Create(
+			context.Background(),
 			&customer,
 			&database.Repair{
 				ID:          "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
 				Description: "To be repaired II",
-				Cost:        2.65,
+				Cost:        database.Money{Amount: 265, Currency: "PLN"},
 				CreatedAt:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 				ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status:      database.RepairReported,
 			},
 		)
 
@@ -1119,20 +2742,38 @@ func TestRepairHandler(t *testing.T) {
 			t,
 			[]map[string]any{
 				{
-					"cost":        12.65,
-					"created_at":  "2022-01-01T00:00:00Z",
-					"reported_at": "2022-01-01T00:00:00Z",
-					"customer_id": "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-					"description": "To be repaired",
-					"id":          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+					"cost":                 map[string]any{"amount": "12.65", "currency": "PLN"},
+					"created_at":           "2022-01-01T00:00:00Z",
+					"reported_at":          "2022-01-01T00:00:00Z",
+					"customer_id":          "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+					"description":          "To be repaired",
+					"id":                   "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+					"status":               "reported",
+					"updated_at":           "0001-01-01T00:00:00Z",
+					"deleted_at":           nil,
+					"version":              0.0,
+					"in_progress_at":       nil,
+					"waiting_for_parts_at": nil,
+					"ready_for_pickup_at":  nil,
+					"collected_at":         nil,
+					"cancelled_at":         nil,
 				},
 				{
-					"cost":        2.65,
-					"created_at":  "2020-01-01T00:00:00Z",
-					"reported_at": "2022-01-01T00:00:00Z",
-					"customer_id": "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
-					"description": "To be repaired II",
-					"id":          "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+					"cost":                 map[string]any{"amount": "2.65", "currency": "PLN"},
+					"created_at":           "2020-01-01T00:00:00Z",
+					"reported_at":          "2022-01-01T00:00:00Z",
+					"customer_id":          "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f",
+					"description":          "To be repaired II",
+					"id":                   "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+					"status":               "reported",
+					"updated_at":           "0001-01-01T00:00:00Z",
+					"deleted_at":           nil,
+					"version":              0.0,
+					"in_progress_at":       nil,
+					"waiting_for_parts_at": nil,
+					"ready_for_pickup_at":  nil,
+					"collected_at":         nil,
+					"cancelled_at":         nil,
 				},
 			},
 			actualRepairs,
@@ -1140,30 +2781,32 @@ func TestRepairHandler(t *testing.T) {
 	})
 
 	t.Run("test delete repair for customer", func(t *testing.T) {
-		server := NewCustomerManagerServer(
-			fiber.New(),
+		server := newTestServer(
+			newTestApp(),
 			&StubCustomerRepository{customers: []database.Customer{customer}},
 			&StubPurchaseRepository{},
 			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
 		)
 		repairOne := &database.Repair{
 			ID:          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
 			Description: "To be repaired",
-			Cost:        12.65,
+			Cost:        database.Money{Amount: 1265, Currency: "PLN"},
 			CreatedAt:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 			ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			Version:     1,
 		}
 		repairTwo := &database.Repair{
 			ID:          "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
 			Description: "To be repaired II",
-			Cost:        2.65,
+			Cost:        database.Money{Amount: 265, Currency: "PLN"},
 			CreatedAt:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 			ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 		}
 
-		err, _ := server.repairsRepository.Create(&customer, repairOne)
+		err, _ := server.repairsRepository.Create(context.Background(), &customer, repairOne)
 		assert.NoError(t, err)
-		err, _ = server.repairsRepository.Create(&customer, repairTwo)
+		err, _ = server.repairsRepository.Create(context.Background(), &customer, repairTwo)
 		assert.NoError(t, err)
 
 		req := makeRequest(
@@ -1172,13 +2815,1845 @@ func TestRepairHandler(t *testing.T) {
 			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, repairOne.ID),
 			nil,
 		)
+		req.Header.Set("If-Match", `"1"`)
 
 		resp := getResponse(t, server, req)
 
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
-		err, currentRepairs := server.repairsRepository.GetAll(customer.ID)
+		err, currentRepairs := server.repairsRepository.GetAll(context.Background(), customer.ID)
 		assert.NoError(t, err)
 		assert.Len(t, currentRepairs, 1)
 		assert.Equal(t, []database.Repair{*repairTwo}, currentRepairs)
 	})
+
+	t.Run("test delete repair of another customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		owner := database.Customer{ID: "37567fea-71ab-4677-9b19-708370034a66"}
+		repair := &database.Repair{
+			ID:          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			Description: "To be repaired",
+			Version:     1,
+		}
+		err, _ := server.repairsRepository.Create(context.Background(), &owner, repair)
+		assert.NoError(t, err)
+		req := makeRequest(
+			t,
+			http.MethodDelete,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, repair.ID),
+			nil,
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("repair with given id '%s' does not exists", repair.ID),
+		})
+		err, currentRepairs := server.repairsRepository.GetAll(context.Background(), owner.ID)
+		assert.NoError(t, err)
+		assert.Len(t, currentRepairs, 1)
+	})
+
+	t.Run("test restore repair for customer", func(t *testing.T) {
+		repair := database.Repair{
+			ID:          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+			Description: "To be repaired",
+			CustomerID:  customer.ID,
+			Version:     1,
+		}
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{deletedRepairs: []database.Repair{repair}},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/repairs/%s/restore", customer.ID, repair.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		err, currentRepairs := server.repairsRepository.GetAll(context.Background(), customer.ID)
+		assert.NoError(t, err)
+		repair.Version = 2
+		assert.Equal(t, []database.Repair{repair}, currentRepairs)
+	})
+}
+
+func TestPrescriptionHandlers(t *testing.T) {
+	customer := getCustomer()
+	prescription := database.Prescription{
+		ID:         "0f5f4c4e-5d34-4d8f-9d0f-9a0b6f2c6a11",
+		LensPower:  getLensPower(),
+		PD:         database.PupillaryDistance{Binocular: 62},
+		IssuedBy:   "Dr. Anna Nowak",
+		IssuedAt:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CustomerID: customer.ID,
+	}
+
+	t.Run("test create prescription for a customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{prescriptionIDToCreate: prescription.ID},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/prescriptions", customer.ID),
+			bytes.NewBuffer([]byte(`{
+				"lens_power": {
+					"right": {"sphere": -1.25, "cylinder": -0.5, "axis": 90},
+					"left": {"sphere": -1, "add": 2}
+				},
+				"pd": {"binocular": 62},
+				"issued_by": "Dr. Anna Nowak",
+				"issued_at": "2022-01-01",
+				"expires_at": "2024-01-01"
+			}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var actualPrescription map[string]any
+		err := json.NewDecoder(resp.Body).Decode(&actualPrescription)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"id":          prescription.ID,
+			"customer_id": customer.ID,
+			"lens_power":  getLensPowerResponse(),
+			"pd":          map[string]any{"binocular": 62.0, "right": 0.0, "left": 0.0},
+			"issued_by":   "Dr. Anna Nowak",
+			"issued_at":   "2022-01-01T00:00:00Z",
+			"expires_at":  "2024-01-01T00:00:00Z",
+			"created_at":  "0001-01-01T00:00:00Z",
+			"updated_at":  "0001-01-01T00:00:00Z",
+		}, actualPrescription)
+	})
+
+	t.Run("test create prescription expiring before issue date", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/prescriptions", customer.ID),
+			bytes.NewBuffer([]byte(`{
+				"lens_power": {"right": {"sphere": -1}, "left": {"sphere": -1}},
+				"pd": {"binocular": 62},
+				"issued_at": "2022-01-01",
+				"expires_at": "2021-01-01"
+			}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"issued_by":  {{Code: "required", Message: "The 'issued_by' is required"}},
+			"expires_at": {{Code: "afterIssuedAt", Message: "The 'expires_at' must be after 'issued_at'"}},
+		})
+	})
+
+	t.Run("test get prescription of another customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{prescriptions: []database.Prescription{prescription}},
+		)
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/prescriptions/%s", "33c2cb49-6156-4efe-b282-b0ba553d883f", prescription.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("prescription with given id '%s' does not exists", prescription.ID),
+		})
+	})
+
+	t.Run("test create purchase from a prescription", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchaseIDToCreate: "80dfb090-deea-4672-873d-a9cf8d4103e0"},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{prescriptions: []database.Prescription{prescription}},
+		)
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/purchases", customer.ID),
+			bytes.NewBuffer([]byte(fmt.Sprintf(`{
+				"frame_model": "Model1",
+				"lens_type": "Lens1",
+				"prescription_id": "%s",
+				"purchase_type": "PurchaseType1",
+				"purchased_at": "2022-02-01"
+			}`, prescription.ID))),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		err, purchases := server.purchasesRepository.GetAll(context.Background(), customer.ID)
+		assert.NoError(t, err)
+		assert.Len(t, purchases, 1)
+		assert.Equal(t, prescription.ID, *purchases[0].PrescriptionID)
+		assert.Equal(t, prescription.LensPower, purchases[0].LensPower)
+		assert.Equal(t, prescription.PD, purchases[0].PD)
+	})
+
+	t.Run("test delete prescription for a customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{prescriptions: []database.Prescription{prescription}},
+		)
+		req := makeRequest(
+			t,
+			http.MethodDelete,
+			fmt.Sprintf("/api/customers/%s/prescriptions/%s", customer.ID, prescription.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		err, currentPrescriptions := server.prescriptionsRepository.GetAll(context.Background(), customer.ID)
+		assert.NoError(t, err)
+		assert.Len(t, currentPrescriptions, 0)
+	})
+
+	t.Run("test delete prescription of another customer", func(t *testing.T) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{prescriptions: []database.Prescription{prescription}},
+		)
+		req := makeRequest(
+			t,
+			http.MethodDelete,
+			fmt.Sprintf("/api/customers/%s/prescriptions/%s", "33c2cb49-6156-4efe-b282-b0ba553d883f", prescription.ID),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("prescription with given id '%s' does not exists", prescription.ID),
+		})
+		err, currentPrescriptions := server.prescriptionsRepository.GetAll(context.Background(), customer.ID)
+		assert.NoError(t, err)
+		assert.Len(t, currentPrescriptions, 1)
+	})
+}
+
+func TestRepairLifecycleHandlers(t *testing.T) {
+	customer := getCustomer()
+	statusChangedAt := time.Date(2022, 1, 2, 10, 0, 0, 0, time.UTC)
+	getRepairs := func() []database.Repair {
+		return []database.Repair{
+			{
+				ID:          "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+				Description: "To be repaired",
+				CustomerID:  customer.ID,
+				ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status:      database.RepairReported,
+				Version:     1,
+			},
+			{
+				ID:          "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+				Description: "Already collected",
+				CustomerID:  "33c2cb49-6156-4efe-b282-b0ba553d883f",
+				ReportedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status:      database.RepairCollected,
+				Version:     1,
+			},
+		}
+	}
+	newServer := func() *CustomerManagerServer {
+		return newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{repairs: getRepairs(), statusChangedAt: statusChangedAt},
+			&StubPrescriptionRepository{},
+		)
+	}
+
+	t.Run("test change repair status", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodPatch,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77"),
+			bytes.NewBuffer([]byte(`{"status": "in_progress"}`)),
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		var actualRepair map[string]any
+		err := json.NewDecoder(resp.Body).Decode(&actualRepair)
+		assert.NoError(t, err)
+		assert.Equal(t, "in_progress", actualRepair["status"])
+		assert.Equal(t, "2022-01-02T10:00:00Z", actualRepair["in_progress_at"])
+	})
+
+	t.Run("test change status of repair changed in the meantime", func(t *testing.T) {
+		server := newServer()
+		path := fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77")
+		for _, status := range []string{"in_progress", "cancelled"} {
+			req := makeRequest(t, http.MethodPatch, path, bytes.NewBufferString(`{"status": "`+status+`"}`))
+			req.Header.Set("If-Match", `"1"`)
+			resp := getResponse(t, server, req)
+
+			if status == "cancelled" {
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+				assertResponse(t, resp, map[string]string{
+					"detail": "record with ID 'ca1224cb-c993-4d45-8053-73c56aaf2c77' " +
+						"has been changed since version 1 was read",
+				})
+			}
+		}
+
+		err, repair := server.repairsRepository.GetByID(context.Background(), "ca1224cb-c993-4d45-8053-73c56aaf2c77")
+		assert.NoError(t, err)
+		assert.Equal(t, database.RepairInProgress, repair.Status)
+	})
+
+	t.Run("test get repair of a customer", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77"),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+		var actualRepair database.Repair
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualRepair))
+		assert.Equal(t, getRepairs()[0], actualRepair)
+	})
+
+	t.Run("test change repair status with illegal transition", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodPatch,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77"),
+			bytes.NewBuffer([]byte(`{"status": "collected"}`)),
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{
+			"detail": "repair with ID 'ca1224cb-c993-4d45-8053-73c56aaf2c77' " +
+				"cannot change status from 'reported' to 'collected'",
+		})
+	})
+
+	t.Run("test change repair status to unknown status", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodPatch,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77"),
+			bytes.NewBuffer([]byte(`{"status": "lost"}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("test edit repair of another customer", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodPut,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "5b521e40-e0f1-47fd-a832-fe6ea3fba22c"),
+			bytes.NewBuffer([]byte(`{"description": "changed", "cost": {"amount": "1.00"}, "reported_at": "2021-01-01"}`)),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": "repair with given id '5b521e40-e0f1-47fd-a832-fe6ea3fba22c' does not exists",
+		})
+	})
+
+	t.Run("test edit repair details", func(t *testing.T) {
+		server := newServer()
+		req := makeRequest(
+			t,
+			http.MethodPut,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, "ca1224cb-c993-4d45-8053-73c56aaf2c77"),
+			bytes.NewBuffer([]byte(`{"description": "changed", "cost": {"amount": "10.50", "currency": "EUR"}, "reported_at": "2022-01-03"}`)),
+		)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		err, repair := server.repairsRepository.GetByID(context.Background(), "ca1224cb-c993-4d45-8053-73c56aaf2c77")
+		assert.NoError(t, err)
+		assert.Equal(t, "changed", repair.Description)
+		assert.Equal(t, database.Money{Amount: 1050, Currency: "EUR"}, repair.Cost)
+		assert.Equal(t, database.RepairReported, repair.Status)
+	})
+
+	t.Run("test get workshop queue", func(t *testing.T) {
+		server := newServer()
+
+		for query, expectedIDs := range map[string][]string{
+			"":                    {"ca1224cb-c993-4d45-8053-73c56aaf2c77"},
+			"?status=collected":   {"5b521e40-e0f1-47fd-a832-fe6ea3fba22c"},
+			"?status=in_progress": {},
+		} {
+			resp := getResponse(t, server, makeRequest(t, http.MethodGet, "/api/repairs"+query, nil))
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			var actualRepairs []database.Repair
+			err := json.NewDecoder(resp.Body).Decode(&actualRepairs)
+			assert.NoError(t, err)
+			actualIDs := []string{}
+			for _, repair := range actualRepairs {
+				actualIDs = append(actualIDs, repair.ID)
+			}
+			assert.Equal(t, expectedIDs, actualIDs, query)
+		}
+	})
+
+	t.Run("test get workshop queue with invalid status", func(t *testing.T) {
+		server := newServer()
+
+		resp := getResponse(t, server, makeRequest(t, http.MethodGet, "/api/repairs?status=reported,lost", nil))
+
+		assertBadRequestResponse(t, resp, map[string]string{"detail": "given repair status 'lost' is not valid"})
+	})
+}
+
+func TestInvoiceHandlers(t *testing.T) {
+	customer := getCustomer()
+	customer.Address = database.Address{Street: "Długa 1", City: "Kraków", PostalCode: "31-147", Country: "PL"}
+	purchase := database.Purchase{
+		ID:         "5c1fbd7a-6b5f-4a89-a6d1-d9b0b8e3b0a1",
+		FrameModel: "Ray-Ban RB5154",
+		LensType:   "Progressive",
+		CustomerID: customer.ID,
+		Price:      database.Money{Amount: 40000, Currency: "PLN"},
+		Discount:   database.Money{Amount: 5000, Currency: "PLN"},
+		VATRate:    8,
+	}
+	repair := database.Repair{
+		ID:          "0e0d3a1c-52a4-4cf8-8b52-2b2ad1fcd7a3",
+		Description: "Hinge replacement",
+		CustomerID:  customer.ID,
+		Cost:        database.Money{Amount: 12300, Currency: "PLN"},
+	}
+	newServer := func() *CustomerManagerServer {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{repairs: []database.Repair{repair}},
+			&StubPrescriptionRepository{},
+		)
+		server.invoicesRepository.(*StubInvoiceRepository).invoiceIDToCreate = "8d3e2f0a-3f5c-4c55-9d8c-2f6a4c1b7e10"
+		server.Seller = database.InvoiceParty{Name: "Optyk Sp. z o.o.", TaxID: "5260250274"}
+		return server
+	}
+	createInvoice := func(t *testing.T, server *CustomerManagerServer, body string) *http.Response {
+		t.Helper()
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/invoices", customer.ID),
+			bytes.NewBufferString(body),
+		)
+		return getResponse(t, server, req)
+	}
+	invoiceBody := fmt.Sprintf(`{
+		"purchase_ids": ["%s"],
+		"repair_ids": ["%s"],
+		"repair_vat_rate": 23,
+		"issued_at": "2024-03-01",
+		"buyer_tax_id": "7740001454"
+	}`, purchase.ID, repair.ID)
+
+	t.Run("test issue invoice for purchase and repair", func(t *testing.T) {
+		server := newServer()
+
+		resp := createInvoice(t, server, invoiceBody)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var invoice map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invoice))
+		assert.Equal(t, "FV/2024/0001", invoice["number"])
+		assert.Equal(t, "2024-03-01T00:00:00Z", invoice["issued_at"])
+		assert.Equal(t, map[string]any{
+			"name":    "John Doe",
+			"tax_id":  "7740001454",
+			"address": map[string]any{"street": "Długa 1", "city": "Kraków", "postal_code": "31-147", "country": "PL"},
+		}, invoice["buyer"])
+		assert.Equal(t, "Optyk Sp. z o.o.", invoice["seller"].(map[string]any)["name"])
+		assert.Equal(t, moneyResponse("424.07", "PLN"), invoice["net"])
+		assert.Equal(t, moneyResponse("48.93", "PLN"), invoice["vat"])
+		assert.Equal(t, moneyResponse("473.00", "PLN"), invoice["total"])
+		items := invoice["items"].([]any)
+		assert.Len(t, items, 2)
+		assert.Equal(t, map[string]any{
+			"id":          "",
+			"invoice_id":  "",
+			"position":    1.0,
+			"purchase_id": purchase.ID,
+			"repair_id":   nil,
+			"description": "Ray-Ban RB5154, Progressive",
+			"unit_price":  moneyResponse("400.00", "PLN"),
+			"discount":    moneyResponse("50.00", "PLN"),
+			"vat_rate":    8.0,
+			"net":         moneyResponse("324.07", "PLN"),
+			"vat":         moneyResponse("25.93", "PLN"),
+			"total":       moneyResponse("350.00", "PLN"),
+		}, items[0])
+		assert.Equal(t, repair.ID, items[1].(map[string]any)["repair_id"])
+		assert.Equal(t, 23.0, items[1].(map[string]any)["vat_rate"])
+		assert.Equal(t, moneyResponse("23.00", "PLN"), items[1].(map[string]any)["vat"])
+	})
+
+	t.Run("test issue invoice with invalid request", func(t *testing.T) {
+		server := newServer()
+		for body, errs := range map[string]map[string][]FieldError{
+			`{"purchase_ids": [], "issued_at": "2999-01-01"}`: {
+				"purchase_ids": {{Code: "invoiceItems", Message: "At least one purchase or repair has to be invoiced"}},
+				"issued_at":    {{Code: "pastDate", Message: "The 'issued_at' cannot be in the future"}},
+			},
+			fmt.Sprintf(`{"purchase_ids": ["%s", "%s"], "repair_ids": ["1"]}`, purchase.ID, purchase.ID): {
+				"purchase_ids.1":  {{Code: "duplicated", Message: "The 'purchase_ids.1' is given more than once"}},
+				"repair_ids.0":    {{Code: "uuid", Message: "The 'repair_ids.0' is not a valid UUID"}},
+				"repair_vat_rate": {{Code: "required", Message: "The 'repair_vat_rate' is required"}},
+			},
+			fmt.Sprintf(`{"repair_ids": ["%s"], "repair_vat_rate": 101}`, repair.ID): {
+				"repair_vat_rate": {{
+					Code:    "vatRate",
+					Message: "The 'repair_vat_rate' must be a percentage between 0 and 100",
+				}},
+			},
+		} {
+			resp := createInvoice(t, server, body)
+
+			assertValidationProblemResponse(t, resp, errs)
+		}
+	})
+
+	t.Run("test issue invoice for purchase of another customer", func(t *testing.T) {
+		server := newServer()
+		otherPurchase := purchase
+		otherPurchase.ID = "f1c1d0de-9f0b-4a4f-9a36-0d7d0b8e5b77"
+		otherPurchase.CustomerID = "a3f0f6c2-51c5-4d5b-8d4c-6a4b5f0b2f11"
+		server.purchasesRepository = &StubPurchaseRepository{purchases: []database.Purchase{otherPurchase}}
+
+		resp := createInvoice(t, server, fmt.Sprintf(`{"purchase_ids": ["%s"]}`, otherPurchase.ID))
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("purchase with ID '%s' does not exist", otherPurchase.ID),
+		})
+	})
+
+	t.Run("test issue invoice in mixed currencies", func(t *testing.T) {
+		server := newServer()
+		euroRepair := repair
+		euroRepair.Cost = database.Money{Amount: 1000, Currency: "EUR"}
+		server.repairsRepository = &StubRepairRepository{repairs: []database.Repair{euroRepair}}
+
+		resp := createInvoice(t, server, invoiceBody)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "invoiced purchases and repairs have to be in the same currency",
+		})
+	})
+
+	t.Run("test issue invoice for cancelled repair", func(t *testing.T) {
+		server := newServer()
+		cancelledRepair := repair
+		cancelledRepair.Status = database.RepairCancelled
+		server.repairsRepository = &StubRepairRepository{repairs: []database.Repair{cancelledRepair}}
+
+		resp := createInvoice(t, server, invoiceBody)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"repair_ids.0": {{
+				Code:    "cancelledRepair",
+				Message: "The 'repair_ids.0' is a cancelled repair, which cannot be invoiced",
+			}},
+		})
+	})
+
+	t.Run("test invoice purchase again", func(t *testing.T) {
+		server := newServer()
+		assert.Equal(t, fiber.StatusCreated, createInvoice(t, server, invoiceBody).StatusCode)
+
+		resp := createInvoice(t, server, fmt.Sprintf(`{"purchase_ids": ["%s"]}`, purchase.ID))
+
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeAlreadyInvoiced, problem.Type)
+		assert.Equal(
+			t,
+			fmt.Sprintf("purchase with ID '%s' is already invoiced on invoice 'FV/2024/0001'", purchase.ID),
+			problem.Detail,
+		)
+	})
+
+	t.Run("test get invoices of customer and invoice by id", func(t *testing.T) {
+		server := newServer()
+		assert.Equal(t, fiber.StatusCreated, createInvoice(t, server, invoiceBody).StatusCode)
+
+		resp := getResponse(t, server, makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/invoices", customer.ID),
+			nil,
+		))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var invoices []database.Invoice
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invoices))
+		assert.Len(t, invoices, 1)
+
+		resp = getResponse(t, server, makeRequest(t, http.MethodGet, "/api/invoices/"+invoices[0].ID, nil))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var invoice database.Invoice
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invoice))
+		assert.Equal(t, "FV/2024/0001", invoice.Number)
+		assert.Len(t, invoice.Items, 2)
+	})
+
+	t.Run("test get invoice but not found", func(t *testing.T) {
+		server := newServer()
+
+		resp := getResponse(t, server, makeRequest(
+			t,
+			http.MethodGet,
+			"/api/invoices/8d3e2f0a-3f5c-4c55-9d8c-2f6a4c1b7e10.pdf",
+			nil,
+		))
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": "invoice with given id '8d3e2f0a-3f5c-4c55-9d8c-2f6a4c1b7e10' does not exists",
+		})
+	})
+
+	t.Run("test get invoice as PDF", func(t *testing.T) {
+		server := newServer()
+		assert.Equal(t, fiber.StatusCreated, createInvoice(t, server, invoiceBody).StatusCode)
+		req := makeRequest(t, http.MethodGet, "/api/invoices/8d3e2f0a-3f5c-4c55-9d8c-2f6a4c1b7e10.pdf", nil)
+		req.Header.Set("Accept-Language", "pl")
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `inline; filename="FV-2024-0001.pdf"`, resp.Header.Get("Content-Disposition"))
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(body, []byte("%PDF-1.4")))
+		assert.Contains(t, string(body), "/Title (Faktura VAT FV/2024/0001)")
+		assert.True(t, bytes.HasSuffix(body, []byte("%%EOF\n")))
+	})
+}
+
+func TestRenderInvoice(t *testing.T) {
+	invoice := &database.Invoice{Number: "FV/2024/0001", IssuedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	for i := 0; i < 80; i++ {
+		rate := 23
+		if i%2 == 0 {
+			rate = 8
+		}
+		invoice.Items = append(invoice.Items, database.InvoiceItem{
+			Position:  i + 1,
+			UnitPrice: database.Money{Amount: 10000, Currency: "PLN"},
+			VATRate:   rate,
+			Total:     database.Money{Amount: 10000, Currency: "PLN"},
+		})
+	}
+	invoice.Items[0].Description = strings.Repeat("Oprawki korekcyjne z soczewkami progresywnymi ", 5)
+
+	t.Run("test items continue on the next page", func(t *testing.T) {
+		var document bytes.Buffer
+		_, err := renderInvoice(invoice, "en").WriteTo(&document)
+
+		assert.NoError(t, err)
+		assert.Contains(t, document.String(), "/Count 3")
+	})
+
+	t.Run("test VAT summary per rate", func(t *testing.T) {
+		invoice.Items[0].Net = database.Money{Amount: 9259, Currency: "PLN"}
+		invoice.Items[1].Net = database.Money{Amount: 8130, Currency: "PLN"}
+
+		summary := vatSummary(invoice)
+
+		assert.Len(t, summary, 2)
+		assert.Equal(t, 23, summary[0].rate)
+		assert.Equal(t, database.Money{Amount: 400000, Currency: "PLN"}, summary[0].gross)
+		assert.Equal(t, database.Money{Amount: 8130, Currency: "PLN"}, summary[0].net)
+		assert.Equal(t, 8, summary[1].rate)
+	})
+
+	t.Run("test wrap description", func(t *testing.T) {
+		lines := wrapText(pdf.Regular, 9, invoice.Items[0].Description, invoiceDescriptionWidth)
+
+		assert.Greater(t, len(lines), 1)
+		assert.Equal(t, strings.TrimSpace(invoice.Items[0].Description), strings.Join(lines, " "))
+		for _, line := range lines {
+			assert.LessOrEqual(t, pdf.TextWidth(pdf.Regular, 9, line), invoiceDescriptionWidth)
+		}
+		assert.Equal(t, []string{"Lorem"}, wrapText(pdf.Regular, 9, "Lorem", invoiceDescriptionWidth))
+		assert.Equal(t, []string{"aaaa", "aa"}, wrapText(pdf.Regular, 9, "aaaaaa", 21))
+	})
+}
+
+func TestPaymentHandlers(t *testing.T) {
+	customer := getCustomer()
+	purchase := database.Purchase{
+		ID:         "5c1fbd7a-6b5f-4a89-a6d1-d9b0b8e3b0a1",
+		CustomerID: customer.ID,
+		Price:      database.Money{Amount: 120000, Currency: "PLN"},
+	}
+	repair := database.Repair{
+		ID:         "0e0d3a1c-52a4-4cf8-8b52-2b2ad1fcd7a3",
+		CustomerID: customer.ID,
+		Cost:       database.Money{Amount: 500, Currency: "EUR"},
+	}
+	newServer := func() *CustomerManagerServer {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{repairs: []database.Repair{repair}},
+			&StubPrescriptionRepository{},
+		)
+		server.paymentsRepository.(*StubPaymentRepository).paymentIDToCreate = "3b1f4f7e-8a4c-4a0e-9c59-0f4f8c7d2a61"
+		return server
+	}
+	createPayment := func(t *testing.T, server *CustomerManagerServer, body string) *http.Response {
+		t.Helper()
+		req := makeRequest(
+			t,
+			http.MethodPost,
+			fmt.Sprintf("/api/customers/%s/payments", customer.ID),
+			bytes.NewBufferString(body),
+		)
+		return getResponse(t, server, req)
+	}
+
+	t.Run("test record deposit for purchase", func(t *testing.T) {
+		server := newServer()
+
+		resp := createPayment(t, server, fmt.Sprintf(`{
+			"purchase_id": "%s",
+			"method": "card",
+			"amount": {"amount": "300.00"},
+			"paid_at": "2024-03-01T10:30:00Z"
+		}`, purchase.ID))
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var payment map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payment))
+		assert.Equal(t, map[string]any{
+			"id":          "3b1f4f7e-8a4c-4a0e-9c59-0f4f8c7d2a61",
+			"customer_id": customer.ID,
+			"purchase_id": purchase.ID,
+			"repair_id":   nil,
+			"method":      "card",
+			"amount":      moneyResponse("300.00", "PLN"),
+			"refund":      false,
+			"paid_at":     "2024-03-01T10:30:00Z",
+			"created_at":  "0001-01-01T00:00:00Z",
+		}, payment)
+	})
+
+	t.Run("test refund repair in its currency", func(t *testing.T) {
+		server := newServer()
+
+		resp := createPayment(t, server, fmt.Sprintf(
+			`{"repair_id": "%s", "method": "cash", "amount": {"amount": "5"}, "refund": true}`,
+			repair.ID,
+		))
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		payments := server.paymentsRepository.(*StubPaymentRepository).payments
+		assert.Len(t, payments, 1)
+		assert.Equal(t, &repair.ID, payments[0].RepairID)
+		assert.Equal(t, database.Money{Amount: 500, Currency: "EUR"}, payments[0].Amount)
+		assert.True(t, payments[0].Refund)
+		assert.WithinDuration(t, time.Now(), payments[0].PaidAt, time.Minute)
+	})
+
+	t.Run("test record payment with invalid request", func(t *testing.T) {
+		server := newServer()
+		for body, errs := range map[string]map[string][]FieldError{
+			`{"method": "cheque", "amount": {"amount": "0"}, "paid_at": "2999-01-01T00:00:00Z"}`: {
+				"purchase_id": {{Code: "paidItem", Message: "Either 'purchase_id' or 'repair_id' has to be given"}},
+				"method": {{
+					Code:    "paymentMethod",
+					Message: "The 'method' must be one of cash, card or transfer",
+				}},
+				"amount.amount": {{Code: "positiveAmount", Message: "The 'amount.amount' must be greater than zero"}},
+				"paid_at":       {{Code: "pastDate", Message: "The 'paid_at' cannot be in the future"}},
+			},
+			fmt.Sprintf(
+				`{"purchase_id": "%s", "repair_id": "%s", "amount": {}, "paid_at": "2024-03-01"}`,
+				purchase.ID,
+				repair.ID,
+			): {
+				"purchase_id":   {{Code: "paidItem", Message: "Either 'purchase_id' or 'repair_id' has to be given"}},
+				"method":        {{Code: "required", Message: "The 'method' is required"}},
+				"amount.amount": {{Code: "required", Message: "The 'amount.amount' is required"}},
+				"paid_at": {{
+					Code:    "dateTime",
+					Message: "The 'paid_at' must be a date and time in RFC 3339 format, e.g. 2024-01-31T15:04:05Z",
+				}},
+			},
+		} {
+			resp := createPayment(t, server, body)
+
+			assertValidationProblemResponse(t, resp, errs)
+		}
+	})
+
+	t.Run("test record payment in other currency", func(t *testing.T) {
+		server := newServer()
+
+		resp := createPayment(t, server, fmt.Sprintf(
+			`{"purchase_id": "%s", "method": "card", "amount": {"amount": "10.00", "currency": "EUR"}}`,
+			purchase.ID,
+		))
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "payment has to be in PLN, the currency of the paid purchase or repair",
+		})
+	})
+
+	t.Run("test record payment of repair of another customer", func(t *testing.T) {
+		server := newServer()
+		otherRepair := repair
+		otherRepair.CustomerID = "a3f0f6c2-51c5-4d5b-8d4c-6a4b5f0b2f11"
+		server.repairsRepository = &StubRepairRepository{repairs: []database.Repair{otherRepair}}
+
+		resp := createPayment(t, server, fmt.Sprintf(
+			`{"repair_id": "%s", "method": "card", "amount": {"amount": "1.00"}}`,
+			repair.ID,
+		))
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("repair with ID '%s' does not exist", repair.ID),
+		})
+	})
+
+	t.Run("test record payment exceeding balance", func(t *testing.T) {
+		server := newServer()
+		server.paymentsRepository.(*StubPaymentRepository).createError = &repositories.BalanceExceededError{
+			Entity:    "purchase",
+			ID:        purchase.ID,
+			Available: database.Money{Amount: 20000, Currency: "PLN"},
+		}
+
+		resp := createPayment(t, server, fmt.Sprintf(
+			`{"purchase_id": "%s", "method": "transfer", "amount": {"amount": "1000.00"}}`,
+			purchase.ID,
+		))
+
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeBalanceExceeded, problem.Type)
+		assert.Equal(
+			t,
+			fmt.Sprintf("payment exceeds outstanding balance of 200.00 PLN of purchase with ID '%s'", purchase.ID),
+			problem.Detail,
+		)
+	})
+
+	t.Run("test get payments of customer", func(t *testing.T) {
+		server := newServer()
+		server.paymentsRepository.(*StubPaymentRepository).payments = []database.Payment{
+			{ID: "3b1f4f7e-8a4c-4a0e-9c59-0f4f8c7d2a61", CustomerID: customer.ID, PurchaseID: &purchase.ID},
+			{ID: "9a2c6d1e-4b7f-4e0a-8f3d-1c5b7e9a2d40", CustomerID: "a3f0f6c2-51c5-4d5b-8d4c-6a4b5f0b2f11"},
+		}
+
+		resp := getResponse(t, server, makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/payments", customer.ID),
+			nil,
+		))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var payments []database.Payment
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payments))
+		assert.Len(t, payments, 1)
+		assert.Equal(t, "3b1f4f7e-8a4c-4a0e-9c59-0f4f8c7d2a61", payments[0].ID)
+	})
+
+	t.Run("test get balance of customer", func(t *testing.T) {
+		server := newServer()
+		server.paymentsRepository.(*StubPaymentRepository).balance = &repositories.CustomerBalance{
+			CustomerID: customer.ID,
+			Totals: []repositories.Balance{{
+				Due:         database.Money{Amount: 120000, Currency: "PLN"},
+				Paid:        database.Money{Amount: 30000, Currency: "PLN"},
+				Outstanding: database.Money{Amount: 90000, Currency: "PLN"},
+			}},
+			Purchases: []repositories.ItemBalance{{
+				PurchaseID: &purchase.ID,
+				Balance: repositories.Balance{
+					Due:         database.Money{Amount: 120000, Currency: "PLN"},
+					Paid:        database.Money{Amount: 30000, Currency: "PLN"},
+					Outstanding: database.Money{Amount: 90000, Currency: "PLN"},
+				},
+			}},
+			Repairs: []repositories.ItemBalance{},
+		}
+
+		resp := getResponse(t, server, makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf("/api/customers/%s/balance", customer.ID),
+			nil,
+		))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var balance map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&balance))
+		outstanding := map[string]any{
+			"due":         moneyResponse("1200.00", "PLN"),
+			"paid":        moneyResponse("300.00", "PLN"),
+			"outstanding": moneyResponse("900.00", "PLN"),
+		}
+		assert.Equal(t, []any{outstanding}, balance["totals"])
+		outstanding["purchase_id"] = purchase.ID
+		assert.Equal(t, []any{outstanding}, balance["purchases"])
+		assert.Equal(t, []any{}, balance["repairs"])
+	})
+
+	t.Run("test get balance of customer but not found", func(t *testing.T) {
+		server := newServer()
+
+		resp := getResponse(t, server, makeRequest(
+			t,
+			http.MethodGet,
+			"/api/customers/a3f0f6c2-51c5-4d5b-8d4c-6a4b5f0b2f11/balance",
+			nil,
+		))
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": "customer with given id 'a3f0f6c2-51c5-4d5b-8d4c-6a4b5f0b2f11' does not exists",
+		})
+	})
+
+	t.Run("test get customers with unpaid balances", func(t *testing.T) {
+		server := newServer()
+		server.paymentsRepository.(*StubPaymentRepository).unpaid = []repositories.UnpaidBalance{{
+			Customer: customer,
+			Totals: []repositories.Balance{{
+				Due:         database.Money{Amount: 120000, Currency: "PLN"},
+				Paid:        database.Money{Amount: 0, Currency: "PLN"},
+				Outstanding: database.Money{Amount: 120000, Currency: "PLN"},
+			}},
+		}}
+
+		resp := getResponse(t, server, makeRequest(t, http.MethodGet, "/api/customers/unpaid", nil))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var unpaid []map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&unpaid))
+		assert.Len(t, unpaid, 1)
+		assert.Equal(t, customer.ID, unpaid[0]["customer"].(map[string]any)["id"])
+		assert.Equal(t, moneyResponse("1200.00", "PLN"), unpaid[0]["totals"].([]any)[0].(map[string]any)["outstanding"])
+	})
+}
+
+func TestCatalogHandlers(t *testing.T) {
+	customer := getCustomer()
+	frame := database.Frame{
+		Product: database.Product{ID: "7d1c5e2a-3f4b-4c6d-9e8f-0a1b2c3d4e5f", SKU: "RB5154-BLK-51", Stock: 1},
+		Brand:   "Ray-Ban",
+		Model:   "RB5154",
+		Color:   "Black",
+		Size:    "51-21-145",
+	}
+	lensType := database.LensType{
+		Product:  database.Product{ID: "2e4f6a8c-1b3d-4f5a-8c7e-9d0b1a2c3e4f", SKU: "SV-PC-AR", Stock: 0},
+		Name:     "Single vision",
+		Material: "Polycarbonate",
+		Coating:  "Anti-reflective",
+	}
+	newServer := func() *CustomerManagerServer {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchaseIDToCreate: "80dfb090-deea-4672-873d-a9cf8d4103e0"},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		frames := server.framesRepository.(*StubCatalogRepository[database.Frame])
+		frames.productIDToCreate = "4b6d8f0a-2c4e-4a6b-8d0f-1e3a5c7e9b2d"
+		frames.products = []database.Frame{frame}
+		server.lensTypesRepository.(*StubCatalogRepository[database.LensType]).products = []database.LensType{lensType}
+		return server
+	}
+	request := func(t *testing.T, server *CustomerManagerServer, method string, path string, body string) *http.Response {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		return getResponse(t, server, makeRequest(t, method, path, reader))
+	}
+	purchasesPath := fmt.Sprintf("/api/customers/%s/purchases", customer.ID)
+	purchaseBody := func(frameModel string, backorder bool) string {
+		return fmt.Sprintf(`{
+			"frame_id": "%s",
+			"frame_model": "%s",
+			"lens_type_id": "%s",
+			"pd": {"binocular": 61},
+			"purchase_type": "Glasses",
+			"purchased_at": "2024-01-01",
+			"backorder": %t
+		}`, frame.ID, frameModel, lensType.ID, backorder)
+	}
+
+	t.Run("test add frame to catalog", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPost, "/api/catalog/frames", `{
+			"sku": "OA-1001",
+			"barcode": "5901234123457",
+			"supplier": "Luxottica",
+			"reorder_threshold": 2,
+			"brand": "Oakley",
+			"model": "Holbrook",
+			"stock": 4
+		}`)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var created map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Equal(t, map[string]any{
+			"id":                "4b6d8f0a-2c4e-4a6b-8d0f-1e3a5c7e9b2d",
+			"sku":               "OA-1001",
+			"barcode":           "5901234123457",
+			"supplier":          "Luxottica",
+			"stock":             4.0,
+			"reorder_threshold": 2.0,
+			"brand":             "Oakley",
+			"model":             "Holbrook",
+			"color":             "",
+			"size":              "",
+			"created_at":        "0001-01-01T00:00:00Z",
+			"updated_at":        "0001-01-01T00:00:00Z",
+		}, created)
+	})
+
+	t.Run("test add product with invalid request", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(
+			t,
+			server,
+			http.MethodPost,
+			"/api/catalog/lens-types",
+			`{"sku": "SV-CR", "stock": -1, "reorder_threshold": -2}`,
+		)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"name":              {{Code: "required", Message: "The 'name' is required"}},
+			"stock":             {{Code: "min", Message: "The 'stock' must be at least 0"}},
+			"reorder_threshold": {{Code: "min", Message: "The 'reorder_threshold' must be at least 0"}},
+		})
+
+		resp = request(
+			t,
+			server,
+			http.MethodPost,
+			"/api/catalog/frames",
+			`{"sku": "RB5154-BLK-51", "brand": "Ray-Ban", "model": "RB5154"}`,
+		)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, ProblemTypeDuplicatedProductCode, decodeProblem(t, resp).Type)
+	})
+
+	t.Run("test list and get products", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodGet, "/api/catalog/frames?q=ray&code=RB5154-BLK-51", "")
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var frames []database.Frame
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&frames))
+		assert.Equal(t, []database.Frame{frame}, frames)
+		assert.Equal(
+			t,
+			repositories.ProductFilter{Q: "ray", Code: "RB5154-BLK-51"},
+			server.framesRepository.(*StubCatalogRepository[database.Frame]).filter,
+		)
+
+		resp = request(t, server, http.MethodGet, "/api/catalog/lens-types/"+lensType.ID, "")
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actual database.LensType
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		assert.Equal(t, lensType, actual)
+
+		resp = request(t, server, http.MethodGet, "/api/catalog/lens-types/"+frame.ID, "")
+
+		assertNotFoundResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("product with given id '%s' does not exists", frame.ID),
+		})
+	})
+
+	t.Run("test edit frame keeps its stock", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPut, "/api/catalog/frames/"+frame.ID, `{
+			"sku": "RB5154-HAV-51",
+			"brand": "Ray-Ban",
+			"model": "RB5154",
+			"color": "Havana",
+			"stock": 100
+		}`)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var edited database.Frame
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&edited))
+		assert.Equal(t, "Havana", edited.Color)
+		assert.Equal(t, 1, edited.Stock)
+	})
+
+	t.Run("test adjust stock", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPost, "/api/catalog/lens-types/"+lensType.ID+"/stock", `{"quantity": 10}`)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var adjusted database.LensType
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&adjusted))
+		assert.Equal(t, 10, adjusted.Stock)
+
+		resp = request(t, server, http.MethodPost, "/api/catalog/frames/"+frame.ID+"/stock", `{"quantity": -2}`)
+
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeOutOfStock, problem.Type)
+		assert.Equal(t, "product with SKU 'RB5154-BLK-51' has only 1 in stock", problem.Detail)
+
+		resp = request(t, server, http.MethodPost, "/api/catalog/frames/"+frame.ID+"/stock", `{"quantity": 0}`)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"quantity": {{Code: "required", Message: "The 'quantity' is required"}},
+		})
+	})
+
+	t.Run("test create purchase of products out of stock", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPost, purchasesPath, purchaseBody("", false))
+
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeOutOfStock, problem.Type)
+		assert.Equal(t, "product with SKU 'SV-PC-AR' is out of stock", problem.Detail)
+	})
+
+	t.Run("test create purchase of products on backorder", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPost, purchasesPath, purchaseBody("", true))
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var purchase database.Purchase
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&purchase))
+		assert.Equal(t, &frame.ID, purchase.FrameID)
+		assert.Equal(t, "Ray-Ban RB5154 Black 51-21-145", purchase.FrameModel)
+		assert.Equal(t, &lensType.ID, purchase.LensTypeID)
+		assert.Equal(t, "Single vision Polycarbonate Anti-reflective", purchase.LensType)
+		assert.Equal(t, []string{"lens type with SKU 'SV-PC-AR' is out of stock, it is backordered"}, purchase.StockWarnings)
+		frames := server.framesRepository.(*StubCatalogRepository[database.Frame]).products
+		assert.Equal(t, 0, frames[0].Stock)
+		lensTypes := server.lensTypesRepository.(*StubCatalogRepository[database.LensType]).products
+		assert.Equal(t, -1, lensTypes[0].Stock)
+	})
+
+	t.Run("test create purchase keeps given frame model", func(t *testing.T) {
+		server := newServer()
+		server.lensTypesRepository.(*StubCatalogRepository[database.LensType]).products[0].Stock = 1
+
+		resp := request(t, server, http.MethodPost, purchasesPath, purchaseBody("RB5154 demo", false))
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		var purchase map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&purchase))
+		assert.Equal(t, "RB5154 demo", purchase["frame_model"])
+		assert.NotContains(t, purchase, "stock_warnings")
+	})
+
+	t.Run("test create purchase of unknown frame", func(t *testing.T) {
+		server := newServer()
+		server.framesRepository.(*StubCatalogRepository[database.Frame]).products = nil
+
+		resp := request(t, server, http.MethodPost, purchasesPath, purchaseBody("", true))
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": fmt.Sprintf("product with ID '%s' does not exist", frame.ID),
+		})
+	})
+
+	t.Run("test create purchase without frame", func(t *testing.T) {
+		server := newServer()
+
+		resp := request(t, server, http.MethodPost, purchasesPath, `{
+			"lens_type_id": "2e4f6a8c-1b3d-4f5a-8c7e-9d0b1a2c3e4f",
+			"pd": {"binocular": 61},
+			"purchase_type": "Glasses",
+			"purchased_at": "2024-01-01"
+		}`)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"frame_model": {{Code: "required", Message: "The 'frame_model' is required"}},
+		})
+	})
+
+	soldPurchase := database.Purchase{
+		ID:         "80dfb090-deea-4672-873d-a9cf8d4103e0",
+		FrameID:    &frame.ID,
+		FrameModel: "Ray-Ban RB5154 Black 51-21-145",
+		LensTypeID: &lensType.ID,
+		CustomerID: customer.ID,
+		Version:    1,
+	}
+	stocks := func(server *CustomerManagerServer) []int {
+		frames := server.framesRepository.(*StubCatalogRepository[database.Frame]).products
+		lensTypes := server.lensTypesRepository.(*StubCatalogRepository[database.LensType]).products
+		return []int{frames[0].Stock, lensTypes[0].Stock}
+	}
+
+	t.Run("test delete purchase gives its products back to stock", func(t *testing.T) {
+		server := newServer()
+		server.purchasesRepository.(*StubPurchaseRepository).purchases = []database.Purchase{soldPurchase}
+		req := makeRequest(t, http.MethodDelete, purchasesPath+"/"+soldPurchase.ID, nil)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, []int{2, 1}, stocks(server))
+	})
+
+	t.Run("test restore purchase takes its products out of stock again", func(t *testing.T) {
+		server := newServer()
+		server.purchasesRepository.(*StubPurchaseRepository).deletedPurchases = []database.Purchase{soldPurchase}
+
+		resp := request(t, server, http.MethodPost, purchasesPath+"/"+soldPurchase.ID+"/restore", "")
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, []int{0, -1}, stocks(server))
+
+		resp = request(t, server, http.MethodPost, purchasesPath+"/"+soldPurchase.ID+"/restore", "")
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, []int{0, -1}, stocks(server))
+	})
+
+	t.Run("test delete customer gives products of their purchases back to stock", func(t *testing.T) {
+		server := newServer()
+		server.customerRepository.(*StubCustomerRepository).customers[0].Version = 1
+		server.purchasesRepository.(*StubPurchaseRepository).purchases = []database.Purchase{soldPurchase}
+		req := makeRequest(t, http.MethodDelete, "/api/customers/"+customer.ID, nil)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, []int{2, 1}, stocks(server))
+	})
+
+	t.Run("test restore customer takes products of their purchases out of stock again", func(t *testing.T) {
+		server := newServer()
+		customers := server.customerRepository.(*StubCustomerRepository)
+		customers.customers, customers.deletedCustomers = nil, []database.Customer{customer}
+		server.purchasesRepository.(*StubPurchaseRepository).purchases = []database.Purchase{soldPurchase}
+
+		resp := request(t, server, http.MethodPost, "/api/customers/"+customer.ID+"/restore", "")
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, []int{0, -1}, stocks(server))
+	})
+}
+
+func TestReorderSuggestionsHandler(t *testing.T) {
+	suggestions := []repositories.SupplierReorder{{
+		Supplier: "Luxottica",
+		Items: []repositories.ReorderItem{{
+			Kind:             "frame",
+			ProductID:        "7d1c5e2a-3f4b-4c6d-9e8f-0a1b2c3d4e5f",
+			SKU:              "RB5154-BLK-51",
+			Description:      "Ray-Ban RB5154 Black 51-21-145",
+			Stock:            1,
+			ReorderThreshold: 2,
+			Sold:             9,
+			DailySales:       0.1,
+			Quantity:         5,
+		}},
+	}}
+	newServer := func() (*CustomerManagerServer, *StubInventoryRepository) {
+		server := newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{},
+			&StubPurchaseRepository{},
+			&StubRepairRepository{},
+			&StubPrescriptionRepository{},
+		)
+		inventory := server.inventoryRepository.(*StubInventoryRepository)
+		inventory.suggestions = suggestions
+		return server, inventory
+	}
+	path := "/api/inventory/reorder-suggestions"
+
+	t.Run("test get reorder suggestions computed on first request", func(t *testing.T) {
+		server, inventory := newServer()
+
+		resp := getResponse(t, server, makeRequest(t, http.MethodGet, path, nil))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var got repositories.ReorderSuggestions
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, suggestions, got.Suppliers)
+		assert.False(t, got.ComputedAt.IsZero())
+		assert.Equal(t, 1, inventory.computed)
+		assert.Equal(t, repositories.DefaultReorderPolicy, inventory.policy)
+	})
+
+	t.Run("test get reorder suggestions of last reorder job run", func(t *testing.T) {
+		server, inventory := newServer()
+		server.runReorderJob(context.Background())
+		inventory.suggestions = nil
+
+		resp := getResponse(t, server, makeRequest(t, http.MethodGet, path, nil))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var got repositories.ReorderSuggestions
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, suggestions, got.Suppliers)
+		assert.Equal(t, 1, inventory.computed)
+
+		resp = getResponse(t, server, makeRequest(t, http.MethodGet, path+"?refresh=true", nil))
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		got = repositories.ReorderSuggestions{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Empty(t, got.Suppliers)
+		assert.Equal(t, 2, inventory.computed)
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	customer := getCustomer()
+	server := newTestServer(
+		newTestApp(),
+		&SlowCustomerRepository{StubCustomerRepository{customers: []database.Customer{customer}}},
+		&StubPurchaseRepository{},
+		&StubRepairRepository{},
+		&StubPrescriptionRepository{},
+	)
+	server.QueryTimeout = 10 * time.Millisecond
+
+	t.Run("test get customer exceeding query timeout", func(t *testing.T) {
+		req := makeRequest(t, http.MethodGet, fmt.Sprintf("/api/customers/%s", customer.ID), nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{"detail": "request did not finish within 10ms"})
+	})
+
+	t.Run("test get all customers exceeding query timeout", func(t *testing.T) {
+		req := makeRequest(t, http.MethodGet, "/api/customers", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+		assertResponse(t, resp, map[string]string{"detail": "request did not finish within 10ms"})
+	})
+
+	t.Run("test request within query timeout", func(t *testing.T) {
+		server.customerRepository = &StubCustomerRepository{customers: []database.Customer{customer}}
+		req := makeRequest(t, http.MethodGet, fmt.Sprintf("/api/customers/%s", customer.ID), nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestProblemResponses(t *testing.T) {
+	customer := getCustomer()
+	customer.Version = 2
+	server := newTestServer(
+		newTestApp(),
+		&FailingCustomerRepository{StubCustomerRepository{customers: []database.Customer{customer}}},
+		&StubPurchaseRepository{},
+		&StubRepairRepository{},
+		&StubPrescriptionRepository{},
+	)
+
+	t.Run("test unexpected error does not reveal its details", func(t *testing.T) {
+		req := makeRequest(t, http.MethodGet, "/api/customers", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, Problem{
+			Type:     ProblemTypeBlank,
+			Title:    "Internal Server Error",
+			Status:   fiber.StatusInternalServerError,
+			Detail:   "unexpected error occurred",
+			Instance: "/api/customers",
+		}, decodeProblem(t, resp))
+	})
+
+	t.Run("test not found customer", func(t *testing.T) {
+		customerID := "4a923682-1234-47c1-b37a-666544d71419"
+		req := makeRequest(t, http.MethodGet, "/api/customers/"+customerID, nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, Problem{
+			Type:     ProblemTypeNotFound,
+			Title:    "Resource not found",
+			Status:   fiber.StatusNotFound,
+			Detail:   fmt.Sprintf("customer with given id '%s' does not exists", customerID),
+			Instance: "/api/customers/" + customerID,
+		}, decodeProblem(t, resp))
+	})
+
+	t.Run("test customer changed in the meantime", func(t *testing.T) {
+		req := makeRequest(t, http.MethodDelete, "/api/customers/"+customer.ID, nil)
+		req.Header.Set("If-Match", `"1"`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeVersionConflict, problem.Type)
+		assert.Equal(t, "Resource changed in the meantime", problem.Title)
+	})
+
+	t.Run("test unknown route", func(t *testing.T) {
+		req := makeRequest(t, http.MethodGet, "/api/unknown", nil)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, ProblemTypeBlank, problem.Type)
+		assert.Equal(t, "Not Found", problem.Title)
+		assert.Equal(t, "Cannot GET /api/unknown", problem.Detail)
+	})
+}
+
+func TestAuditHandlers(t *testing.T) {
+	server := newTestServer(
+		newTestApp(),
+		&StubCustomerRepository{},
+		&StubPurchaseRepository{},
+		&StubRepairRepository{},
+		&StubPrescriptionRepository{},
+	)
+
+	t.Run("test get audit log of an entity", func(t *testing.T) {
+		customerID := "ec8f6cb1-61f6-4dfc-b970-9dd81ff2547f"
+		auditRepository := &StubAuditRepository{entries: []database.AuditEntry{
+			{
+				ID:         "b483c02c-d4d0-4da9-8601-50a72c1eac14",
+				Action:     database.AuditUpdate,
+				EntityType: database.AuditCustomer,
+				EntityID:   customerID,
+				Actor:      "alice",
+				Changes:    database.AuditChanges{"telephone_number": {Old: "123", New: "321"}},
+				CreatedAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{EntityID: "5b521e40-e0f1-47fd-a832-fe6ea3fba22c"},
+		}}
+		server.auditRepository = auditRepository
+		req := makeRequest(
+			t,
+			http.MethodGet,
+			fmt.Sprintf(
+				"/api/audit?entityID=%s&actor=alice&from=2023-01-01T00:00:00Z&to=2023-01-02T00:00:00%%2B02:00&limit=5",
+				customerID,
+			),
+			nil,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, map[string]any{
+			"data": []any{map[string]any{
+				"id":          "b483c02c-d4d0-4da9-8601-50a72c1eac14",
+				"action":      "update",
+				"entity_type": "customer",
+				"entity_id":   customerID,
+				"actor":       "alice",
+				"changes":     map[string]any{"telephone_number": map[string]any{"old": "123", "new": "321"}},
+				"created_at":  "2023-01-01T00:00:00Z",
+			}},
+			"total": 1.0,
+		}, body)
+		filter := auditRepository.filter
+		assert.Equal(t, customerID, filter.EntityID)
+		assert.Equal(t, "alice", filter.Actor)
+		assert.True(t, filter.From.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, filter.To.Equal(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC)))
+		assert.Equal(t, 5, filter.Limit)
+	})
+
+	t.Run("test get audit log with invalid time range", func(t *testing.T) {
+		server.auditRepository = &StubAuditRepository{}
+		req := makeRequest(t, http.MethodGet, "/api/audit?from=yesterday", nil)
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "given from time 'yesterday' is not a valid RFC 3339 time",
+		})
+	})
+
+	t.Run("test get audit log of invalid entity id", func(t *testing.T) {
+		server.auditRepository = &StubAuditRepository{}
+		req := makeRequest(t, http.MethodGet, "/api/audit?entityID=im-not-uuid", nil)
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "given entity id 'im-not-uuid' is not a valid UUID",
+		})
+	})
+}
+
+func TestMergePatchHandlers(t *testing.T) {
+	customer := getCustomer()
+	customer.Version = 1
+	purchase := database.Purchase{
+		ID:           "ca1224cb-c993-4d45-8053-73c56aaf2c77",
+		FrameModel:   "Model1",
+		LensType:     "Lens1",
+		LensPower:    getLensPower(),
+		PD:           database.PupillaryDistance{Binocular: 61},
+		PurchaseType: "PurchaseType1",
+		PurchasedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Price:        database.Money{Amount: 40000, Currency: "PLN"},
+		Discount:     database.Money{Amount: 5000, Currency: "PLN"},
+		VATRate:      23,
+		CustomerID:   customer.ID,
+		Version:      1,
+	}
+	prescription := database.Prescription{
+		ID:         "0f5f4c4e-5d34-4d8f-9d0f-9a0b6f2c6a11",
+		LensPower:  database.LensPower{Right: database.EyePrescription{Sphere: 2}},
+		PD:         database.PupillaryDistance{Binocular: 64},
+		CustomerID: customer.ID,
+	}
+	repair := database.Repair{
+		ID:          "5b521e40-e0f1-47fd-a832-fe6ea3fba22c",
+		Description: "To be repaired",
+		Cost:        database.Money{Amount: 1250, Currency: "PLN"},
+		CustomerID:  customer.ID,
+		ReportedAt:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:      database.RepairReported,
+		Version:     1,
+	}
+	newServer := func() *CustomerManagerServer {
+		return newTestServer(
+			newTestApp(),
+			&StubCustomerRepository{customers: []database.Customer{customer}},
+			&StubPurchaseRepository{purchases: []database.Purchase{purchase}},
+			&StubRepairRepository{repairs: []database.Repair{repair}},
+			&StubPrescriptionRepository{prescriptions: []database.Prescription{prescription}},
+		)
+	}
+	makePatchRequest := func(t *testing.T, path string, patch string) *http.Request {
+		req := makeRequest(t, http.MethodPatch, path, bytes.NewBufferString(patch))
+		req.Header.Set("Content-Type", MIMEApplicationMergePatchJSON)
+		req.Header.Set("If-Match", `"1"`)
+		return req
+	}
+
+	t.Run("test patch customer changes only given details", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(t, "/api/customers/"+customer.ID, `{"first_name": "Bob"}`)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		assertCustomerDetailsResponse(t, resp, map[string]any{
+			"id":                    customer.ID,
+			"first_name":            "Bob",
+			"last_name":             "Doe",
+			"telephone_number":      "123-456-789",
+			"telephone_number_e164": nil,
+			"email":                 "",
+			"address":               emptyAddressResponse(),
+			"birth_date":            nil,
+			"preferred_contact":     "",
+			"notes":                 "",
+			"created_at":            "0001-01-01T00:00:00Z",
+			"updated_at":            "0001-01-01T00:00:00Z",
+			"deleted_at":            nil,
+			"version":               2.0,
+		})
+	})
+
+	t.Run("test patch customer validates only given details", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(t, "/api/customers/"+customer.ID, `{"last_name": null, "nickname": "Johnny"}`)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"last_name": {{Code: "required", Message: "The 'last_name' is required"}},
+		})
+	})
+
+	t.Run("test patch customer merges nested address", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			"/api/customers/"+customer.ID,
+			`{"address": {"street": "Długa 1", "city": "Kraków", "postal_code": "30-001"}, "preferred_contact": "post"}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualCustomer database.Customer
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualCustomer))
+		assert.Equal(t, database.Address{Street: "Długa 1", City: "Kraków", PostalCode: "30-001"}, actualCustomer.Address)
+		assert.Equal(t, database.ContactPost, actualCustomer.PreferredContact)
+		assert.Equal(t, "123-456-789", actualCustomer.TelephoneNumber)
+	})
+
+	t.Run("test patch customer preferred contact requires its contact details", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(t, "/api/customers/"+customer.ID, `{"preferred_contact": "email"}`)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"email": {{
+				Code:    "requiredForContact",
+				Message: "The 'email' is required for the preferred contact channel",
+			}},
+		})
+	})
+
+	t.Run("test patch customer with invalid merge patch", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(t, "/api/customers/"+customer.ID, `["first_name"]`)
+
+		resp := getResponse(t, server, req)
+
+		assertBadRequestResponse(t, resp, map[string]string{
+			"detail": "given body is not a valid JSON merge patch object",
+		})
+	})
+
+	t.Run("test patch purchase merges nested lens power", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			`{"lens_power": {"left": {"add": 2.5}}, "frame_model": "Model2"}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualPurchase database.Purchase
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualPurchase))
+		expectedLensPower := getLensPower()
+		expectedLensPower.Left.Add = 2.5
+		assert.Equal(t, expectedLensPower, actualPurchase.LensPower)
+		assert.Equal(t, "Model2", actualPurchase.FrameModel)
+		assert.Equal(t, purchase.PD, actualPurchase.PD)
+		assert.Equal(t, purchase.PurchasedAt, actualPurchase.PurchasedAt)
+	})
+
+	t.Run("test patch purchase VAT rate keeps the price", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			`{"vat_rate": 8}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualPurchase database.Purchase
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualPurchase))
+		assert.Equal(t, purchase.Price, actualPurchase.Price)
+		assert.Equal(t, purchase.Discount, actualPurchase.Discount)
+		assert.Equal(t, 8, actualPurchase.VATRate)
+		assert.Equal(t, database.Money{Amount: 35000, Currency: "PLN"}, actualPurchase.Total)
+		assert.Equal(t, database.Money{Amount: 2593, Currency: "PLN"}, actualPurchase.VAT)
+	})
+
+	t.Run("test patch purchase price below the discount", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			`{"price": {"amount": "40"}}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"discount.amount": {{Code: "discount", Message: "The 'discount.amount' cannot exceed the price"}},
+		})
+	})
+
+	t.Run("test patch purchase prescription takes lens power from it", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/purchases/%s", customer.ID, purchase.ID),
+			fmt.Sprintf(`{"prescription_id": "%s"}`, prescription.ID),
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var actualPurchase database.Purchase
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actualPurchase))
+		assert.Equal(t, &prescription.ID, actualPurchase.PrescriptionID)
+		assert.Equal(t, prescription.LensPower, actualPurchase.LensPower)
+		assert.Equal(t, prescription.PD, actualPurchase.PD)
+	})
+
+	t.Run("test patch repair details and status", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, repair.ID),
+			`{"cost": {"amount": "20.00"}, "status": "in_progress"}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+		err, actualRepair := server.repairsRepository.GetByID(context.Background(), repair.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, database.Money{Amount: 2000, Currency: "PLN"}, actualRepair.Cost)
+		assert.Equal(t, repair.Description, actualRepair.Description)
+		assert.Equal(t, database.RepairInProgress, actualRepair.Status)
+	})
+
+	t.Run("test patch repair with invalid reported date", func(t *testing.T) {
+		server := newServer()
+		req := makePatchRequest(
+			t,
+			fmt.Sprintf("/api/customers/%s/repairs/%s", customer.ID, repair.ID),
+			`{"reported_at": "yesterday"}`,
+		)
+
+		resp := getResponse(t, server, req)
+
+		assertValidationProblemResponse(t, resp, map[string][]FieldError{
+			"reported_at": {{Code: "date", Message: "The 'reported_at' must be a date in YYYY-MM-DD format"}},
+		})
+	})
+
+	t.Run("test create repair with invalid cost", func(t *testing.T) {
+		for cost, errs := range map[string]map[string][]FieldError{
+			`{"amount": "12.345"}`: {"cost.amount": {{
+				Code:    "moneyScale",
+				Message: "The 'cost.amount' has more decimal places than its currency",
+			}}},
+			`{"amount": "1.5", "currency": "JPY"}`: {"cost.amount": {{
+				Code:    "moneyScale",
+				Message: "The 'cost.amount' has more decimal places than its currency",
+			}}},
+			`{"amount": "-5"}`: {"cost.amount": {{
+				Code:    "money",
+				Message: "The 'cost.amount' must be a non-negative decimal amount, e.g. 120.50",
+			}}},
+			`{"amount": "5", "currency": "ZZZ"}`: {"cost.currency": {{
+				Code:    "currency",
+				Message: "The 'cost.currency' must be an ISO 4217 currency code, e.g. PLN",
+			}}},
+			`{}`: {"cost.amount": {{Code: "required", Message: "The 'cost.amount' is required"}}},
+		} {
+			server := newServer()
+			req := makeRequest(
+				t,
+				http.MethodPost,
+				fmt.Sprintf("/api/customers/%s/repairs", customer.ID),
+				bytes.NewBuffer([]byte(fmt.Sprintf(
+					`{"description": "Broken temple", "cost": %s, "reported_at": "2021-01-01"}`, cost,
+				))),
+			)
+
+			resp := getResponse(t, server, req)
+
+			assertValidationProblemResponse(t, resp, errs)
+		}
+	})
 }
@@ -1,89 +1,487 @@
 package server
 
 import (
-	"reflect"
+	"customer-manager/database"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gookit/validate"
+	"golang.org/x/exp/slices"
 )
 
+// DateLayout is the format of dates given in requests.
+const DateLayout = "2006-01-02"
+
+type AddressRequest struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code" validate:"maxLen:16"`
+	Country    string `json:"country"     validate:"country"   example:"PL"`
+}
+
+// EditCustomerDetailsRequest holds customer details, all but names and telephone number are optional.
 type EditCustomerDetailsRequest struct {
-	FirstName       string `json:"first_name"       validate:"required"`
-	LastName        string `json:"last_name"        validate:"required"`
-	TelephoneNumber string `json:"telephone_number" validate:"required"`
+	FirstName        string         `json:"first_name"        validate:"required"`
+	LastName         string         `json:"last_name"         validate:"required"`
+	TelephoneNumber  string         `json:"telephone_number"  validate:"required"`
+	Email            string         `json:"email"             validate:"email|maxLen:256"`
+	Address          AddressRequest `json:"address"`
+	BirthDate        string         `json:"birth_date"        validate:"date"                example:"1980-01-01"`
+	PreferredContact string         `json:"preferred_contact" validate:"contactChannel"      example:"sms"`
+	Notes            string         `json:"notes"             validate:"maxLen:2000"`
 }
 
 type CreateCustomerRequest = EditCustomerDetailsRequest
 
-type Date time.Time
+type EyePrescriptionRequest struct {
+	Sphere   float64 `json:"sphere"   validate:"sphere"`
+	Cylinder float64 `json:"cylinder" validate:"cylinder"`
+	Axis     int     `json:"axis"     validate:"axis"`
+	Add      float64 `json:"add"      validate:"addition"`
+	Prism    float64 `json:"prism"    validate:"prism"`
+	Base     string  `json:"base"     validate:"prismBase"`
+}
 
-func (d *Date) UnmarshalJSON(b []byte) error {
-	t, err := time.Parse("2006-01-02", strings.ReplaceAll(string(b), "\"", ""))
-	if err != nil {
-		return err
-	}
-	*d = Date(t)
-	return nil
+type LensPowerRequest struct {
+	Right EyePrescriptionRequest `json:"right"`
+	Left  EyePrescriptionRequest `json:"left"`
 }
 
-func (d *Date) MarshalJSON() ([]byte, error) {
-	return []byte(time.Time(*d).Format("2006-01-02")), nil
+type PupillaryDistanceRequest struct {
+	Binocular float64 `json:"binocular" validate:"binocularPD"`
+	Right     float64 `json:"right"     validate:"monocularPD"`
+	Left      float64 `json:"left"      validate:"monocularPD"`
 }
 
+// MoneyRequest is an amount given as a decimal string, e.g. "120.50", in the currency of the ISO 4217 code,
+// which defaults to the currency of the server.
+type MoneyRequest struct {
+	Amount   string `json:"amount"   validate:"money"    example:"120.50"`
+	Currency string `json:"currency" validate:"currency" example:"PLN"`
+}
+
+// EditPurchaseRequest holds purchase details. The price includes VAT and is optional, the discount
+// is taken off the price.
+type EditPurchaseRequest struct {
+	FrameModel     string                   `json:"frame_model"`
+	LensType       string                   `json:"lens_type"`
+	LensPower      LensPowerRequest         `json:"lens_power"`
+	PD             PupillaryDistanceRequest `json:"pd"`
+	PrescriptionID string                   `json:"prescription_id" validate:"uuid"`
+	PurchaseType   string                   `json:"purchase_type"   validate:"required"`
+	PurchasedAt    string                   `json:"purchased_at"    validate:"required|date" example:"2021-01-01"`
+	Price          MoneyRequest             `json:"price"`
+	Discount       MoneyRequest             `json:"discount"`
+	VATRate        int                      `json:"vat_rate"        validate:"vatRate"       example:"23"`
+}
+
+// CreatePurchaseRequest holds details of a new purchase, whose frame and lens type may be picked from
+// the catalog. Their descriptions are then taken as the frame model and the lens type unless those are given.
+// Items out of stock are only sold when backorder is set.
 type CreatePurchaseRequest struct {
-	FrameModel   string `json:"frame_model"   validate:"required"`
-	LensType     string `json:"lens_type"     validate:"required"`
-	LensPower    string `json:"lens_power"    validate:"required"`
-	PD           string `json:"pd"            validate:"required"`
-	PurchaseType string `json:"purchase_type" validate:"required"`
-	// TODO - when invalid date specified it returns field is required
-	PurchasedAt Date `json:"purchased_at"  validate:"required"`
+	EditPurchaseRequest
+	FrameID    string `json:"frame_id"     validate:"uuid"`
+	LensTypeID string `json:"lens_type_id" validate:"uuid"`
+	Backorder  bool   `json:"backorder"`
 }
 
-type EditPurchaseRequest = CreatePurchaseRequest
+type CreatePrescriptionRequest struct {
+	LensPower LensPowerRequest         `json:"lens_power"`
+	PD        PupillaryDistanceRequest `json:"pd"`
+	IssuedBy  string                   `json:"issued_by"  validate:"required"`
+	IssuedAt  string                   `json:"issued_at"  validate:"required|date" example:"2021-01-01"`
+	ExpiresAt string                   `json:"expires_at" validate:"required|date" example:"2022-01-01"`
+}
+
+type EditPrescriptionRequest = CreatePrescriptionRequest
 
-func getValidator(s interface{}) *validate.Validation {
+type CreateRepairRequest struct {
+	Description string       `json:"description" validate:"required"`
+	Cost        MoneyRequest `json:"cost"`
+	ReportedAt  string       `json:"reported_at" validate:"required|date" example:"2021-01-01"`
+}
+
+type EditRepairRequest = CreateRepairRequest
+
+// PatchRepairRequest holds repair details which can be changed with a merge patch, including the status.
+type PatchRepairRequest struct {
+	EditRepairRequest
+	Status string `json:"status" validate:"required|repairStatus"`
+}
+
+// CreateInvoiceRequest lists purchases and repairs of the customer to invoice. Repairs do not have a VAT rate
+// of their own, so the one given is applied to all of them. The invoice is issued today unless issued_at is given.
+type CreateInvoiceRequest struct {
+	PurchaseIDs   []string `json:"purchase_ids"`
+	RepairIDs     []string `json:"repair_ids"`
+	RepairVATRate *int     `json:"repair_vat_rate"                      example:"23"`
+	IssuedAt      string   `json:"issued_at"       validate:"date"      example:"2024-01-31"`
+	BuyerTaxID    string   `json:"buyer_tax_id"    validate:"maxLen:32" example:"5260250274"`
+}
+
+// CreatePaymentRequest holds a payment of either a purchase or a repair of the customer, in the currency
+// it is due in unless the amount is given in another one. Refunds return the amount to the customer.
+// The payment is made now unless paid_at is given.
+type CreatePaymentRequest struct {
+	PurchaseID string       `json:"purchase_id" validate:"uuid"`
+	RepairID   string       `json:"repair_id"   validate:"uuid"`
+	Method     string       `json:"method"      validate:"required|paymentMethod" example:"card"`
+	Amount     MoneyRequest `json:"amount"`
+	Refund     bool         `json:"refund"`
+	PaidAt     string       `json:"paid_at"     validate:"dateTime"               example:"2024-01-31T15:04:05Z"`
+}
+
+// EditFrameRequest holds details of a frame of the catalog, the barcode and the supplier are optional.
+// The frame is suggested for reordering once its stock is expected to fall below the reorder threshold.
+type EditFrameRequest struct {
+	SKU              string `json:"sku"               validate:"required|maxLen:64"  example:"RB5154-BLK-51"`
+	Barcode          string `json:"barcode"           validate:"maxLen:64"           example:"8056597000000"`
+	Supplier         string `json:"supplier"          validate:"maxLen:128"          example:"Luxottica"`
+	ReorderThreshold int    `json:"reorder_threshold" validate:"min:0"               example:"2"`
+	Brand            string `json:"brand"             validate:"required|maxLen:128" example:"Ray-Ban"`
+	Model            string `json:"model"             validate:"required|maxLen:128" example:"RB5154"`
+	Color            string `json:"color"             validate:"maxLen:64"           example:"Black"`
+	Size             string `json:"size"              validate:"maxLen:32"           example:"51-21-145"`
+}
+
+// CreateFrameRequest holds details of a new frame along with the quantity initially in stock,
+// which is changed by stock adjustments afterwards.
+type CreateFrameRequest struct {
+	EditFrameRequest
+	Stock int `json:"stock" validate:"min:0"`
+}
+
+// EditLensTypeRequest holds details of a lens type of the catalog, the barcode and the supplier are optional.
+// The reorder threshold is given in pairs of lenses.
+type EditLensTypeRequest struct {
+	SKU              string `json:"sku"               validate:"required|maxLen:64"  example:"SV-PC-AR"`
+	Barcode          string `json:"barcode"           validate:"maxLen:64"`
+	Supplier         string `json:"supplier"          validate:"maxLen:128"          example:"Hoya"`
+	ReorderThreshold int    `json:"reorder_threshold" validate:"min:0"               example:"5"`
+	Name             string `json:"name"              validate:"required|maxLen:128" example:"Single vision"`
+	Material         string `json:"material"          validate:"maxLen:64"           example:"Polycarbonate"`
+	Coating          string `json:"coating"           validate:"maxLen:64"           example:"Anti-reflective"`
+}
+
+// CreateLensTypeRequest holds details of a new lens type along with the number of pairs initially in stock,
+// which is changed by stock adjustments afterwards.
+type CreateLensTypeRequest struct {
+	EditLensTypeRequest
+	Stock int `json:"stock" validate:"min:0"`
+}
+
+// StockAdjustmentRequest adds the quantity to the stock of a product, e.g. when a delivery arrives,
+// while negative quantities write items off.
+type StockAdjustmentRequest struct {
+	Quantity int `json:"quantity" validate:"required" example:"10"`
+}
+
+// moneyPattern matches non-negative decimal amounts, digits are limited so that minor units fit int64.
+var moneyPattern = regexp.MustCompile(`^[0-9]{1,15}(\.[0-9]{1,3})?$`)
+
+// inRange checks that the number lies within the range and, when step is given, that it is a multiple of it.
+func inRange(val interface{}, min float64, max float64, step float64) bool {
+	var number float64
+	switch value := val.(type) {
+	case float64:
+		number = value
+	case int:
+		number = float64(value)
+	default:
+		return false
+	}
+	if number < min || number > max {
+		return false
+	}
+	return step == 0 || math.Mod(math.Abs(number), step) == 0
+}
+
+func init() {
+	// all invalid fields are reported at once, the global options are set once as requests validate concurrently
 	validate.Config(func(opt *validate.GlobalOption) {
 		opt.StopOnError = false
 	})
-	v := validate.New(s)
-	v.AddMessages(map[string]string{
-		"required": "The '{field}' is required",
+	validate.AddValidators(map[string]interface{}{
+		"date": func(val interface{}) bool {
+			date, ok := val.(string)
+			if !ok {
+				return false
+			}
+			_, err := time.Parse(DateLayout, date)
+			return err == nil
+		},
+		"dateTime": func(val interface{}) bool {
+			dateTime, ok := val.(string)
+			if !ok {
+				return false
+			}
+			_, err := time.Parse(time.RFC3339, dateTime)
+			return err == nil
+		},
+		"money": func(val interface{}) bool {
+			amount, ok := val.(string)
+			return ok && moneyPattern.MatchString(amount)
+		},
+		"sphere":      func(val interface{}) bool { return inRange(val, -30, 30, 0.25) },
+		"cylinder":    func(val interface{}) bool { return inRange(val, -10, 10, 0.25) },
+		"axis":        func(val interface{}) bool { return inRange(val, 0, 180, 0) },
+		"addition":    func(val interface{}) bool { return inRange(val, 0, 4, 0.25) },
+		"prism":       func(val interface{}) bool { return inRange(val, 0, 20, 0) },
+		"binocularPD": func(val interface{}) bool { return inRange(val, 40, 80, 0) },
+		"monocularPD": func(val interface{}) bool { return inRange(val, 20, 40, 0) },
+		"prismBase": func(val interface{}) bool {
+			base, ok := val.(string)
+			return ok && (base == "BI" || base == "BO" || base == "BU" || base == "BD")
+		},
+		"repairStatus": func(val interface{}) bool {
+			status, ok := val.(string)
+			return ok && database.RepairStatus(status).IsValid()
+		},
+		"contactChannel": func(val interface{}) bool {
+			channel, ok := val.(string)
+			return ok && database.ContactChannel(channel).IsValid()
+		},
+		"currency": func(val interface{}) bool {
+			code, ok := val.(string)
+			return ok && database.IsCurrency(strings.ToUpper(code))
+		},
+		"paymentMethod": func(val interface{}) bool {
+			method, ok := val.(string)
+			return ok && database.PaymentMethod(method).IsValid()
+		},
+		"vatRate": func(val interface{}) bool { return inRange(val, 0, 100, 0) },
+		"country": func(val interface{}) bool {
+			country, ok := val.(string)
+			return ok && len(country) == 2 && database.IsTelephoneRegion(strings.ToUpper(country))
+		},
 	})
-	return v
 }
 
-var valid *validator.Validate = validator.New()
+// validator validates requests reporting errors in the given language.
+type validator struct {
+	*validate.Validation
+	language string
+}
 
-type CreateRepairRequest struct {
-	Description string `json:"description" validate:"required"`
-	Cost        string `json:"cost"        validate:"required"`
-	ReportedAt  string `json:"reported_at" validate:"required,datetime=2006-01-02"`
+func newValidator(s interface{}, language string) *validator {
+	v := validate.New(s)
+	v.AddMessages(validationMessages[language])
+	v.Validate()
+	return &validator{Validation: v, language: language}
 }
 
-func registerValidators() {
-	valid.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-		if name == "-" {
-			return ""
-		}
-		return name
-	})
-	valid.RegisterStructValidation(func(sl validator.StructLevel) {}, CreateRepairRequest{})
+// addError reports the field as invalid for the reason identified by the code.
+func (v *validator) addError(field string, code string) {
+	v.AddError(field, code, validationMessage(v.language, code, field))
 }
 
-func validateRequest(r interface{}) map[string]string {
-	err := valid.Struct(r)
-	if err == nil {
+// fieldErrors returns errors of invalid fields sorted by their codes to keep responses stable.
+func (v *validator) fieldErrors() map[string][]FieldError {
+	if v.Errors.Empty() {
 		return nil
 	}
+	fields := make(map[string][]FieldError, len(v.Errors))
+	for field, messages := range v.Errors {
+		for code, message := range messages {
+			fields[field] = append(fields[field], FieldError{Code: code, Message: message})
+		}
+		sort.Slice(fields[field], func(i, j int) bool {
+			return fields[field][i].Code < fields[field][j].Code
+		})
+	}
+	return fields
+}
+
+// validateStruct validates the request struct by its validation tags.
+func validateStruct(s interface{}, language string) map[string][]FieldError {
+	return newValidator(s, language).fieldErrors()
+}
+
+// validateCustomerRequest validates customer details, the telephone number has to be one which can be
+// normalized, numbers given without the country calling code are considered to belong to the region.
+// Customers who prefer to be contacted by email or post have to give their email or address.
+func validateCustomerRequest(r *EditCustomerDetailsRequest, language string, region string) map[string][]FieldError {
+	v := newValidator(r, language)
+	if _, _, ok := database.NormalizeTelephoneNumber(r.TelephoneNumber, region); r.TelephoneNumber != "" && !ok {
+		v.addError("telephone_number", "telephone")
+	}
+	if birthDate, err := time.Parse(DateLayout, r.BirthDate); err == nil && birthDate.After(time.Now()) {
+		v.addError("birth_date", "pastDate")
+	}
+	switch database.ContactChannel(r.PreferredContact) {
+	case database.ContactEmail:
+		if r.Email == "" {
+			v.addError("email", "requiredForContact")
+		}
+	case database.ContactPost:
+		address := map[string]string{
+			"address.street":      r.Address.Street,
+			"address.city":        r.Address.City,
+			"address.postal_code": r.Address.PostalCode,
+		}
+		for field, value := range address {
+			if value == "" {
+				v.addError(field, "requiredForContact")
+			}
+		}
+	}
+	return v.fieldErrors()
+}
 
-	validationErrors := make(map[string]string)
+func (v *validator) validateEyePrescription(field string, eye EyePrescriptionRequest) {
+	if eye.Cylinder != 0 && eye.Axis == 0 {
+		v.addError(field+".axis", "requiredWithCylinder")
+	}
+	if eye.Prism != 0 && eye.Base == "" {
+		v.addError(field+".base", "requiredWithPrism")
+	}
+}
+
+func (v *validator) validateLensPower(lensPower LensPowerRequest, pd PupillaryDistanceRequest) {
+	v.validateEyePrescription("lens_power.right", lensPower.Right)
+	v.validateEyePrescription("lens_power.left", lensPower.Left)
+	if pd.Binocular == 0 && (pd.Right == 0 || pd.Left == 0) {
+		v.addError("pd", "pd")
+	}
+}
+
+// moneyCurrency returns the currency of the money, which defaults to the given one.
+func moneyCurrency(money MoneyRequest, currency string) string {
+	if money.Currency == "" {
+		return currency
+	}
+	return strings.ToUpper(money.Currency)
+}
+
+// validateMoney checks that the amount has no more decimal places than its currency, e.g. none for JPY,
+// and returns the money unless it is missing or invalid.
+func (v *validator) validateMoney(field string, money MoneyRequest, currency string) (database.Money, bool) {
+	if !moneyPattern.MatchString(money.Amount) || !database.IsCurrency(moneyCurrency(money, currency)) {
+		return database.Money{}, false
+	}
+	parsed, err := database.ParseMoney(money.Amount, moneyCurrency(money, currency))
+	if err != nil {
+		v.addError(field+".amount", "moneyScale")
+		return database.Money{}, false
+	}
+	return parsed, true
+}
+
+// validatePurchaseDetails validates purchase details. Lens power and PD may be omitted
+// when purchase references a prescription, as they are then taken from the prescription.
+// The discount has to be in the currency of the price and cannot exceed it.
+func (v *validator) validatePurchaseDetails(r *EditPurchaseRequest, currency string) {
+	if r.PrescriptionID == "" {
+		v.validateLensPower(r.LensPower, r.PD)
+	}
+	price, _ := v.validateMoney("price", r.Price, currency)
+	if discount, ok := v.validateMoney("discount", r.Discount, currency); ok && !discount.IsZero() {
+		if discount.Currency != moneyCurrency(r.Price, currency) {
+			v.addError("discount.currency", "priceCurrency")
+		} else if discount.Amount > price.Amount {
+			v.addError("discount.amount", "discount")
+		}
+	}
+}
+
+func validatePurchaseRequest(r *EditPurchaseRequest, language string, currency string) map[string][]FieldError {
+	v := newValidator(r, language)
+	v.validatePurchaseDetails(r, currency)
+	if r.FrameModel == "" {
+		v.addError("frame_model", "required")
+	}
+	if r.LensType == "" {
+		v.addError("lens_type", "required")
+	}
+	return v.fieldErrors()
+}
+
+// validateNewPurchaseRequest validates details of a new purchase, the frame model and the lens type are only
+// required when they are not picked from the catalog.
+func validateNewPurchaseRequest(r *CreatePurchaseRequest, language string, currency string) map[string][]FieldError {
+	v := newValidator(r, language)
+	v.validatePurchaseDetails(&r.EditPurchaseRequest, currency)
+	if r.FrameModel == "" && r.FrameID == "" {
+		v.addError("frame_model", "required")
+	}
+	if r.LensType == "" && r.LensTypeID == "" {
+		v.addError("lens_type", "required")
+	}
+	return v.fieldErrors()
+}
+
+// validateRepairRequest validates repair details, given in either CreateRepairRequest or PatchRepairRequest,
+// along with the cost they hold.
+func validateRepairRequest(
+	r interface{},
+	cost MoneyRequest,
+	language string,
+	currency string,
+) map[string][]FieldError {
+	v := newValidator(r, language)
+	if cost.Amount == "" {
+		v.addError("cost.amount", "required")
+	}
+	v.validateMoney("cost", cost, currency)
+	return v.fieldErrors()
+}
+
+// validateInvoiceRequest checks that at least one purchase or repair is invoiced, each of them once,
+// and that the VAT rate of repairs is given along with them.
+func validateInvoiceRequest(r *CreateInvoiceRequest, language string) map[string][]FieldError {
+	v := newValidator(r, language)
+	if len(r.PurchaseIDs) == 0 && len(r.RepairIDs) == 0 {
+		v.addError("purchase_ids", "invoiceItems")
+	}
+	for field, ids := range map[string][]string{"purchase_ids": r.PurchaseIDs, "repair_ids": r.RepairIDs} {
+		for i, id := range ids {
+			if !validate.IsUUID(id) {
+				v.addError(fmt.Sprintf("%s.%d", field, i), "uuid")
+			} else if slices.Index(ids, id) < i {
+				v.addError(fmt.Sprintf("%s.%d", field, i), "duplicated")
+			}
+		}
+	}
+	if r.RepairVATRate == nil && len(r.RepairIDs) > 0 {
+		v.addError("repair_vat_rate", "required")
+	} else if r.RepairVATRate != nil && !inRange(*r.RepairVATRate, 0, 100, 0) {
+		v.addError("repair_vat_rate", "vatRate")
+	}
+	if issuedAt, err := time.Parse(DateLayout, r.IssuedAt); err == nil && issuedAt.After(time.Now()) {
+		v.addError("issued_at", "pastDate")
+	}
+	return v.fieldErrors()
+}
+
+// validatePaymentRequest checks that exactly one of purchase and repair is paid and that the amount is
+// positive, as refunds are told apart by the flag instead of the sign.
+func validatePaymentRequest(r *CreatePaymentRequest, language string, currency string) map[string][]FieldError {
+	v := newValidator(r, language)
+	if (r.PurchaseID == "") == (r.RepairID == "") {
+		v.addError("purchase_id", "paidItem")
+	}
+	if r.Amount.Amount == "" {
+		v.addError("amount.amount", "required")
+	} else if amount, ok := v.validateMoney("amount", r.Amount, currency); ok && amount.Amount <= 0 {
+		v.addError("amount.amount", "positiveAmount")
+	}
+	if paidAt, err := time.Parse(time.RFC3339, r.PaidAt); err == nil && paidAt.After(time.Now()) {
+		v.addError("paid_at", "pastDate")
+	}
+	return v.fieldErrors()
+}
 
-	for _, err := range err.(validator.ValidationErrors) {
-		validationErrors[err.Field()] = err.Error()
+func validatePrescriptionRequest(r *CreatePrescriptionRequest, language string) map[string][]FieldError {
+	v := newValidator(r, language)
+	v.validateLensPower(r.LensPower, r.PD)
+	issuedAt, issuedErr := time.Parse(DateLayout, r.IssuedAt)
+	expiresAt, expiresErr := time.Parse(DateLayout, r.ExpiresAt)
+	if issuedErr == nil && expiresErr == nil && !expiresAt.After(issuedAt) {
+		v.addError("expires_at", "afterIssuedAt")
 	}
-	return validationErrors
+	return v.fieldErrors()
 }
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setETag tags the response with the version of the returned record, clients send it back in the
+// If-Match header when changing the record.
+func setETag(ctx *fiber.Ctx, version int) {
+	ctx.Set(fiber.HeaderETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion returns the record version given in the If-Match header. When the header is missing
+// or it is not a version tag, the problem to respond with is returned instead.
+func ifMatchVersion(ctx *fiber.Ctx) (error, *int) {
+	header := ctx.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return newProblem(
+			fiber.StatusPreconditionRequired,
+			ProblemTypePreconditionRequired,
+			"If-Match header with the ETag of the record is required",
+		), nil
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		tag = header
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return badRequest(fmt.Sprintf("given If-Match header '%s' is not a valid ETag", header)), nil
+	}
+	return nil, &version
+}